@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var checkIgnoreCmd = &cobra.Command{
+	Use:   "check-ignore <pathname>...",
+	Short: "git check-ignore",
+	Long:  ``,
+	Args:  cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			os.Exit(1)
+		}
+
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		noIndex, _ := cmd.Flags().GetBool("no-index")
+		options := command.CheckIgnoreOption{
+			Verbose: verbose,
+			NoIndex: noIndex,
+		}
+
+		checkIgnore, _ := command.NewCheckIgnore(dir, args, options, stdout, stderr)
+		code := checkIgnore.Run()
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkIgnoreCmd)
+	checkIgnoreCmd.Flags().BoolP("verbose", "v", false, "Show the matching exclude pattern for each path")
+	checkIgnoreCmd.Flags().Bool("no-index", false, "Don't look in the index when undertaking the checks")
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <repository> [directory]",
+	Short: "git clone",
+	Long:  ``,
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			os.Exit(1)
+		}
+
+		uploader, _ := cmd.Flags().GetString("upload-pack")
+		options := command.CloneOption{
+			Uploader: uploader,
+		}
+
+		clone, _ := command.NewClone(dir, args, options, stdout, stderr)
+		code := clone.Run()
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+	cloneCmd.Flags().String("upload-pack", "", "Program to run on the remote end to serve the clone")
+}
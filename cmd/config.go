@@ -57,13 +57,13 @@ func init() {
 	configCmd.PersistentFlags().Bool("system", false, "Use system config")
 
 	cobra.OnInitialize(func() {
-		if rootCmd.PersistentFlags().Lookup("local").Changed {
+		if configCmd.PersistentFlags().Lookup("local").Changed {
 			file = "local"
 		}
-		if rootCmd.PersistentFlags().Lookup("global").Changed {
+		if configCmd.PersistentFlags().Lookup("global").Changed {
 			file = "global"
 		}
-		if rootCmd.PersistentFlags().Lookup("system").Changed {
+		if configCmd.PersistentFlags().Lookup("system").Changed {
 			file = "system"
 		}
 	})
@@ -2,10 +2,16 @@ package cmd
 
 import (
 	"building-git/lib/command"
+	"building-git/lib/command/print_diff"
+	"building-git/lib/database"
+	"building-git/lib/diff"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var stage = ""
@@ -27,15 +33,43 @@ var diffCmd = &cobra.Command{
 		cached, _ := cmd.Flags().GetBool("cached")
 		staged, _ := cmd.Flags().GetBool("staged")
 
+		statOptions, err := parseStatOption(cmd)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			os.Exit(129)
+		}
+
 		patch, _ := cmd.Flags().GetBool("patch")
+		if statOptions.Any() && !cmd.Flags().Changed("patch") {
+			patch = false
+		}
 		noPatch, _ := cmd.Flags().GetBool("no-patch")
 		if noPatch {
 			patch = false
 		}
 		options := command.DiffOption{
-			Cached: cached || staged,
-			Patch:  patch,
-			Stage:  stage,
+			Cached:  cached || staged,
+			Patch:   patch,
+			Stage:   stage,
+			Renames: cmd.Flags().Changed("find-renames"),
+			Copies:  cmd.Flags().Changed("find-copies"),
+			Stat:    statOptions,
+		}
+
+		score, _ := cmd.Flags().GetString("find-renames")
+		if options.Copies {
+			score, _ = cmd.Flags().GetString("find-copies")
+		}
+		options.RenameScore, err = database.ParseRenameScore(score)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			os.Exit(129)
+		}
+
+		options.Algorithm, err = parseDiffAlgorithm(cmd)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			os.Exit(129)
 		}
 
 		diff, _ := command.NewDiff(dir, args, options, stdout, stderr)
@@ -44,11 +78,88 @@ var diffCmd = &cobra.Command{
 	},
 }
 
+func parseDiffAlgorithm(cmd *cobra.Command) (diff.Algorithm, error) {
+	if !cmd.Flags().Changed("diff-algorithm") {
+		return "", nil
+	}
+	name, _ := cmd.Flags().GetString("diff-algorithm")
+	return diff.ParseAlgorithm(name)
+}
+
+func addStatFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.String("stat", "", "generate a diffstat, optionally limited to <width>[,<name-width>] columns")
+	flags.Lookup("stat").NoOptDefVal = "0"
+	flags.Int("stat-width", 0, "limit the diffstat to the given number of columns")
+	flags.Int("stat-name-width", 0, "limit the file name part of the diffstat to the given number of columns")
+	flags.Int("stat-graph-width", 0, "limit the histogram part of the diffstat to the given number of columns")
+	flags.Bool("numstat", false, "show the numbers of added and deleted lines of each file in decimal notation")
+	flags.Bool("shortstat", false, "output only the last line of the diffstat")
+	flags.String("dirstat", "", "output the distribution of changes across directories")
+	flags.Lookup("dirstat").NoOptDefVal = "changes"
+}
+
+// parseStatOption reads the diffstat flags. Unless a width is given, the
+// diffstat fills the terminal when writing to one.
+func parseStatOption(cmd *cobra.Command) (print_diff.StatOption, error) {
+	flags := cmd.Flags()
+	options := print_diff.StatOption{Stat: flags.Changed("stat")}
+	options.NumStat, _ = flags.GetBool("numstat")
+	options.ShortStat, _ = flags.GetBool("shortstat")
+
+	if flags.Changed("dirstat") {
+		params, _ := flags.GetString("dirstat")
+		dirStat, err := print_diff.ParseDirStat(params)
+		if err != nil {
+			return options, err
+		}
+		options.DirStat = dirStat
+	}
+
+	if options.Stat {
+		value, _ := flags.GetString("stat")
+		widths := strings.Split(value, ",")
+		if len(widths) > 2 {
+			return options, fmt.Errorf("invalid --stat value: %s", value)
+		}
+		for i, target := range []*int{&options.Width, &options.NameWidth}[:len(widths)] {
+			n, err := strconv.Atoi(widths[i])
+			if err != nil || n < 0 {
+				return options, fmt.Errorf("invalid --stat value: %s", value)
+			}
+			*target = n
+		}
+	}
+	for name, target := range map[string]*int{
+		"stat-width":       &options.Width,
+		"stat-name-width":  &options.NameWidth,
+		"stat-graph-width": &options.GraphWidth,
+	} {
+		if flags.Changed(name) {
+			*target, _ = flags.GetInt(name)
+		}
+	}
+
+	if options.Width == 0 && term.IsTerminal(int(os.Stdout.Fd())) {
+		if columns, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+			options.Width = columns
+		}
+	}
+	return options, nil
+}
+
 func init() {
 	diffCmd.Flags().Bool("cached", false, "prints the changes staged for commit")
 	diffCmd.Flags().Bool("staged", false, "alias for --cached; prints the changes staged for commit")
 	diffCmd.Flags().Bool("patch", true, "generate patch (default is true)")
 	diffCmd.Flags().Bool("no-patch", false, "do not generate patch (default is false)")
+	diffCmd.Flags().StringP("find-renames", "M", "", "detect renames, optionally with a similarity threshold")
+	diffCmd.Flags().Lookup("find-renames").NoOptDefVal = "50%"
+	diffCmd.Flags().StringP("find-copies", "C", "", "detect copies as well as renames")
+	diffCmd.Flags().Lookup("find-copies").NoOptDefVal = "50%"
+
+	diffCmd.Flags().String("diff-algorithm", "", "choose a diff algorithm: myers, minimal, patience or histogram")
+	addStatFlags(diffCmd)
 
 	diffCmd.Flags().StringVar(&stage, "1", "1", "set stage to 1 (base)")
 	diffCmd.Flags().StringVar(&stage, "2", "2", "set stage to 2 (ours)")
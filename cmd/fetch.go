@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var fetchCmd = &cobra.Command{
+	Use:   "fetch [remote] [refspec...]",
+	Short: "git fetch",
+	Long:  ``,
+	Args:  cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			os.Exit(1)
+		}
+
+		uploader, _ := cmd.Flags().GetString("upload-pack")
+		options := command.FetchOption{
+			Uploader: uploader,
+		}
+
+		fetch, _ := command.NewFetch(dir, args, options, stdout, stderr)
+		code := fetch.Run()
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fetchCmd)
+	fetchCmd.Flags().String("upload-pack", "", "Program to run on the remote end to serve the fetch")
+}
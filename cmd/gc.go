@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "git gc",
+	Long:  ``,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			os.Exit(1)
+		}
+
+		options := command.RepackOption{
+			Delete: true,
+		}
+
+		repack, _ := command.NewRepack(dir, args, options, stdout, stderr)
+		code := repack.Run()
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+}
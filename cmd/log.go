@@ -2,20 +2,17 @@ package cmd
 
 import (
 	"building-git/lib/command"
+	"building-git/lib/database"
 	"building-git/lib/pager"
+	"building-git/lib/repository"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
 
-var LogFormat = map[string]bool{
-	"oneline": true,
-	"short":   true,
-	"medium":  true,
-}
-
 var logCmd = &cobra.Command{
 	Use:   "log",
 	Short: "git log",
@@ -31,7 +28,12 @@ var logCmd = &cobra.Command{
 		}
 
 		abbrevCommit, _ := cmd.Flags().GetBool("abbrev-commit")
-		pretty, _ := cmd.Flags().GetString("pretty")
+		pretty, date, err := parsePrettyOption(cmd)
+		if err != nil {
+			fmt.Fprintf(stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+
 		oneline, _ := cmd.Flags().GetBool("oneline")
 		if oneline {
 			pretty = "oneline"
@@ -44,6 +46,12 @@ var logCmd = &cobra.Command{
 			decorate = "no"
 		}
 
+		filter, err := parseLogFilter(cmd)
+		if err != nil {
+			fmt.Fprintf(stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+
 		isTTY := term.IsTerminal(int(os.Stdout.Fd()))
 		writer, cleanup := pager.SetupPager(isTTY, stdout, stderr)
 		defer cleanup()
@@ -51,8 +59,24 @@ var logCmd = &cobra.Command{
 		options := command.LogOption{
 			Abbrev:   abbrevCommit,
 			Format:   pretty,
+			Date:     date,
 			Decorate: decorate,
 			IsTty:    isTTY,
+			Filter:   filter,
+		}
+
+		options.Stat, err = parseStatOption(cmd)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			os.Exit(129)
+		}
+		options.Graph, _ = cmd.Flags().GetBool("graph")
+		options.Follow, _ = cmd.Flags().GetBool("follow")
+
+		options.Algorithm, err = parseDiffAlgorithm(cmd)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			os.Exit(129)
 		}
 
 		cc, _ := cmd.Flags().GetBool("cc")
@@ -61,22 +85,107 @@ var logCmd = &cobra.Command{
 			options.Patch = true
 		}
 
-		status, _ := command.NewLog(dir, args, options, writer, stderr)
+		status, err := command.NewLog(dir, args, options, writer, stderr)
+		if err != nil {
+			fmt.Fprintf(stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
 		code := status.Run()
 		os.Exit(code)
 	},
 }
 
+func parsePrettyOption(cmd *cobra.Command) (string, string, error) {
+	pretty, _ := cmd.Flags().GetString("pretty")
+	if cmd.Flags().Changed("format") {
+		pretty, _ = cmd.Flags().GetString("format")
+		if !command.PRETTY_FORMATS[pretty] {
+			pretty = "tformat:" + pretty
+		}
+	}
+	if !command.IsPrettyFormat(pretty) {
+		return "", "", fmt.Errorf("invalid --pretty format: %s", pretty)
+	}
+
+	date, _ := cmd.Flags().GetString("date")
+	if _, ok := database.DATE_MODES[date]; !ok {
+		return "", "", fmt.Errorf("unknown date format %s", date)
+	}
+	return pretty, date, nil
+}
+
+func parseLogFilter(cmd *cobra.Command) (repository.RevListFilter, error) {
+	flags := cmd.Flags()
+	filter := repository.RevListFilter{}
+
+	if flags.Changed("max-count") {
+		n, _ := flags.GetInt("max-count")
+		filter.MaxCount = &n
+	}
+
+	now := time.Now()
+	for _, name := range []string{"since", "after", "until", "before"} {
+		if !flags.Changed(name) {
+			continue
+		}
+		value, _ := flags.GetString(name)
+		date, err := repository.ParseDate(value, now)
+		if err != nil {
+			return filter, err
+		}
+		if name == "since" || name == "after" {
+			filter.Since = date
+		} else {
+			filter.Until = date
+		}
+	}
+
+	filter.Authors, _ = flags.GetStringArray("author")
+	filter.Committers, _ = flags.GetStringArray("committer")
+	filter.Grep, _ = flags.GetStringArray("grep")
+	filter.AllMatch, _ = flags.GetBool("all-match")
+	filter.IgnoreCase, _ = flags.GetBool("regexp-ignore-case")
+	filter.FirstParent, _ = flags.GetBool("first-parent")
+	filter.Merges, _ = flags.GetBool("merges")
+	filter.NoMerges, _ = flags.GetBool("no-merges")
+	filter.Pickaxe, _ = flags.GetString("pickaxe")
+	filter.PickaxeRegex, _ = flags.GetBool("pickaxe-regex")
+	filter.DiffGrep, _ = flags.GetString("diff-grep")
+
+	return filter, nil
+}
+
 func init() {
 	logCmd.Flags().Bool("abbrev-commit", false, "Show only the first few characters of the SHA-1 checksum.")
 	logCmd.Flags().String("pretty", "medium", "Set log message format")
 	logCmd.Flags().Lookup("pretty").NoOptDefVal = "medium"
 
-	// logCmd.Flags().String("format", "medium", "Alias for --pretty")
+	logCmd.Flags().String("format", "", "Pretty-print the contents of the commit logs in a given format")
+	logCmd.Flags().String("date", "default", "Set the date format: default, iso, iso-strict, rfc, short, raw, unix or relative")
 	logCmd.Flags().Bool("oneline", false, "Shorthand for --pretty=oneline --abbrev-commit")
 	logCmd.Flags().String("decorate", "auto", "Decorate log format")
 	logCmd.Flags().Lookup("decorate").NoOptDefVal = "short"
 	logCmd.Flags().Bool("no-decorate", false, "Disable decorate")
+	logCmd.Flags().Bool("graph", false, "Draw a text-based graphical representation of the commit history")
+	logCmd.Flags().Bool("follow", false, "Continue listing the history of a file beyond renames")
+	logCmd.Flags().String("diff-algorithm", "", "choose a diff algorithm: myers, minimal, patience or histogram")
+	addStatFlags(logCmd)
+	logCmd.Flags().IntP("max-count", "n", -1, "Limit the number of commits to output")
+	logCmd.Flags().String("since", "", "Show commits more recent than a specific date")
+	logCmd.Flags().String("after", "", "Alias for --since")
+	logCmd.Flags().String("until", "", "Show commits older than a specific date")
+	logCmd.Flags().String("before", "", "Alias for --until")
+	logCmd.Flags().StringArray("author", nil, "Limit the commits output to ones with author matching the pattern")
+	logCmd.Flags().StringArray("committer", nil, "Limit the commits output to ones with committer matching the pattern")
+	logCmd.Flags().StringArray("grep", nil, "Limit the commits output to ones with a log message matching the pattern")
+	logCmd.Flags().Bool("all-match", false, "Limit the commits output to ones that match all given --grep")
+	logCmd.Flags().BoolP("regexp-ignore-case", "i", false, "Match the regular expression limiting patterns without regard to letter case")
+	logCmd.Flags().Bool("first-parent", false, "Follow only the first parent commit upon seeing a merge commit")
+	logCmd.Flags().Bool("merges", false, "Print only merge commits")
+	logCmd.Flags().Bool("no-merges", false, "Do not print commits with more than one parent")
+	logCmd.Flags().StringP("pickaxe", "S", "", "Look for differences that change the number of occurrences of the string")
+	logCmd.Flags().Bool("pickaxe-regex", false, "Treat the string given to -S as a regular expression")
+	logCmd.Flags().StringP("diff-grep", "G", "", "Look for differences whose added or removed lines match the regular expression")
 	logCmd.Flags().Bool("cc", false, "Produce dense combined diff output for merge commits")
 
 	rootCmd.AddCommand(logCmd)
@@ -16,7 +16,7 @@ var mergeCmd = &cobra.Command{
 	Use:   "merge",
 	Short: "git merge",
 	Long:  ``,
-	Args:  cobra.ExactArgs(0),
+	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		stdout := cmd.OutOrStdout()
 		stderr := cmd.ErrOrStderr()
@@ -30,6 +30,7 @@ var mergeCmd = &cobra.Command{
 		message, _ := cmd.Flags().GetString("message")
 		file, _ := cmd.Flags().GetString("file")
 		edit, _ := cmd.Flags().GetBool("edit")
+		strategy, _ := cmd.Flags().GetString("strategy")
 		isTTY := term.IsTerminal(int(os.Stdout.Fd()))
 		options := command.MergeOption{
 			Mode: command.MergeMode(mode),
@@ -37,8 +38,9 @@ var mergeCmd = &cobra.Command{
 				Message: message,
 				File:    file,
 			},
-			Edit:  edit,
-			IsTTY: isTTY,
+			Strategy: strategy,
+			Edit:     edit,
+			IsTTY:    isTTY,
 		}
 		merge, _ := command.NewMerge(dir, args, options, stdout, stderr)
 		code := merge.Run()
@@ -53,5 +55,6 @@ func init() {
 
 	mergeCmd.Flags().StringP("message", "m", "", "Specify a message to associate with the command execution")
 	mergeCmd.Flags().StringP("file", "F", "", "Specify a file to be used with the command")
+	mergeCmd.Flags().StringP("strategy", "s", "", "Use the given merge strategy (resolve or recursive)")
 	mergeCmd.Flags().BoolP("edit", "e", false, "Invoke an editor before committing successful mechanical merge to further edit the auto-generated merge message")
 }
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var packRefsCmd = &cobra.Command{
+	Use:   "pack-refs",
+	Short: "git pack-refs",
+	Long:  ``,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			os.Exit(1)
+		}
+
+		all, _ := cmd.Flags().GetBool("all")
+		noPrune, _ := cmd.Flags().GetBool("no-prune")
+		options := command.PackRefsOption{
+			All:     all,
+			NoPrune: noPrune,
+		}
+
+		packRefs, _ := command.NewPackRefs(dir, args, options, stdout, stderr)
+		code := packRefs.Run()
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(packRefsCmd)
+	packRefsCmd.Flags().Bool("all", false, "Pack all refs instead of only tags")
+	packRefsCmd.Flags().Bool("no-prune", false, "Keep the loose refs after packing them")
+}
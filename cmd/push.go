@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push [remote] [refspec...]",
+	Short: "git push",
+	Long:  ``,
+	Args:  cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			os.Exit(1)
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		receiver, _ := cmd.Flags().GetString("receive-pack")
+		options := command.PushOption{
+			Force:    force,
+			Receiver: receiver,
+		}
+
+		push, _ := command.NewPush(dir, args, options, stdout, stderr)
+		code := push.Run()
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pushCmd)
+	pushCmd.Flags().BoolP("force", "f", false, "Force updates")
+	pushCmd.Flags().String("receive-pack", "", "Program to run on the remote end to receive the push")
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"building-git/lib/editor"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var rebaseCmd = &cobra.Command{
+	Use:   "rebase [-i] [--onto <newbase>] <upstream>",
+	Short: "git rebase",
+	Long:  ``,
+	Args:  cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			os.Exit(1)
+		}
+
+		mode := command.Run
+		for _, m := range []command.MergeMode{command.Continue, command.Abort, command.Quit, command.Skip} {
+			if set, _ := cmd.Flags().GetBool(string(m)); set {
+				mode = m
+			}
+		}
+		onto, _ := cmd.Flags().GetString("onto")
+		interactive, _ := cmd.Flags().GetBool("interactive")
+		autosquash, _ := cmd.Flags().GetBool("autosquash")
+		options := command.RebaseOption{
+			SequencingOption: command.SequencingOption{
+				Mode:      mode,
+				EditorCmd: editor.EditorCmdFactory(),
+				IsTTY:     term.IsTerminal(int(os.Stdout.Fd())),
+			},
+			Onto:        onto,
+			Interactive: interactive,
+			Autosquash:  autosquash,
+		}
+
+		rebase, _ := command.NewRebase(dir, args, options, stdout, stderr)
+		code := rebase.Run()
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rebaseCmd)
+	rebaseCmd.Flags().String("onto", "", "Starting point at which to create the new commits")
+	rebaseCmd.Flags().BoolP("interactive", "i", false, "Make a list of the commits to be rebased and edit it before rebasing")
+	rebaseCmd.Flags().Bool("autosquash", false, "Move fixup! and squash! commits after the commits they modify")
+	rebaseCmd.Flags().Bool(string(command.Continue), false, "Resume command execution from a saved state")
+	rebaseCmd.Flags().Bool(string(command.Abort), false, "Cancel the current operation and revert to the pre-operation state")
+	rebaseCmd.Flags().Bool(string(command.Quit), false, "Forget about the current operation in progress")
+	rebaseCmd.Flags().Bool(string(command.Skip), false, "Skip the current commit and continue")
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var reflogCmd = &cobra.Command{
+	Use:   "reflog [show|expire|delete] [<args>]",
+	Short: "git reflog",
+	Long:  ``,
+	Args:  cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			os.Exit(1)
+		}
+
+		expire, _ := cmd.Flags().GetString("expire")
+		all, _ := cmd.Flags().GetBool("all")
+		options := command.ReflogOption{
+			Expire: expire,
+			All:    all,
+		}
+
+		reflog, _ := command.NewReflog(dir, args, options, stdout, stderr)
+		code := reflog.Run(time.Now())
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reflogCmd)
+	reflogCmd.Flags().String("expire", "", "Prune entries older than the specified time")
+	reflogCmd.Flags().Bool("all", false, "Process the reflogs of all references")
+}
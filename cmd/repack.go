@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var repackCmd = &cobra.Command{
+	Use:   "repack",
+	Short: "git repack",
+	Long:  ``,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			os.Exit(1)
+		}
+
+		deleteFlag, _ := cmd.Flags().GetBool("delete")
+		options := command.RepackOption{
+			Delete: deleteFlag,
+		}
+
+		repack, _ := command.NewRepack(dir, args, options, stdout, stderr)
+		code := repack.Run()
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(repackCmd)
+	repackCmd.Flags().BoolP("delete", "d", false, "Remove redundant packs and loose objects after packing")
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"building-git/lib/editor"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var revertCmd = &cobra.Command{
+	Use:   "revert <commit>...",
+	Short: "git revert",
+	Long:  ``,
+	Args:  cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			os.Exit(1)
+		}
+
+		mode := command.Run
+		for _, m := range []command.MergeMode{command.Continue, command.Abort, command.Quit} {
+			if set, _ := cmd.Flags().GetBool(string(m)); set {
+				mode = m
+			}
+		}
+		options := command.RevertOption{
+			Mode:      mode,
+			EditorCmd: editor.EditorCmdFactory(),
+			IsTTY:     term.IsTerminal(int(os.Stdout.Fd())),
+		}
+
+		revert, _ := command.NewRevert(dir, args, options, stdout, stderr)
+		code := revert.Run()
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(revertCmd)
+	revertCmd.Flags().Bool(string(command.Continue), false, "Resume command execution from a saved state")
+	revertCmd.Flags().Bool(string(command.Abort), false, "Cancel the current operation and revert to the pre-operation state")
+	revertCmd.Flags().Bool(string(command.Quit), false, "Forget about the current operation in progress")
+}
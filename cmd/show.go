@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"building-git/lib/pager"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show",
+	Short: "git show",
+	Long:  ``,
+	Args:  cobra.MinimumNArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			os.Exit(1)
+		}
+
+		abbrevCommit, _ := cmd.Flags().GetBool("abbrev-commit")
+		pretty, date, err := parsePrettyOption(cmd)
+		if err != nil {
+			fmt.Fprintf(stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+
+		oneline, _ := cmd.Flags().GetBool("oneline")
+		if oneline {
+			pretty = "oneline"
+			abbrevCommit = true
+		}
+
+		decorate, _ := cmd.Flags().GetString("decorate")
+		noDecorate, _ := cmd.Flags().GetBool("no-decorate")
+		if noDecorate {
+			decorate = "no"
+		}
+
+		statOptions, err := parseStatOption(cmd)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			os.Exit(129)
+		}
+
+		patch, _ := cmd.Flags().GetBool("patch")
+		if statOptions.Any() && !cmd.Flags().Changed("patch") {
+			patch = false
+		}
+		noPatch, _ := cmd.Flags().GetBool("no-patch")
+		if noPatch {
+			patch = false
+		}
+
+		isTTY := term.IsTerminal(int(os.Stdout.Fd()))
+		writer, cleanup := pager.SetupPager(isTTY, stdout, stderr)
+		defer cleanup()
+
+		options := command.ShowOption{
+			Abbrev:   abbrevCommit,
+			Format:   pretty,
+			Date:     date,
+			Decorate: decorate,
+			IsTty:    isTTY,
+			Patch:    patch,
+			Stat:     statOptions,
+		}
+
+		options.Algorithm, err = parseDiffAlgorithm(cmd)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			os.Exit(129)
+		}
+
+		show, err := command.NewShow(dir, args, options, writer, stderr)
+		if err != nil {
+			fmt.Fprintf(stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+		code := show.Run()
+		os.Exit(code)
+	},
+}
+
+func init() {
+	showCmd.Flags().Bool("abbrev-commit", false, "Show only the first few characters of the SHA-1 checksum.")
+	showCmd.Flags().String("pretty", "medium", "Set commit message format")
+	showCmd.Flags().Lookup("pretty").NoOptDefVal = "medium"
+
+	showCmd.Flags().String("format", "", "Pretty-print the contents of the commits in a given format")
+	showCmd.Flags().String("date", "default", "Set the date format: default, iso, iso-strict, rfc, short, raw, unix or relative")
+	showCmd.Flags().Bool("oneline", false, "Shorthand for --pretty=oneline --abbrev-commit")
+	showCmd.Flags().String("decorate", "auto", "Decorate commit format")
+	showCmd.Flags().Lookup("decorate").NoOptDefVal = "short"
+	showCmd.Flags().Bool("no-decorate", false, "Disable decorate")
+	showCmd.Flags().BoolP("patch", "p", true, "generate patch (default is true)")
+	showCmd.Flags().BoolP("no-patch", "s", false, "do not generate patch (default is false)")
+	showCmd.Flags().String("diff-algorithm", "", "choose a diff algorithm: myers, minimal, patience or histogram")
+	addStatFlags(showCmd)
+
+	rootCmd.AddCommand(showCmd)
+}
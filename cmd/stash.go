@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var stashCmd = &cobra.Command{
+	Use:   "stash [push|list|show|apply|pop|drop] [<args>]",
+	Short: "git stash",
+	Long:  ``,
+	Args:  cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			os.Exit(1)
+		}
+
+		untracked, _ := cmd.Flags().GetBool("include-untracked")
+		message, _ := cmd.Flags().GetString("message")
+		patch, _ := cmd.Flags().GetBool("patch")
+		index, _ := cmd.Flags().GetBool("index")
+		options := command.StashOption{
+			Untracked: untracked,
+			Message:   message,
+			Patch:     patch,
+			Index:     index,
+		}
+
+		stash, _ := command.NewStash(dir, args, options, stdout, stderr)
+		code := stash.Run(time.Now())
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(stashCmd)
+	stashCmd.Flags().BoolP("include-untracked", "u", false, "Include untracked files in the stash")
+	stashCmd.Flags().StringP("message", "m", "", "Use the given message to describe the stash")
+	stashCmd.Flags().BoolP("patch", "p", false, "Show the changes as a patch")
+	stashCmd.Flags().Bool("index", false, "Restore the index changes as well as the working tree")
+}
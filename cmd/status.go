@@ -25,8 +25,10 @@ var statusCmd = &cobra.Command{
 		}
 
 		porcelainFlag, _ := cmd.Flags().GetBool("porcelain")
+		ignoredFlag, _ := cmd.Flags().GetBool("ignored")
 		options := command.StatusOption{
 			Porcelain: porcelainFlag,
+			Ignored:   ignoredFlag,
 		}
 
 		status, _ := command.NewStatus(dir, args, options, stdout, stderr)
@@ -37,5 +39,6 @@ var statusCmd = &cobra.Command{
 
 func init() {
 	statusCmd.Flags().BoolVar(&porcelain, "porcelain", false, "use porcelain format")
+	statusCmd.Flags().Bool("ignored", false, "show ignored files")
 	rootCmd.AddCommand(statusCmd)
 }
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"building-git/lib/command/write_commit"
+	"building-git/lib/editor"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag [<tagname> [<commit>]]",
+	Short: "git tag",
+	Long:  ``,
+	Args:  cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			os.Exit(1)
+		}
+
+		message, _ := cmd.Flags().GetString("message")
+		file, _ := cmd.Flags().GetString("file")
+		annotate, _ := cmd.Flags().GetBool("annotate")
+		deleteFlag, _ := cmd.Flags().GetBool("delete")
+		forceFlag, _ := cmd.Flags().GetBool("force")
+		options := command.TagOption{
+			ReadOption: write_commit.ReadOption{
+				Message: message,
+				File:    file,
+			},
+			Annotate:  annotate,
+			Delete:    deleteFlag,
+			Force:     forceFlag,
+			IsTTY:     term.IsTerminal(int(os.Stdout.Fd())),
+			EditorCmd: editor.EditorCmdFactory(),
+		}
+
+		tag, _ := command.NewTag(dir, args, options, stdout, stderr)
+		code := tag.Run(time.Now())
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	tagCmd.Flags().BoolP("annotate", "a", false, "Make an unsigned, annotated tag object")
+	tagCmd.Flags().StringP("message", "m", "", "Use the given tag message")
+	tagCmd.Flags().StringP("file", "F", "", "Take the tag message from the given file")
+	tagCmd.Flags().BoolP("delete", "d", false, "Delete existing tags with the given names")
+	tagCmd.Flags().BoolP("force", "f", false, "Replace an existing tag with the given name")
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"building-git/lib/command"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var uploadPackCmd = &cobra.Command{
+	Use:   "upload-pack <directory>",
+	Short: "git upload-pack",
+	Long:  ``,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		stdin := cmd.InOrStdin()
+		stdout := cmd.OutOrStdout()
+		stderr := cmd.ErrOrStderr()
+
+		uploadPack, err := command.NewUploadPack(args, stdin, stdout, stderr)
+		if err != nil {
+			fmt.Fprintf(stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+		code := uploadPack.Run()
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(uploadPackCmd)
+}
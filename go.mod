@@ -7,7 +7,7 @@ require github.com/spf13/cobra v1.7.0
 require golang.org/x/term v0.12.0 // indirect
 
 require (
-	github.com/fatih/color v1.15.0 // indirect
+	github.com/fatih/color v1.15.0
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.17 // indirect
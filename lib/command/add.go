@@ -5,6 +5,7 @@ import (
 	"building-git/lib/repository"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
 )
 
@@ -26,14 +27,18 @@ func Add(dir string, args []string, stdout, stderr io.Writer) int {
 		return 128
 	}
 
-	var paths []string
+	var paths, ignored []string
 	for _, path := range args {
 		absPath, err := filepath.Abs(filepath.Join(dir, path))
 		if err != nil {
 			fmt.Fprintf(stderr, "fatal: %v", err)
 			return 128
 		}
-		files, err := repo.Workspace.ListFiles(absPath)
+		if isIgnoredPath(repo, rootPath, absPath) {
+			ignored = append(ignored, path)
+			continue
+		}
+		files, err := repo.Workspace.ListFiles(absPath, repo.Index)
 		if err != nil {
 			fmt.Fprintf(stderr, "fatal: %v", err)
 			return 128
@@ -59,5 +64,25 @@ func Add(dir string, args []string, stdout, stderr io.Writer) int {
 		repo.Index.Add(pathname, blob.Oid(), stat)
 	}
 	repo.Index.WriteUpdates()
+
+	if len(ignored) > 0 {
+		fmt.Fprintln(stderr, "The following paths are ignored by one of your .gitignore files:")
+		for _, path := range ignored {
+			fmt.Fprintln(stderr, path)
+		}
+		return 1
+	}
 	return 0
 }
+
+func isIgnoredPath(repo *repository.Repository, rootPath, absPath string) bool {
+	relPath, err := filepath.Rel(rootPath, absPath)
+	if err != nil || repo.Index.IsTracked(relPath) {
+		return false
+	}
+	stat, err := os.Stat(absPath)
+	if err != nil {
+		return false
+	}
+	return repo.Workspace.IsIgnored(relPath, stat.IsDir())
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"building-git/lib/database"
 	"building-git/lib/repository"
 	"os"
 	"reflect"
@@ -259,3 +260,73 @@ func TestFailsIfIndexIsLocked(t *testing.T) {
 	}
 	assertIndex(t, tmpDir, []*indexEntry{})
 }
+
+func TestAddSkipsIgnoredFiles(t *testing.T) {
+	tmpDir, stdout, stderr := setupTestEnvironment(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeFile(t, tmpDir, ".gitignore", "*.o\nbuild/\n")
+	writeFile(t, tmpDir, "main.c", "")
+	writeFile(t, tmpDir, "main.o", "")
+	writeFile(t, tmpDir, "build/out.bin", "")
+	writeFile(t, tmpDir, "lib/util.o", "")
+
+	Add(tmpDir, []string{"."}, stdout, stderr)
+
+	assertIndex(t, tmpDir, []*indexEntry{
+		{mode: 0o100644, path: ".gitignore"},
+		{mode: 0o100644, path: "main.c"},
+	})
+}
+
+func TestAddStagesTrackedFilesMatchingIgnoreRules(t *testing.T) {
+	tmpDir, stdout, stderr := setupTestEnvironment(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeFile(t, tmpDir, "keep.log", "first")
+	writeFile(t, tmpDir, "build/keep.bin", "first")
+	Add(tmpDir, []string{"."}, stdout, stderr)
+
+	writeFile(t, tmpDir, ".gitignore", "*.log\nbuild/\n")
+	writeFile(t, tmpDir, "keep.log", "second")
+	writeFile(t, tmpDir, "new.log", "")
+	writeFile(t, tmpDir, "build/keep.bin", "second")
+	writeFile(t, tmpDir, "build/new.bin", "")
+	Add(tmpDir, []string{"."}, stdout, stderr)
+
+	assertIndex(t, tmpDir, []*indexEntry{
+		{mode: 0o100644, path: ".gitignore"},
+		{mode: 0o100644, path: "build/keep.bin"},
+		{mode: 0o100644, path: "keep.log"},
+	})
+	r := repo(t, tmpDir)
+	r.Index.Load()
+	expected, _ := r.Database.HashObject(database.NewBlob("second"))
+	for _, path := range []string{"keep.log", "build/keep.bin"} {
+		if got := r.Index.EntryForPath(path, "0").Oid(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	}
+}
+
+func TestAddRefusesExplicitlyIgnoredPaths(t *testing.T) {
+	tmpDir, stdout, stderr := setupTestEnvironment(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeFile(t, tmpDir, ".gitignore", "*.o\n")
+	writeFile(t, tmpDir, "main.c", "")
+	writeFile(t, tmpDir, "main.o", "")
+
+	code := Add(tmpDir, []string{"main.c", "main.o"}, stdout, stderr)
+
+	if code != 1 {
+		t.Errorf("want %d, but got %d", 1, code)
+	}
+	expected := "The following paths are ignored by one of your .gitignore files:\nmain.o\n"
+	if got := stderr.String(); got != expected {
+		t.Errorf("want %q, but got %q", expected, got)
+	}
+	assertIndex(t, tmpDir, []*indexEntry{
+		{mode: 0o100644, path: "main.c"},
+	})
+}
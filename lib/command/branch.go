@@ -110,10 +110,11 @@ func (b *Branch) extendedBranchInfo(ref *repository.SymRef, maxWidth int) string
 func (b *Branch) createBranch() error {
 	branchName := b.args[0]
 	startOid := ""
+	startPoint := repository.HEAD
 	var revision *repository.Revision
 	var err error
 	if len(b.args) > 1 {
-		startPoint := b.args[1]
+		startPoint = b.args[1]
 		revision = repository.NewRevision(b.repo, startPoint)
 		startOid, err = revision.Resolve(repository.COMMIT)
 	} else {
@@ -133,7 +134,7 @@ func (b *Branch) createBranch() error {
 		return err
 	}
 
-	err = b.repo.Refs.CreateBranch(branchName, startOid)
+	err = b.repo.Refs.CreateBranch(branchName, startOid, "branch: Created from "+startPoint)
 	if err != nil {
 		if _, ok := err.(*repository.InvalidBranchError); ok {
 			fmt.Fprintf(b.stderr, "fatal: %v", err)
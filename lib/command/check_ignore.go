@@ -0,0 +1,88 @@
+package command
+
+import (
+	"building-git/lib/repository"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type CheckIgnoreOption struct {
+	Verbose bool
+	NoIndex bool
+}
+
+type CheckIgnore struct {
+	rootPath string
+	args     []string
+	options  CheckIgnoreOption
+	repo     *repository.Repository
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+func NewCheckIgnore(dir string, args []string, options CheckIgnoreOption, stdout, stderr io.Writer) (*CheckIgnore, error) {
+	rootPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(rootPath)
+
+	return &CheckIgnore{
+		rootPath: rootPath,
+		args:     args,
+		options:  options,
+		repo:     repo,
+		stdout:   stdout,
+		stderr:   stderr,
+	}, nil
+}
+
+func (c *CheckIgnore) Run() int {
+	if len(c.args) == 0 {
+		fmt.Fprintln(c.stderr, "fatal: no path specified")
+		return 128
+	}
+	if !c.options.NoIndex {
+		c.repo.Index.Load()
+	}
+
+	matched := false
+	for _, arg := range c.args {
+		path, err := filepath.Rel(c.rootPath, filepath.Join(c.rootPath, arg))
+		if err != nil || strings.HasPrefix(path, "..") {
+			fmt.Fprintf(c.stderr, "fatal: %s: '%s' is outside repository\n", arg, arg)
+			return 128
+		}
+		if !c.options.NoIndex && c.repo.Index.IsTrackedFile(path) {
+			continue
+		}
+
+		pattern := c.repo.Workspace.Ignore().Match(path, c.isDirectory(arg))
+		if pattern == nil || pattern.IsNegated() && !c.options.Verbose {
+			continue
+		}
+		matched = true
+
+		if c.options.Verbose {
+			fmt.Fprintf(c.stdout, "%s:%d:%s\t%s\n", pattern.Source, pattern.Line, pattern.Pattern, arg)
+		} else {
+			fmt.Fprintln(c.stdout, arg)
+		}
+	}
+
+	if matched {
+		return 0
+	}
+	return 1
+}
+
+func (c *CheckIgnore) isDirectory(arg string) bool {
+	if strings.HasSuffix(arg, "/") {
+		return true
+	}
+	stat, err := os.Stat(filepath.Join(c.rootPath, arg))
+	return err == nil && stat.IsDir()
+}
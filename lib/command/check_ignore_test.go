@@ -0,0 +1,100 @@
+package command
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestCheckIgnore(t *testing.T) {
+	setup := func() (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+		writeFile(t, tmpDir, ".gitignore", "*.o\nbuild/\n!keep.o\n")
+		writeFile(t, tmpDir, "docs/.gitignore", "*.html\n")
+		writeFile(t, tmpDir, ".git/info/exclude", "secret.txt\n")
+		return
+	}
+
+	checkIgnore := func(tmpDir string, args []string, options CheckIgnoreOption, stdout, stderr *bytes.Buffer) int {
+		cmd, _ := NewCheckIgnore(tmpDir, args, options, stdout, stderr)
+		return cmd.Run()
+	}
+
+	t.Run("prints ignored paths", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		status := checkIgnore(tmpDir, []string{"main.o", "main.c", "build/out", "keep.o"}, CheckIgnoreOption{}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected := "main.o\nbuild/out\n"
+		if stdout.String() != expected {
+			t.Errorf("want %q, but got %q", expected, stdout.String())
+		}
+	})
+
+	t.Run("prints the matching pattern with -v", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		args := []string{"main.o", "docs/index.html", "secret.txt", "keep.o"}
+		checkIgnore(tmpDir, args, CheckIgnoreOption{Verbose: true}, stdout, stderr)
+
+		expected := ".gitignore:1:*.o\tmain.o\n" +
+			"docs/.gitignore:1:*.html\tdocs/index.html\n" +
+			".git/info/exclude:1:secret.txt\tsecret.txt\n" +
+			".gitignore:3:!keep.o\tkeep.o\n"
+		if stdout.String() != expected {
+			t.Errorf("want %q, but got %q", expected, stdout.String())
+		}
+	})
+
+	t.Run("exits with 1 when nothing is ignored", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		status := checkIgnore(tmpDir, []string{"main.c"}, CheckIgnoreOption{}, stdout, stderr)
+
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		if stdout.String() != "" {
+			t.Errorf("want %q, but got %q", "", stdout.String())
+		}
+	})
+
+	t.Run("skips tracked files unless --no-index is given", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "lib.o", "object")
+		repo := repo(t, tmpDir)
+		repo.Index.LoadForUpdate()
+		stat, _ := repo.Workspace.StatFile("lib.o")
+		repo.Index.Add("lib.o", "0000000000000000000000000000000000000000", stat)
+		repo.Index.WriteUpdates()
+
+		if status := checkIgnore(tmpDir, []string{"lib.o"}, CheckIgnoreOption{}, stdout, stderr); status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		if status := checkIgnore(tmpDir, []string{"lib.o"}, CheckIgnoreOption{NoIndex: true}, stdout, stderr); status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+	})
+
+	t.Run("fails without a path", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		status := checkIgnore(tmpDir, []string{}, CheckIgnoreOption{}, stdout, stderr)
+
+		if status != 128 {
+			t.Errorf("want %d, but got %d", 128, status)
+		}
+		if expected := "fatal: no path specified\n"; stderr.String() != expected {
+			t.Errorf("want %q, but got %q", expected, stderr.String())
+		}
+	})
+}
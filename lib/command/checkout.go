@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"strconv"
 )
 
 type CheckOutOption struct {
@@ -52,6 +53,12 @@ func NewCheckOut(dir string, args []string, options CheckOutOption, stdout, stde
 
 func (c *CheckOut) Run() int {
 	c.target = c.args[0]
+	if match := repository.PREVIOUS.FindStringSubmatch(c.target); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		if name, _ := c.repo.Refs.PreviousBranch(n); name != "" {
+			c.target = name
+		}
+	}
 	currentRef, _ := c.repo.Refs.CurrentRef("")
 	currentOid, _ := currentRef.ReadOid()
 	c.currentRef = currentRef
@@ -77,7 +84,7 @@ func (c *CheckOut) Run() int {
 	}
 
 	c.repo.Index.WriteUpdates()
-	c.repo.Refs.SetHead(c.target, targetOid)
+	c.repo.Refs.SetHead(c.target, targetOid, c.logMessage())
 	newRef, _ := c.repo.Refs.CurrentRef("")
 	c.newRef = newRef
 
@@ -88,6 +95,14 @@ func (c *CheckOut) Run() int {
 	return 0
 }
 
+func (c *CheckOut) logMessage() string {
+	from := c.currentOid
+	if !c.currentRef.IsHead() {
+		from, _ = c.currentRef.ShortName()
+	}
+	return fmt.Sprintf("checkout: moving from %s to %s", from, c.target)
+}
+
 func (c *CheckOut) handleMigrationConflict(migration *repository.Migration) {
 	c.repo.Index.ReleaseLock()
 
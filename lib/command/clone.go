@@ -0,0 +1,222 @@
+package command
+
+import (
+	"building-git/lib/repository"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type CloneOption struct {
+	Uploader string
+}
+
+type Clone struct {
+	rootPath string
+	args     []string
+	options  CloneOption
+	repo     *repository.Repository
+	source   string
+	target   string
+	path     string
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+func NewClone(dir string, args []string, options CloneOption, stdout, stderr io.Writer) (*Clone, error) {
+	rootPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Clone{
+		rootPath: rootPath,
+		args:     args,
+		options:  options,
+		stdout:   stdout,
+		stderr:   stderr,
+	}, nil
+}
+
+func (c *Clone) Run() int {
+	if err := c.configure(); err != nil {
+		fmt.Fprintf(c.stderr, "fatal: %v\n", err)
+		return 128
+	}
+	created, err := c.prepareTarget()
+	if err != nil {
+		fmt.Fprintf(c.stderr, "fatal: %v\n", err)
+		return 128
+	}
+
+	if err := c.repo.Remotes().Add(repository.DEFAULT_REMOTE, c.source, nil); err != nil {
+		c.cleanup(created)
+		fmt.Fprintf(c.stderr, "fatal: %v\n", err)
+		return 128
+	}
+
+	fetch, status := c.fetch()
+	if status != 0 {
+		c.cleanup(created)
+		return status
+	}
+
+	branch := defaultBranch(fetch.client.remoteRefs)
+	if branch == "" {
+		fmt.Fprintln(c.stderr, "warning: You appear to have cloned an empty repository.")
+		return 0
+	}
+	if err := c.checkoutBranch(branch); err != nil {
+		c.cleanup(created)
+		fmt.Fprintf(c.stderr, "fatal: %v\n", err)
+		return 128
+	}
+	return 0
+}
+
+func (c *Clone) configure() error {
+	if len(c.args) == 0 {
+		return fmt.Errorf("You must specify a repository to clone.")
+	}
+	c.source = c.args[0]
+
+	uri, err := url.Parse(c.source)
+	if err != nil {
+		return err
+	}
+	path := uri.Path
+	if uri.Scheme == "" {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.rootPath, path)
+		}
+		c.source = filepath.Clean(path)
+	}
+
+	if len(c.args) > 1 {
+		c.target = c.args[1]
+	} else {
+		c.target = humanishName(path)
+	}
+	if c.target == "" {
+		return fmt.Errorf("could not infer a directory name from '%s'", c.args[0])
+	}
+	return nil
+}
+
+func humanishName(path string) string {
+	path = strings.TrimSuffix(filepath.Clean(path), string(filepath.Separator)+".git")
+	name := strings.TrimSuffix(filepath.Base(path), ".git")
+	if name == "." || name == string(filepath.Separator) {
+		return ""
+	}
+	return name
+}
+
+func (c *Clone) prepareTarget() (bool, error) {
+	c.path = c.target
+	if !filepath.IsAbs(c.path) {
+		c.path = filepath.Join(c.rootPath, c.path)
+	}
+
+	entries, err := ioutil.ReadDir(c.path)
+	if err == nil && len(entries) > 0 {
+		return false, fmt.Errorf("destination path '%s' already exists and is not an empty directory.", c.target)
+	}
+	created := os.IsNotExist(err)
+
+	fmt.Fprintf(c.stderr, "Cloning into '%s'...\n", c.target)
+	if status := Init([]string{c.path}, ioutil.Discard, c.stderr); status != 0 {
+		return created, fmt.Errorf("could not create repository in '%s'", c.target)
+	}
+	c.repo = repository.NewRepository(c.path)
+	return created, nil
+}
+
+func (c *Clone) fetch() (*Fetch, int) {
+	output := new(bytes.Buffer)
+	fetch, err := NewFetch(c.path, []string{repository.DEFAULT_REMOTE}, FetchOption{Uploader: c.options.Uploader}, ioutil.Discard, output)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "fatal: %v\n", err)
+		return nil, 128
+	}
+
+	status := fetch.Run()
+	if status != 0 {
+		io.Copy(c.stderr, output)
+	}
+	return fetch, status
+}
+
+func defaultBranch(remoteRefs map[string]string) string {
+	branches := []string{}
+	for ref := range remoteRefs {
+		if strings.HasPrefix(ref, repository.HeadsDir()+"/") {
+			branches = append(branches, ref)
+		}
+	}
+	if len(branches) == 0 {
+		return ""
+	}
+	sort.Strings(branches)
+
+	master := filepath.Join(repository.HeadsDir(), DEFAULT_BRANCH)
+	if headOid, ok := remoteRefs[repository.HEAD]; ok {
+		if remoteRefs[master] == headOid {
+			return master
+		}
+		for _, ref := range branches {
+			if remoteRefs[ref] == headOid {
+				return ref
+			}
+		}
+	}
+	if _, ok := remoteRefs[master]; ok {
+		return master
+	}
+	return branches[0]
+}
+
+func (c *Clone) checkoutBranch(ref string) error {
+	name := strings.TrimPrefix(ref, repository.HeadsDir()+"/")
+	upstream := filepath.Join(repository.RemotesDir(), repository.DEFAULT_REMOTE, name)
+	oid, err := c.repo.Refs.ReadRef(upstream)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("clone: from %s", c.source)
+	if err := c.repo.Refs.CreateBranch(name, oid, message); err != nil {
+		return err
+	}
+	if _, _, err := c.repo.Remotes().SetUpstream(name, upstream); err != nil {
+		return err
+	}
+
+	if err := c.repo.Index.LoadForUpdate(); err != nil {
+		return err
+	}
+	treeDiff := c.repo.Database.TreeDiff("", oid, nil)
+	if err := c.repo.Migration(treeDiff).ApplyChanges(); err != nil {
+		c.repo.Index.ReleaseLock()
+		return err
+	}
+	c.repo.Index.WriteUpdates()
+	return c.repo.Refs.SetHead(name, oid, message)
+}
+
+func (c *Clone) cleanup(created bool) {
+	if c.repo == nil {
+		return
+	}
+	if created {
+		os.RemoveAll(c.path)
+	} else {
+		os.RemoveAll(c.repo.GitPath)
+	}
+}
@@ -0,0 +1,181 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClone(t *testing.T) {
+	uploader := os.Args[0] + " upload-pack"
+
+	setup := func() (remoteDir, tmpDir string, stdout, stderr *bytes.Buffer) {
+		remoteDir, _, _ = setupTestEnvironment(t)
+		now := time.Now()
+		for i, message := range []string{"one", "two"} {
+			commitTree(t, remoteDir, message, map[string]string{
+				fmt.Sprintf("%s.txt", message): message,
+				"dir/notes.txt":                fmt.Sprintf("notes for %s\n", message),
+			}, now.Add(time.Duration(i)*time.Second))
+		}
+
+		tmpDir, err := ioutil.TempDir("", "jit")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return remoteDir, tmpDir, new(bytes.Buffer), new(bytes.Buffer)
+	}
+
+	clone := func(tmpDir string, args []string, stdout, stderr *bytes.Buffer) int {
+		cmd, _ := NewClone(tmpDir, args, CloneOption{Uploader: uploader}, stdout, stderr)
+		return cmd.Run()
+	}
+
+	t.Run("clones into a directory named after the source", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		status := clone(tmpDir, []string{remoteDir}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		name := filepath.Base(remoteDir)
+		expected := fmt.Sprintf("Cloning into '%s'...\n", name)
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		assertWorkspace(t, filepath.Join(tmpDir, name), map[string]string{
+			"one.txt":       "one",
+			"two.txt":       "two",
+			"dir/notes.txt": "notes for two\n",
+		})
+	})
+
+	t.Run("checks out the default branch with upstream tracking", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		clone(tmpDir, []string{remoteDir, "copy"}, stdout, stderr)
+
+		local := repo(t, filepath.Join(tmpDir, "copy"))
+		expected, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master")
+		for _, ref := range []string{"HEAD", "refs/heads/master", "refs/remotes/origin/master"} {
+			if got, _ := local.Refs.ReadRef(ref); got != expected {
+				t.Errorf("want %s at %q, but got %q", ref, expected, got)
+			}
+		}
+		if head, _ := local.Refs.CurrentRef(""); head.Path != "refs/heads/master" {
+			t.Errorf("want %q, but got %q", "refs/heads/master", head.Path)
+		}
+
+		for key, expected := range map[string]string{
+			"remote.origin.url":    remoteDir,
+			"remote.origin.fetch":  "+refs/heads/*:refs/remotes/origin/*",
+			"branch.master.remote": "origin",
+			"branch.master.merge":  "refs/heads/master",
+		} {
+			value, _ := local.Config.Get(strings.Split(key, "."))
+			if value != expected {
+				t.Errorf("want %s = %q, but got %q", key, expected, value)
+			}
+		}
+	})
+
+	t.Run("checks out the branch the remote HEAD points at", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		remote := repo(t, remoteDir)
+		oid, _ := remote.Refs.ReadRef("HEAD")
+		remote.Refs.CreateBranch("topic", oid, "")
+		checkout(remoteDir, new(bytes.Buffer), new(bytes.Buffer), "topic")
+		commitTree(t, remoteDir, "three", map[string]string{"three.txt": "three"}, time.Now().Add(time.Minute))
+
+		clone(tmpDir, []string{remoteDir, "copy"}, stdout, stderr)
+
+		local := repo(t, filepath.Join(tmpDir, "copy"))
+		if head, _ := local.Refs.CurrentRef(""); head.Path != "refs/heads/topic" {
+			t.Errorf("want %q, but got %q", "refs/heads/topic", head.Path)
+		}
+		assertWorkspace(t, filepath.Join(tmpDir, "copy"), map[string]string{
+			"one.txt":       "one",
+			"two.txt":       "two",
+			"three.txt":     "three",
+			"dir/notes.txt": "notes for two\n",
+		})
+	})
+
+	t.Run("clones from a file:// URL", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		status := clone(tmpDir, []string{"file://" + remoteDir, "copy"}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master")
+		if got, _ := repo(t, filepath.Join(tmpDir, "copy")).Refs.ReadRef("HEAD"); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("warns when cloning an empty repository", func(t *testing.T) {
+		remoteDir, _, _ := setupTestEnvironment(t)
+		tmpDir, _, stderr := setupTestEnvironment(t)
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		status := clone(tmpDir, []string{remoteDir, "copy"}, new(bytes.Buffer), stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected := "Cloning into 'copy'...\nwarning: You appear to have cloned an empty repository.\n"
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("refuses to clone into a non-empty directory", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "copy/file.txt", "contents")
+		status := clone(tmpDir, []string{remoteDir, "copy"}, stdout, stderr)
+
+		if status != 128 {
+			t.Errorf("want %d, but got %d", 128, status)
+		}
+		expected := "fatal: destination path 'copy' already exists and is not an empty directory.\n"
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("removes the new directory when the source is not a repository", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		status := clone(tmpDir, []string{filepath.Join(tmpDir, "missing"), "copy"}, stdout, stderr)
+
+		if status != 128 {
+			t.Errorf("want %d, but got %d", 128, status)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "copy")); !os.IsNotExist(err) {
+			t.Errorf("want the target directory to be removed, but got %v", err)
+		}
+	})
+}
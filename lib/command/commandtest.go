@@ -90,6 +90,42 @@ func cherryPick(t *testing.T, dir string, stdout, stderr *bytes.Buffer, args []s
 	return c.Run()
 }
 
+func revert(t *testing.T, dir string, stdout, stderr *bytes.Buffer, args []string, options RevertOption) int {
+	t.Helper()
+
+	os.Setenv("GIT_AUTHOR_NAME", "A. U. Thor")
+	os.Setenv("GIT_AUTHOR_EMAIL", "author@example.com")
+	defer os.Unsetenv("GIT_AUTHOR_NAME")
+	defer os.Unsetenv("GIT_AUTHOR_EMAIL")
+
+	if options.EditorCmd == nil {
+		options.EditorCmd = func(path string) editor.Executable {
+			return &MockEditor{path: path}
+		}
+	}
+
+	r, _ := NewRevert(dir, args, options, stdout, stderr)
+	return r.Run()
+}
+
+func rebase(t *testing.T, dir string, stdout, stderr *bytes.Buffer, args []string, options RebaseOption) int {
+	t.Helper()
+
+	os.Setenv("GIT_AUTHOR_NAME", "A. U. Thor")
+	os.Setenv("GIT_AUTHOR_EMAIL", "author@example.com")
+	defer os.Unsetenv("GIT_AUTHOR_NAME")
+	defer os.Unsetenv("GIT_AUTHOR_EMAIL")
+
+	if options.EditorCmd == nil {
+		options.EditorCmd = func(path string) editor.Executable {
+			return &MockEditor{path: path}
+		}
+	}
+
+	r, _ := NewRebase(dir, args, options, stdout, stderr)
+	return r.Run()
+}
+
 func commitTree(t *testing.T, tmpDir, message string, files map[string]string, now time.Time) {
 	t.Helper()
 
@@ -246,7 +282,7 @@ func assertWorkspace(t *testing.T, dir string, expected map[string]string) {
 
 	actual := make(map[string]string)
 
-	files, _ := repo.Workspace.ListFiles(rootPath)
+	files, _ := repo.Workspace.ListFiles(rootPath, repo.Index)
 	sort.Strings(files)
 	for _, path := range files {
 		actual[path], _ = repo.Workspace.ReadFile(path)
@@ -130,7 +130,7 @@ func (c *Commit) handleAmend() {
 
 	new := database.NewCommit(old.Parents, tree.Oid(), old.Author(), committer, message)
 	c.repo.Database.Store(new)
-	c.repo.Refs.UpdateHead(new.Oid())
+	c.repo.Refs.UpdateHead(new.Oid(), "commit (amend): "+new.TitleLine())
 
 	c.writeCommit.PrintCommit(new, c.stdout)
 }
@@ -3,6 +3,7 @@ package command
 import (
 	"building-git/lib/command/print_diff"
 	"building-git/lib/database"
+	"building-git/lib/diff"
 	"building-git/lib/index"
 	"building-git/lib/repository"
 	"fmt"
@@ -23,9 +24,14 @@ type Diff struct {
 }
 
 type DiffOption struct {
-	Cached bool
-	Patch  bool
-	Stage  string
+	Cached      bool
+	Patch       bool
+	Stage       string
+	Renames     bool
+	Copies      bool
+	RenameScore int
+	Algorithm   diff.Algorithm
+	Stat        print_diff.StatOption
 }
 
 func NewDiff(dir string, args []string, options DiffOption, stdout, stderr io.Writer) (*Diff, error) {
@@ -57,6 +63,18 @@ func (d *Diff) Run() int {
 		return 128
 	}
 
+	if d.options.Renames || d.options.Copies {
+		d.prindDiff.DetectRenames(database.RenameOption{
+			Copies:    d.options.Copies,
+			Threshold: d.options.RenameScore,
+		})
+	}
+
+	if d.options.Algorithm != "" {
+		d.prindDiff.SetAlgorithm(d.options.Algorithm)
+	}
+	d.prindDiff.SetStat(d.options.Stat)
+
 	if d.options.Cached {
 		d.diffHeadIndex()
 	} else if len(d.args) == 2 {
@@ -69,37 +87,47 @@ func (d *Diff) Run() int {
 }
 
 func (d *Diff) diffCommits() {
-	if !d.options.Patch {
+	if !d.options.Patch && !d.options.Stat.Any() {
 		return
 	}
 
 	a, _ := repository.NewRevision(d.repo, d.args[0]).Resolve(repository.COMMIT)
 	b, _ := repository.NewRevision(d.repo, d.args[1]).Resolve(repository.COMMIT)
-	d.prindDiff.PrintCommitDiff(a, b, d.repo.Database)
+	d.printChanges(d.repo.Database.TreeDiff(a, b, nil))
 }
 
 func (d *Diff) diffHeadIndex() {
-	if !d.options.Patch {
+	if !d.options.Patch && !d.options.Stat.Any() {
 		return
 	}
-	d.status.IndexChanges.Iterate(func(path string, state repository.ChangeType) {
-		switch state {
-		case repository.Added:
-			d.prindDiff.PrintDiff(d.prindDiff.FromNothing(path), d.fromIndex(path, "0"))
-		case repository.Modified:
-			d.prindDiff.PrintDiff(d.fromHead(path), d.fromIndex(path, "0"))
-		case repository.Deleted:
-			d.prindDiff.PrintDiff(d.fromHead(path), d.prindDiff.FromNothing(path))
-		}
-	})
+	d.printChanges(d.status.IndexDiff())
+}
+
+func (d *Diff) printChanges(changes map[string][2]database.TreeObject) {
+	if d.options.Stat.Any() {
+		d.prindDiff.PrintStat(changes)
+		d.printStatSeparator(len(changes))
+	}
+	if d.options.Patch {
+		d.prindDiff.PrintChanges(changes)
+	}
 }
 
 func (d *Diff) diffIndexWorkspace() {
-	if !d.options.Patch {
+	if !d.options.Patch && !d.options.Stat.Any() {
 		return
 	}
 
 	paths := append(d.status.Conflicts.Keys, d.status.WorkspaceChanges.Keys...)
+
+	if d.options.Stat.Any() {
+		d.printWorkspaceStat(paths)
+		d.printStatSeparator(len(paths))
+	}
+	if !d.options.Patch {
+		return
+	}
+
 	for _, path := range paths {
 		if _, exists := d.status.Conflicts.Get(path); exists {
 			d.printConflictDiff(path)
@@ -109,6 +137,32 @@ func (d *Diff) diffIndexWorkspace() {
 	}
 }
 
+func (d *Diff) printWorkspaceStat(paths []string) {
+	stats := []*print_diff.FileStat{}
+	for _, path := range paths {
+		if _, exists := d.status.Conflicts.Get(path); exists {
+			stats = append(stats, print_diff.UnmergedStat(path))
+			continue
+		}
+
+		switch state, _ := d.status.WorkspaceChanges.Get(path); state {
+		case repository.Modified:
+			stats = append(stats, d.prindDiff.StatFor(d.fromIndex(path, "0"), d.fromFile(path)))
+		case repository.Deleted:
+			stats = append(stats, d.prindDiff.StatFor(d.fromIndex(path, "0"), d.prindDiff.FromNothing(path)))
+		}
+	}
+	d.prindDiff.PrintStats(stats)
+}
+
+// printStatSeparator leaves a blank line between the diffstat and the patch
+// that follows it.
+func (d *Diff) printStatSeparator(changed int) {
+	if d.options.Patch && changed > 0 {
+		fmt.Fprintf(d.stdout, "\n")
+	}
+}
+
 func (d *Diff) printConflictDiff(path string) {
 	targets := []*print_diff.Target{}
 	for stage := 0; stage <= 3; stage++ {
@@ -138,15 +192,6 @@ func (d *Diff) printWorkspaceDiff(path string) {
 	}
 }
 
-func (d *Diff) fromHead(path string) *print_diff.Target {
-	entry := d.status.HeadTree[path]
-	aOid := entry.Oid()
-	aMode := fmt.Sprintf("%o", entry.Mode())
-	blob, _ := d.repo.Database.Load(aOid)
-
-	return print_diff.NewTarget(path, aOid, aMode, blob.String())
-}
-
 func (d *Diff) fromIndex(path, stage string) *print_diff.Target {
 	entry := d.repo.Index.EntryForPath(path, stage)
 	if entry == nil || entry.IsNil() {
@@ -1,9 +1,12 @@
 package command
 
 import (
+	"building-git/lib/command/print_diff"
 	"building-git/lib/command/write_commit"
 	"bytes"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -187,4 +190,174 @@ index 0000000..ce01362
 
 		assertDiff(t, tmpDir, []string{}, DiffOption{Patch: true, Cached: true}, stdout, stderr, expected)
 	})
+
+	t.Run("diffs a renamed file with -M", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+		delete(t, tmpDir, "file.txt")
+		writeFile(t, tmpDir, "moved.txt", "contents\n")
+		delete(t, tmpDir, ".git/index")
+		Add(tmpDir, []string{"."}, new(bytes.Buffer), new(bytes.Buffer))
+
+		expected := `diff --git a/file.txt b/moved.txt
+similarity index 100%
+rename from file.txt
+rename to moved.txt
+`
+
+		assertDiff(t, tmpDir, []string{}, DiffOption{Patch: true, Cached: true, Renames: true}, stdout, stderr, expected)
+	})
+
+	t.Run("diffs a renamed file as a deletion and an addition without -M", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+		delete(t, tmpDir, "file.txt")
+		writeFile(t, tmpDir, "moved.txt", "contents\n")
+		delete(t, tmpDir, ".git/index")
+		Add(tmpDir, []string{"."}, new(bytes.Buffer), new(bytes.Buffer))
+
+		expected := `diff --git a/file.txt b/file.txt
+deleted file mode 100644
+index 12f00e9..0000000
+--- a/file.txt
++++ /dev/null
+@@ -1,1 +0,0 @@
+-contents
+diff --git a/moved.txt b/moved.txt
+new file mode 100644
+index 0000000..12f00e9
+--- /dev/null
++++ b/moved.txt
+@@ -0,0 +1,1 @@
++contents
+`
+
+		assertDiff(t, tmpDir, []string{}, DiffOption{Patch: true, Cached: true}, stdout, stderr, expected)
+	})
+}
+
+func TestDiffStat(t *testing.T) {
+	lines := func(from, to int) string {
+		var text strings.Builder
+		for i := from; i <= to; i++ {
+			fmt.Fprintf(&text, "%d\n", i)
+		}
+		return text.String()
+	}
+
+	setup := func() (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+
+		commitTree(t, tmpDir, "first commit", map[string]string{
+			"a.txt":                                  lines(1, 10),
+			"lib/long-named-file-in-a-directory.txt": lines(1, 100),
+			"bin.dat":                                "a\x00b",
+		}, time.Now())
+
+		writeFile(t, tmpDir, "a.txt", lines(3, 12))
+		writeFile(t, tmpDir, "lib/long-named-file-in-a-directory.txt", lines(51, 150))
+		writeFile(t, tmpDir, "bin.dat", "a\x00bcdef")
+
+		return
+	}
+
+	t.Run("prints a diffstat of the workspace", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		expected := ` a.txt                                  |   4 +-
+ bin.dat                                | Bin 3 -> 7 bytes
+ lib/long-named-file-in-a-directory.txt | 100 ++++++++++++++++-----------------
+ 3 files changed, 52 insertions(+), 52 deletions(-)
+`
+		options := DiffOption{Stat: print_diff.StatOption{Stat: true}}
+		assertDiff(t, tmpDir, []string{}, options, stdout, stderr, expected)
+	})
+
+	t.Run("prints the numbers of changed lines for scripts", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		expected := "2\t2\ta.txt\n-\t-\tbin.dat\n50\t50\tlib/long-named-file-in-a-directory.txt\n"
+		options := DiffOption{Stat: print_diff.StatOption{NumStat: true}}
+		assertDiff(t, tmpDir, []string{}, options, stdout, stderr, expected)
+	})
+
+	t.Run("prints only the summary of staged changes", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+		Add(tmpDir, []string{"."}, new(bytes.Buffer), new(bytes.Buffer))
+
+		expected := " 3 files changed, 52 insertions(+), 52 deletions(-)\n"
+		options := DiffOption{Cached: true, Stat: print_diff.StatOption{ShortStat: true}}
+		assertDiff(t, tmpDir, []string{}, options, stdout, stderr, expected)
+	})
+
+	t.Run("fits the diffstat into the given width", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+		Add(tmpDir, []string{"."}, new(bytes.Buffer), new(bytes.Buffer))
+
+		expected := ` a.txt                     |   4 +-
+ bin.dat                   | Bin 3 -> 7 bytes
+ ...ile-in-a-directory.txt | 100 +++---
+ 3 files changed, 52 insertions(+), 52 deletions(-)
+`
+		options := DiffOption{Cached: true, Stat: print_diff.StatOption{Stat: true, Width: 40}}
+		assertDiff(t, tmpDir, []string{}, options, stdout, stderr, expected)
+	})
+
+	t.Run("prints the share of the changes made in each directory", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+		Add(tmpDir, []string{"."}, new(bytes.Buffer), new(bytes.Buffer))
+
+		dirStat, _ := print_diff.ParseDirStat("lines")
+		expected := "  95.2% lib/\n"
+		options := DiffOption{Cached: true, Stat: print_diff.StatOption{DirStat: dirStat}}
+		assertDiff(t, tmpDir, []string{}, options, stdout, stderr, expected)
+	})
+
+	t.Run("separates the diffstat from the patch", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+		writeFile(t, tmpDir, "lib/long-named-file-in-a-directory.txt", lines(1, 100))
+		writeFile(t, tmpDir, "bin.dat", "a\x00b")
+
+		expected := ` a.txt | 4 ++--
+ 1 file changed, 2 insertions(+), 2 deletions(-)
+
+diff --git a/a.txt b/a.txt
+index f00c965..c58eae6 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,5 +1,3 @@
+-1
+-2
+ 3
+ 4
+ 5
+@@ -8,3 +6,5 @@
+ 8
+ 9
+ 10
++11
++12
+`
+		options := DiffOption{Patch: true, Stat: print_diff.StatOption{Stat: true}}
+		assertDiff(t, tmpDir, []string{}, options, stdout, stderr, expected)
+	})
+
+	t.Run("reports binary files in the patch", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+		writeFile(t, tmpDir, "a.txt", lines(1, 10))
+		writeFile(t, tmpDir, "lib/long-named-file-in-a-directory.txt", lines(1, 100))
+
+		expected := `diff --git a/bin.dat b/bin.dat
+index 20b5be9..fc755f9 100644
+Binary files a/bin.dat and b/bin.dat differ
+`
+		assertDiff(t, tmpDir, []string{}, DiffOption{Patch: true}, stdout, stderr, expected)
+	})
 }
@@ -0,0 +1,235 @@
+package command
+
+import (
+	"building-git/lib/pack"
+	"building-git/lib/repository"
+	"building-git/lib/repository/remotes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+var FETCH_CAPABILITIES = []string{"ofs-delta"}
+
+const UPLOAD_PACK = "jit upload-pack"
+
+type FetchOption struct {
+	Uploader string
+}
+
+type Fetch struct {
+	rootPath   string
+	args       []string
+	options    FetchOption
+	repo       *repository.Repository
+	client     *remoteClient
+	fetchUrl   string
+	uploader   string
+	fetchSpecs []string
+	targets    map[string][]interface{}
+	localRefs  map[string]string
+	errors     map[string]string
+	stdout     io.Writer
+	stderr     io.Writer
+}
+
+func NewFetch(dir string, args []string, options FetchOption, stdout, stderr io.Writer) (*Fetch, error) {
+	rootPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(rootPath)
+
+	return &Fetch{
+		rootPath:  rootPath,
+		args:      args,
+		options:   options,
+		repo:      repo,
+		client:    newRemoteClient(repo, stderr),
+		localRefs: map[string]string{},
+		errors:    map[string]string{},
+		stdout:    stdout,
+		stderr:    stderr,
+	}, nil
+}
+
+func (f *Fetch) Run() int {
+	if err := f.configure(); err != nil {
+		fmt.Fprintf(f.stderr, "fatal: %v\n", err)
+		return 128
+	}
+	if err := f.client.startAgent("fetch", f.uploader, f.fetchUrl, FETCH_CAPABILITIES); err != nil {
+		fmt.Fprintf(f.stderr, "fatal: %v\n", err)
+		return 128
+	}
+
+	if err := f.fetch(); err != nil {
+		f.client.finishAgent()
+		fmt.Fprintf(f.stderr, "fatal: %v\n", err)
+		return 128
+	}
+	if err := f.client.finishAgent(); err != nil {
+		fmt.Fprintf(f.stderr, "fatal: %v\n", err)
+		return 128
+	}
+
+	if len(f.errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func (f *Fetch) configure() error {
+	name := repository.DEFAULT_REMOTE
+	if len(f.args) > 0 {
+		name = f.args[0]
+	}
+	remote := f.repo.Remotes().Get(name)
+
+	if remote != nil {
+		f.fetchUrl, _ = remote.FetchUrl()
+		f.uploader, _ = remote.Uploader()
+	}
+	if f.fetchUrl == "" && len(f.args) > 0 {
+		f.fetchUrl = f.args[0]
+	}
+	if f.fetchUrl == "" {
+		return fmt.Errorf("'%s' does not appear to be a git repository", name)
+	}
+
+	if f.options.Uploader != "" {
+		f.uploader = f.options.Uploader
+	}
+	if f.uploader == "" {
+		f.uploader = UPLOAD_PACK
+	}
+
+	if len(f.args) > 1 {
+		f.fetchSpecs = f.args[1:]
+	} else if remote != nil {
+		f.fetchSpecs, _ = remote.FetchSpecs()
+	}
+	return nil
+}
+
+func (f *Fetch) fetch() error {
+	if err := f.client.recvReferences(); err != nil {
+		return err
+	}
+
+	wanted, err := f.sendWantList()
+	if err != nil || !wanted {
+		return err
+	}
+	if err := f.sendHaveList(); err != nil {
+		return err
+	}
+
+	unpackLimit := configInt(f.repo, []string{"fetch", "unpackLimit"})
+	if err := recvPackedObjects(f.repo, f.client.conn, unpackLimit, pack.SIGNATURE); err != nil {
+		return err
+	}
+	return f.updateRemoteRefs()
+}
+
+func (f *Fetch) sendWantList() (bool, error) {
+	f.targets = remotes.ExpandRefspecs(f.fetchSpecs, f.client.remoteRefNames())
+	wanted := map[string]bool{}
+
+	for _, target := range sortedKeys(f.targets) {
+		source := f.targets[target][0].(string)
+		localOid, _ := f.repo.Refs.ReadRef(target)
+		remoteOid := f.client.remoteRefs[source]
+
+		if localOid == remoteOid {
+			continue
+		}
+		f.localRefs[target] = localOid
+
+		if wanted[remoteOid] {
+			continue
+		}
+		wanted[remoteOid] = true
+		if err := f.client.conn.SendPacket("want " + remoteOid); err != nil {
+			return false, err
+		}
+	}
+
+	if err := f.client.conn.SendFlush(); err != nil {
+		return false, err
+	}
+	return len(wanted) > 0, nil
+}
+
+func (f *Fetch) sendHaveList() error {
+	revList, err := repository.NewRevList(f.repo, []string{}, repository.RevListOption{
+		All:     true,
+		Missing: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, commit := range revList.Each() {
+		if err := f.client.conn.SendPacket("have " + commit.Oid()); err != nil {
+			return err
+		}
+	}
+	if err := f.client.conn.SendPacket("done"); err != nil {
+		return err
+	}
+
+	return f.client.conn.RecvUntil(pack.SIGNATURE, func(line string) error {
+		return nil
+	})
+}
+
+func (f *Fetch) updateRemoteRefs() error {
+	fmt.Fprintf(f.stderr, "From %s\n", f.fetchUrl)
+
+	targets := []string{}
+	for target := range f.localRefs {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	for _, target := range targets {
+		if err := f.attemptRefUpdate(target, f.localRefs[target]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Fetch) attemptRefUpdate(target, oldOid string) error {
+	source := f.targets[target][0].(string)
+	forced := f.targets[target][1].(bool)
+	newOid := f.client.remoteRefs[source]
+
+	refNames := []string{source, target}
+	ffError := fastForwardError(f.repo, oldOid, newOid)
+
+	var err string
+	if forced || ffError == "" {
+		if err := f.repo.Refs.UpdateRef(target, newOid, fetchLogMessage(oldOid, ffError)); err != nil {
+			return err
+		}
+	} else {
+		err = ffError
+		f.errors[target] = ffError
+	}
+
+	f.client.reportRefUpdate(refNames, err, oldOid, newOid, ffError == "")
+	return nil
+}
+
+func fetchLogMessage(oldOid, ffError string) string {
+	if oldOid == "" {
+		return "fetch: storing head"
+	}
+	if ffError == "" {
+		return "fetch: fast-forward"
+	}
+	return "fetch: forced-update"
+}
@@ -0,0 +1,210 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFetch(t *testing.T) {
+	uploader := os.Args[0] + " upload-pack"
+
+	setup := func() (remoteDir, tmpDir string, stdout, stderr *bytes.Buffer) {
+		remoteDir, _, _ = setupTestEnvironment(t)
+		now := time.Now()
+		for i, message := range []string{"one", "two", "three"} {
+			commitTree(t, remoteDir, message, map[string]string{
+				fmt.Sprintf("%s.txt", message): message,
+				"dir/notes.txt":                fmt.Sprintf("notes for %s\n", message),
+			}, now.Add(time.Duration(i)*time.Second))
+		}
+
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+		remote, _ := NewRemote(tmpDir, []string{"add", "origin", remoteDir}, RemoteOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		remote.Run()
+		return
+	}
+
+	fetch := func(tmpDir string, args []string, stdout, stderr *bytes.Buffer) int {
+		cmd, _ := NewFetch(tmpDir, args, FetchOption{Uploader: uploader}, stdout, stderr)
+		return cmd.Run()
+	}
+
+	t.Run("displays the new branches being fetched", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		status := fetch(tmpDir, []string{}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected := fmt.Sprintf("From %s\n * [new branch] master -> origin/master\n", remoteDir)
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("maps the remote's heads to local remote refs", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		fetch(tmpDir, []string{}, stdout, stderr)
+
+		expected, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master")
+		got, _ := repo(t, tmpDir).Refs.ReadRef("refs/remotes/origin/master")
+		if got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("copies every object from the remote", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		fetch(tmpDir, []string{}, stdout, stderr)
+
+		remoteObjects, _ := filepath.Glob(filepath.Join(remoteDir, ".git", "objects", "??", "*"))
+		local := repo(t, tmpDir)
+		for _, path := range remoteObjects {
+			oid := filepath.Base(filepath.Dir(path)) + filepath.Base(path)
+			if !local.Database.Has(oid) {
+				t.Errorf("want object %s to be fetched", oid)
+			}
+		}
+	})
+
+	t.Run("fetches into the refs named by a refspec", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		fetch(tmpDir, []string{"origin", "refs/heads/*:refs/remotes/other/*"}, stdout, stderr)
+
+		expected, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master")
+		got, _ := repo(t, tmpDir).Refs.ReadRef("refs/remotes/other/master")
+		if got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("prints nothing when already up to date", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		fetch(tmpDir, []string{}, new(bytes.Buffer), new(bytes.Buffer))
+		status := fetch(tmpDir, []string{}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		if got := stderr.String(); got != "" {
+			t.Errorf("want %q, but got %q", "", got)
+		}
+	})
+
+	t.Run("fast-forwards a tracking ref", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		fetch(tmpDir, []string{}, new(bytes.Buffer), new(bytes.Buffer))
+		oldOid, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master")
+		commitTree(t, remoteDir, "four", map[string]string{"four.txt": "four"}, time.Now().Add(time.Minute))
+		newOid, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master")
+
+		fetch(tmpDir, []string{}, stdout, stderr)
+
+		expected := fmt.Sprintf("From %s\n   %s..%s master -> origin/master\n", remoteDir, oldOid[:7], newOid[:7])
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("force-updates a tracking ref with a forced refspec", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		fetch(tmpDir, []string{}, new(bytes.Buffer), new(bytes.Buffer))
+		oldOid, newOid := rewriteHistory(t, remoteDir)
+
+		status := fetch(tmpDir, []string{}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected := fmt.Sprintf("From %s\n + %s...%s master -> origin/master (forced update)\n", remoteDir, oldOid[:7], newOid[:7])
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		got, _ := repo(t, tmpDir).Refs.ReadRef("refs/remotes/origin/master")
+		if got != newOid {
+			t.Errorf("want %q, but got %q", newOid, got)
+		}
+	})
+
+	t.Run("rejects a non-fast-forward update without a forced refspec", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		fetch(tmpDir, []string{}, new(bytes.Buffer), new(bytes.Buffer))
+		oldOid, _ := rewriteHistory(t, remoteDir)
+
+		status := fetch(tmpDir, []string{"origin", "refs/heads/*:refs/remotes/origin/*"}, stdout, stderr)
+
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		expected := fmt.Sprintf("From %s\n ! [rejected] master -> origin/master (non-fast-forward)\n", remoteDir)
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		got, _ := repo(t, tmpDir).Refs.ReadRef("refs/remotes/origin/master")
+		if got != oldOid {
+			t.Errorf("want %q, but got %q", oldOid, got)
+		}
+	})
+
+	t.Run("keeps the pack when it exceeds the unpack limit", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		config, _ := NewConfig(tmpDir, []string{"fetch.unpackLimit", "5"}, ConfigOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		config.Run()
+		fetch(tmpDir, []string{}, stdout, stderr)
+
+		packs, _ := filepath.Glob(filepath.Join(tmpDir, ".git", "objects", "pack", "*"))
+		if len(packs) != 2 {
+			t.Errorf("want %d, but got %d", 2, len(packs))
+		}
+
+		local := repo(t, tmpDir)
+		oid, _ := local.Refs.ReadRef("refs/remotes/origin/master")
+		if _, err := local.Database.Load(oid); err != nil {
+			t.Errorf("want commit %s to load, but got %v", oid, err)
+		}
+	})
+}
+
+func rewriteHistory(t *testing.T, remoteDir string) (string, string) {
+	t.Helper()
+
+	r := repo(t, remoteDir)
+	oldOid, _ := r.Refs.ReadRef("refs/heads/master")
+	parent, _ := resolveRevision(t, remoteDir, "master^")
+	r.Refs.UpdateRef("refs/heads/master", parent, "reset: moving to HEAD^")
+
+	commitTree(t, remoteDir, "rewritten", map[string]string{"other.txt": "other"}, time.Now().Add(time.Minute))
+	newOid, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master")
+	return oldOid, newOid
+}
@@ -0,0 +1,522 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type graphState int
+
+const (
+	graphPadding graphState = iota
+	graphSkip
+	graphPreCommit
+	graphCommit
+	graphPostMerge
+	graphCollapsing
+)
+
+var graphColors = []*color.Color{
+	color.New(color.FgRed),
+	color.New(color.FgGreen),
+	color.New(color.FgYellow),
+	color.New(color.FgBlue),
+	color.New(color.FgMagenta),
+	color.New(color.FgCyan),
+}
+
+type graphColumn struct {
+	commit string
+	color  int
+}
+
+type graphLine struct {
+	builder strings.Builder
+	width   int
+}
+
+func (l *graphLine) addChar(char byte) {
+	l.builder.WriteByte(char)
+	l.width++
+}
+
+func (l *graphLine) addColumn(column graphColumn, char byte) {
+	l.builder.WriteString(graphColors[column.color].Sprint(string(char)))
+	l.width++
+}
+
+func (l *graphLine) pad(width int) {
+	for l.width < width {
+		l.addChar(' ')
+	}
+}
+
+// Graph draws the lanes of history to the left of log output. Each commit
+// owns a column; merges open new columns for their extra parents and columns
+// that lead to the same commit are collapsed back together once it is shown.
+// Text written to the graph is prefixed, line by line, with the next row of
+// the drawing.
+type Graph struct {
+	out             io.Writer
+	buffer          []byte
+	commit          string
+	parents         []string
+	width           int
+	expansionRow    int
+	state           graphState
+	prevState       graphState
+	commitIndex     int
+	prevCommitIndex int
+	columns         []graphColumn
+	newColumns      []graphColumn
+	mapping         []int
+	newMapping      []int
+	mappingSize     int
+	defaultColor    int
+	commitShown     bool
+}
+
+func NewGraph(out io.Writer) *Graph {
+	return &Graph{
+		out:          out,
+		state:        graphPadding,
+		prevState:    graphPadding,
+		defaultColor: len(graphColors) - 1,
+	}
+}
+
+// Update moves the graph on to the next commit. parents should only list the
+// commits that will themselves be shown.
+func (g *Graph) Update(commit string, parents []string) {
+	g.commit = commit
+	g.parents = parents
+	g.prevCommitIndex = g.commitIndex
+	g.commitShown = false
+
+	g.updateColumns()
+	g.expansionRow = 0
+
+	if g.state != graphPadding {
+		g.state = graphSkip
+	} else if g.needsPreCommitLine() {
+		g.state = graphPreCommit
+	} else {
+		g.state = graphCommit
+	}
+}
+
+func (g *Graph) Write(p []byte) (int, error) {
+	g.buffer = append(g.buffer, p...)
+
+	for {
+		index := bytes.IndexByte(g.buffer, '\n')
+		if index < 0 {
+			break
+		}
+		line := g.buffer[:index]
+		if _, err := fmt.Fprintf(g.out, "%s%s\n", g.prefix(), line); err != nil {
+			return 0, err
+		}
+		g.buffer = g.buffer[index+1:]
+	}
+	return len(p), nil
+}
+
+// ShowPadding prints a row that leaves every lane where it is, for the blank
+// lines that separate one commit from the next.
+func (g *Graph) ShowPadding() {
+	if g.state != graphCommit {
+		fmt.Fprintln(g.out, g.nextLine())
+		return
+	}
+
+	var line graphLine
+	for _, column := range g.columns {
+		line.addColumn(column, '|')
+		if column.commit == g.commit && len(g.parents) > 2 {
+			for i := 0; i < (len(g.parents)-2)*2; i++ {
+				line.addChar(' ')
+			}
+		} else {
+			line.addChar(' ')
+		}
+	}
+	line.pad(g.width)
+	g.prevState = graphPadding
+
+	fmt.Fprintln(g.out, line.builder.String())
+}
+
+// ShowRemainder prints whatever rows the current commit still needs once
+// its text has run out, such as the edges leading away from a merge.
+func (g *Graph) ShowRemainder() {
+	if !g.commitShown {
+		g.prefix()
+	}
+	for g.state != graphPadding {
+		fmt.Fprintln(g.out, g.nextLine())
+	}
+}
+
+func (g *Graph) prefix() string {
+	if g.commitShown {
+		return g.nextLine()
+	}
+	for {
+		isCommit := g.state == graphCommit
+		line := g.nextLine()
+		if isCommit {
+			g.commitShown = true
+			return line
+		}
+		fmt.Fprintln(g.out, line)
+	}
+}
+
+func (g *Graph) nextLine() string {
+	var line graphLine
+
+	switch g.state {
+	case graphPadding:
+		g.outputPaddingLine(&line)
+	case graphSkip:
+		g.outputSkipLine(&line)
+	case graphPreCommit:
+		g.outputPreCommitLine(&line)
+	case graphCommit:
+		g.outputCommitLine(&line)
+	case graphPostMerge:
+		g.outputPostMergeLine(&line)
+	case graphCollapsing:
+		g.outputCollapsingLine(&line)
+	}
+
+	line.pad(g.width)
+	return line.builder.String()
+}
+
+func (g *Graph) updateState(state graphState) {
+	g.prevState = g.state
+	g.state = state
+}
+
+func (g *Graph) updateColumns() {
+	g.columns = g.newColumns
+	g.newColumns = []graphColumn{}
+
+	maxColumns := len(g.columns) + len(g.parents)
+	g.mappingSize = 2 * maxColumns
+	g.mapping = make([]int, g.mappingSize+2)
+	g.newMapping = make([]int, g.mappingSize+2)
+	for i := range g.mapping {
+		g.mapping[i] = -1
+	}
+
+	seenThis := false
+	inColumns := true
+	mappingIndex := 0
+
+	for i := 0; i <= len(g.columns); i++ {
+		var commit string
+		if i == len(g.columns) {
+			if seenThis {
+				break
+			}
+			inColumns = false
+			commit = g.commit
+		} else {
+			commit = g.columns[i].commit
+		}
+
+		if commit != g.commit {
+			g.insertIntoNewColumns(commit, &mappingIndex)
+			continue
+		}
+
+		oldMappingIndex := mappingIndex
+		seenThis = true
+		g.commitIndex = i
+		for _, parent := range g.parents {
+			if len(g.parents) > 1 || !inColumns {
+				g.defaultColor = (g.defaultColor + 1) % len(graphColors)
+			}
+			g.insertIntoNewColumns(parent, &mappingIndex)
+		}
+		if mappingIndex == oldMappingIndex {
+			mappingIndex += 2
+		}
+	}
+
+	for g.mappingSize > 1 && g.mapping[g.mappingSize-1] < 0 {
+		g.mappingSize--
+	}
+
+	width := len(g.columns) + len(g.parents)
+	if len(g.parents) < 1 {
+		width++
+	}
+	if inColumns {
+		width--
+	}
+	g.width = width * 2
+}
+
+func (g *Graph) insertIntoNewColumns(commit string, mappingIndex *int) {
+	for i, column := range g.newColumns {
+		if column.commit == commit {
+			g.mapping[*mappingIndex] = i
+			*mappingIndex += 2
+			return
+		}
+	}
+
+	g.newColumns = append(g.newColumns, graphColumn{commit: commit, color: g.commitColor(commit)})
+	g.mapping[*mappingIndex] = len(g.newColumns) - 1
+	*mappingIndex += 2
+}
+
+func (g *Graph) commitColor(commit string) int {
+	for _, column := range g.columns {
+		if column.commit == commit {
+			return column.color
+		}
+	}
+	return g.defaultColor
+}
+
+func (g *Graph) newColumnFor(commit string) graphColumn {
+	for _, column := range g.newColumns {
+		if column.commit == commit {
+			return column
+		}
+	}
+	return graphColumn{}
+}
+
+func (g *Graph) numExpansionRows() int {
+	return (len(g.parents) - 2) * 2
+}
+
+func (g *Graph) needsPreCommitLine() bool {
+	return len(g.parents) >= 3 &&
+		g.commitIndex < len(g.columns)-1 &&
+		g.expansionRow < g.numExpansionRows()
+}
+
+func (g *Graph) isMappingCorrect() bool {
+	for i := 0; i < g.mappingSize; i++ {
+		target := g.mapping[i]
+		if target >= 0 && target != i/2 {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Graph) outputPaddingLine(line *graphLine) {
+	for _, column := range g.newColumns {
+		line.addColumn(column, '|')
+		line.addChar(' ')
+	}
+}
+
+func (g *Graph) outputSkipLine(line *graphLine) {
+	line.builder.WriteString("...")
+	line.width += 3
+
+	if g.needsPreCommitLine() {
+		g.updateState(graphPreCommit)
+	} else {
+		g.updateState(graphCommit)
+	}
+}
+
+// outputPreCommitLine makes room for an octopus merge by pushing the lanes
+// to its right further over before the commit itself is drawn.
+func (g *Graph) outputPreCommitLine(line *graphLine) {
+	seenThis := false
+
+	for i, column := range g.columns {
+		if column.commit == g.commit {
+			seenThis = true
+			line.addColumn(column, '|')
+			for j := 0; j < g.expansionRow; j++ {
+				line.addChar(' ')
+			}
+		} else if seenThis && g.expansionRow == 0 {
+			if g.prevState == graphPostMerge && g.prevCommitIndex < i {
+				line.addColumn(column, '\\')
+			} else {
+				line.addColumn(column, '|')
+			}
+		} else if seenThis {
+			line.addColumn(column, '\\')
+		} else {
+			line.addColumn(column, '|')
+		}
+		line.addChar(' ')
+	}
+
+	g.expansionRow++
+	if !g.needsPreCommitLine() {
+		g.updateState(graphCommit)
+	}
+}
+
+func (g *Graph) outputCommitLine(line *graphLine) {
+	seenThis := false
+
+	for i := 0; i <= len(g.columns); i++ {
+		var column graphColumn
+		if i == len(g.columns) {
+			if seenThis {
+				break
+			}
+			column = graphColumn{commit: g.commit}
+		} else {
+			column = g.columns[i]
+		}
+
+		if column.commit == g.commit {
+			seenThis = true
+			line.addChar('*')
+			if len(g.parents) > 2 {
+				g.drawOctopusMerge(line)
+			}
+		} else if seenThis && len(g.parents) > 2 {
+			line.addColumn(column, '\\')
+		} else if seenThis && len(g.parents) == 2 &&
+			g.prevState == graphPostMerge && g.prevCommitIndex < i {
+			line.addColumn(column, '\\')
+		} else {
+			line.addColumn(column, '|')
+		}
+		line.addChar(' ')
+	}
+
+	if len(g.parents) > 1 {
+		g.updateState(graphPostMerge)
+	} else if g.isMappingCorrect() {
+		g.updateState(graphPadding)
+	} else {
+		g.updateState(graphCollapsing)
+	}
+}
+
+func (g *Graph) drawOctopusMerge(line *graphLine) {
+	dashes := (len(g.parents)-2)*2 - 1
+	for i := 0; i < dashes; i++ {
+		line.addColumn(g.newColumns[i/2+2+g.commitIndex], '-')
+	}
+	line.addColumn(g.newColumns[dashes/2+2+g.commitIndex], '.')
+}
+
+func (g *Graph) outputPostMergeLine(line *graphLine) {
+	seenThis := false
+
+	for i := 0; i <= len(g.columns); i++ {
+		var column graphColumn
+		if i == len(g.columns) {
+			if seenThis {
+				break
+			}
+			column = graphColumn{commit: g.commit}
+		} else {
+			column = g.columns[i]
+		}
+
+		if column.commit == g.commit {
+			seenThis = true
+			line.addColumn(g.newColumnFor(g.parents[0]), '|')
+			for _, parent := range g.parents[1:] {
+				line.addColumn(g.newColumnFor(parent), '\\')
+				line.addChar(' ')
+			}
+		} else if seenThis {
+			line.addColumn(column, '\\')
+			line.addChar(' ')
+		} else {
+			line.addColumn(column, '|')
+			line.addChar(' ')
+		}
+	}
+
+	if g.isMappingCorrect() {
+		g.updateState(graphPadding)
+	} else {
+		g.updateState(graphCollapsing)
+	}
+}
+
+// outputCollapsingLine moves every lane that is not yet in its final column
+// one step to the left, letting lanes that lead to the same commit join up.
+func (g *Graph) outputCollapsingLine(line *graphLine) {
+	usedHorizontal := false
+	horizontalEdge := -1
+	horizontalEdgeTarget := -1
+
+	for i := 0; i < g.mappingSize; i++ {
+		g.newMapping[i] = -1
+	}
+
+	for i := 0; i < g.mappingSize; i++ {
+		target := g.mapping[i]
+		if target < 0 {
+			continue
+		}
+
+		if target*2 == i {
+			g.newMapping[i] = target
+		} else if g.newMapping[i-1] < 0 {
+			g.newMapping[i-1] = target
+			if horizontalEdge == -1 {
+				horizontalEdge = i
+				horizontalEdgeTarget = target
+				for j := target*2 + 3; j < i-2; j += 2 {
+					g.newMapping[j] = target
+				}
+			}
+		} else if g.newMapping[i-1] == target {
+			continue
+		} else {
+			g.newMapping[i-2] = target
+			if horizontalEdge == -1 {
+				horizontalEdge = i
+			}
+		}
+	}
+
+	if g.newMapping[g.mappingSize-1] < 0 {
+		g.mappingSize--
+	}
+
+	for i := 0; i < g.mappingSize; i++ {
+		target := g.newMapping[i]
+		if target < 0 {
+			line.addChar(' ')
+		} else if target*2 == i {
+			line.addColumn(g.newColumns[target], '|')
+		} else if target == horizontalEdgeTarget && i != horizontalEdge-1 {
+			if i != target*2+3 {
+				g.newMapping[i] = -1
+			}
+			usedHorizontal = true
+			line.addColumn(g.newColumns[target], '_')
+		} else {
+			if usedHorizontal && i < horizontalEdge {
+				g.newMapping[i] = -1
+			}
+			line.addColumn(g.newColumns[target], '/')
+		}
+	}
+
+	g.mapping, g.newMapping = g.newMapping, g.mapping
+
+	if g.isMappingCorrect() {
+		g.updateState(graphPadding)
+	}
+}
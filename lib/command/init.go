@@ -42,7 +42,7 @@ func Init(args []string, stdout, stderr io.Writer) int {
 
 	refs := repository.NewRefs(gitPath)
 	headPath := filepath.Join("refs", "heads", DEFAULT_BRANCH)
-	refs.UpdateHead(fmt.Sprintf("ref: %s", headPath))
+	refs.UpdateSymbolicRef(repository.HEAD, headPath)
 
 	fmt.Fprintf(stdout, "Initialized empty Jit repository in %s\n", gitPath)
 	return 0
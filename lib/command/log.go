@@ -3,22 +3,30 @@ package command
 import (
 	"building-git/lib/command/print_diff"
 	"building-git/lib/database"
+	"building-git/lib/diff"
 	"building-git/lib/repository"
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/fatih/color"
 )
 
 type LogOption struct {
-	Abbrev   bool
-	Format   string
-	Decorate string
-	IsTty    bool
-	Patch    bool
-	Combined bool
+	Abbrev    bool
+	Format    string
+	Date      string
+	Decorate  string
+	IsTty     bool
+	Patch     bool
+	Stat      print_diff.StatOption
+	Combined  bool
+	Graph     bool
+	Follow    bool
+	Algorithm diff.Algorithm
+	Filter    repository.RevListFilter
 }
 
 type Log struct {
@@ -32,6 +40,7 @@ type Log struct {
 	reverseRefs map[string][]*repository.SymRef
 	prindDiff   *print_diff.PrintDiff
 	revList     *repository.RevList
+	graph       *Graph
 }
 
 func NewLog(dir string, args []string, options LogOption, stdout, stderr io.Writer) (*Log, error) {
@@ -40,9 +49,28 @@ func NewLog(dir string, args []string, options LogOption, stdout, stderr io.Writ
 		return nil, err
 	}
 	repo := repository.NewRepository(rootPath)
+
+	var graph *Graph
+	if options.Graph {
+		graph = NewGraph(stdout)
+		stdout = graph
+	}
+
 	prindDiff, _ := print_diff.NewPrintDiff(dir, stdout, stderr)
+	prindDiff.DetectRenames(database.RenameOption{})
+	if options.Algorithm != "" {
+		prindDiff.SetAlgorithm(options.Algorithm)
+	}
+	prindDiff.SetStat(options.Stat)
 
-	revList, _ := repository.NewRevList(repo, args, repository.RevListOption{})
+	revList, err := repository.NewRevList(repo, args, repository.RevListOption{
+		TopoOrder: options.Graph,
+		Follow:    options.Follow,
+		Filter:    options.Filter,
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return &Log{
 		rootPath:  rootPath,
@@ -53,22 +81,44 @@ func NewLog(dir string, args []string, options LogOption, stdout, stderr io.Writ
 		stderr:    stderr,
 		prindDiff: prindDiff,
 		revList:   revList,
+		graph:     graph,
 	}, nil
 }
 
 func (l *Log) Run() int {
-	l.reverseRefs = l.repo.Refs.ReverseRefs()
-	l.currentRef, _ = l.repo.Refs.CurrentRef("")
+	l.loadRefs()
+
+	commits := []*database.Commit{}
+	shown := map[string]bool{}
+	for _, object := range l.revList.Each() {
+		commit := object.(*database.Commit)
+		commits = append(commits, commit)
+		shown[commit.Oid()] = true
+	}
 
 	blankLine := false
-	for _, commit := range l.revList.Each() {
-		l.showCommit(blankLine, commit.(*database.Commit))
+	for _, commit := range commits {
+		if l.graph != nil {
+			parents := []string{}
+			for _, oid := range commit.Parents {
+				if shown[oid] {
+					parents = append(parents, oid)
+				}
+			}
+			l.graph.Update(commit.Oid(), parents)
+		}
+		l.showCommit(blankLine, commit)
 		blankLine = true
 	}
 
 	return 0
 }
 
+func (l *Log) loadRefs() {
+	l.reverseRefs = l.repo.Refs.ReverseRefs(l.repo.Database)
+	l.currentRef, _ = l.repo.Refs.CurrentRef("")
+}
+
 func (l *Log) showCommit(blankLine bool, commit *database.Commit) {
 	switch l.options.Format {
 	case "", "medium":
@@ -77,20 +127,95 @@ func (l *Log) showCommit(blankLine bool, commit *database.Commit) {
 	case "oneline":
 		l.showCommitOneLine(commit)
 		blankLine = false
+	case "short", "full", "fuller":
+		l.showCommitFull(blankLine, commit)
+		blankLine = true
+	case "email":
+		l.showCommitEmail(blankLine, commit)
+		blankLine = true
+	case "raw":
+		l.showCommitRaw(blankLine, commit)
+		blankLine = true
+	default:
+		l.showCommitFormat(blankLine, commit)
+		blankLine = true
 	}
 
+	if l.graph != nil {
+		l.graph.ShowRemainder()
+	}
 	l.showPatch(blankLine, commit)
 }
 
 func (l *Log) showCommitMedium(blankLine bool, commit *database.Commit) {
 	author := commit.Author()
 
-	if l.options.Format != "oneline" && blankLine {
-		fmt.Fprintf(l.stdout, "\n")
+	l.showCommitHeader(blankLine, commit)
+	fmt.Fprintf(l.stdout, "Author: %s <%s>\n", author.Name, author.Email)
+	fmt.Fprintf(l.stdout, "Date:  %s\n", author.FormatTime(l.options.Date))
+	l.showMessage(commit.Message())
+}
+
+func (l *Log) showCommitFull(blankLine bool, commit *database.Commit) {
+	author, committer := commit.Author(), commit.Committer()
+
+	l.showCommitHeader(blankLine, commit)
+	switch l.options.Format {
+	case "short":
+		fmt.Fprintf(l.stdout, "Author: %s <%s>\n", author.Name, author.Email)
+		l.showMessage(commit.TitleLine())
+	case "full":
+		fmt.Fprintf(l.stdout, "Author: %s <%s>\n", author.Name, author.Email)
+		fmt.Fprintf(l.stdout, "Commit: %s <%s>\n", committer.Name, committer.Email)
+		l.showMessage(commit.Message())
+	case "fuller":
+		fmt.Fprintf(l.stdout, "Author:     %s <%s>\n", author.Name, author.Email)
+		fmt.Fprintf(l.stdout, "AuthorDate: %s\n", author.FormatTime(l.options.Date))
+		fmt.Fprintf(l.stdout, "Commit:     %s <%s>\n", committer.Name, committer.Email)
+		fmt.Fprintf(l.stdout, "CommitDate: %s\n", committer.FormatTime(l.options.Date))
+		l.showMessage(commit.Message())
 	}
-	fmt.Fprintf(l.stdout,
-		color.New(color.FgYellow).Sprintf("commit %s\n", l.abbrev(commit))+
-			l.decorate(commit),
+}
+
+func (l *Log) showCommitEmail(blankLine bool, commit *database.Commit) {
+	author := commit.Author()
+
+	if blankLine {
+		l.separator()
+	}
+	fmt.Fprintf(l.stdout, "From %s Mon Sep 17 00:00:00 2001\n", commit.Oid())
+	fmt.Fprintf(l.stdout, "From: %s <%s>\n", author.Name, author.Email)
+	fmt.Fprintf(l.stdout, "Date: %s\n", author.FormatTime("rfc"))
+	fmt.Fprintf(l.stdout, "Subject: [PATCH] %s\n", commit.TitleLine())
+	fmt.Fprintf(l.stdout, "\n%s", messageBody(commit.Message()))
+}
+
+func (l *Log) showCommitRaw(blankLine bool, commit *database.Commit) {
+	if blankLine {
+		l.separator()
+	}
+	fmt.Fprintf(l.stdout, "%s\n", color.New(color.FgYellow).Sprintf("commit %s", l.abbrev(commit)))
+	fmt.Fprintf(l.stdout, "tree %s\n", commit.Tree())
+	for _, oid := range commit.Parents {
+		fmt.Fprintf(l.stdout, "parent %s\n", oid)
+	}
+	for _, person := range []struct {
+		header string
+		author *database.Author
+	}{{"author", commit.Author()}, {"committer", commit.Committer()}} {
+		fmt.Fprintf(l.stdout, "%s %s <%s> %s\n",
+			person.header, person.author.Name, person.author.Email, person.author.FormatTime("raw"))
+	}
+	l.showMessage(commit.Message())
+}
+
+func (l *Log) showCommitHeader(blankLine bool, commit *database.Commit) {
+	if blankLine {
+		l.separator()
+	}
+	fmt.Fprintf(l.stdout, "%s%s\n",
+		color.New(color.FgYellow).Sprintf("commit %s", l.abbrev(commit)),
+		l.decorate(commit),
 	)
 
 	if commit.IsMerge() {
@@ -100,12 +225,12 @@ func (l *Log) showCommitMedium(blankLine bool, commit *database.Commit) {
 		}
 		fmt.Fprintf(l.stdout, "Merge: %s\n", strings.Join(oids, " "))
 	}
+}
 
-	fmt.Fprintf(l.stdout, "Author: %s <%s>\n", author.Name, author.Email)
-	fmt.Fprintf(l.stdout, "Date:  %s\n", author.ReadableTime())
+func (l *Log) showMessage(message string) {
 	fmt.Fprintf(l.stdout, "\n")
 
-	lines := strings.Split(commit.Message(), "\n")
+	lines := strings.Split(message, "\n")
 	if lines[len(lines)-1] == "" { // Replicate Ruby's String#lines
 		lines = lines[:len(lines)-1]
 	}
@@ -114,6 +239,14 @@ func (l *Log) showCommitMedium(blankLine bool, commit *database.Commit) {
 	}
 }
 
+func (l *Log) separator() {
+	if l.graph != nil {
+		l.graph.ShowPadding()
+		return
+	}
+	fmt.Fprintf(l.stdout, "\n")
+}
+
 func (l *Log) showCommitOneLine(commit *database.Commit) {
 	id := fmt.Sprintf(
 		color.New(color.FgYellow).Sprint(l.abbrev(commit)) +
@@ -139,9 +272,22 @@ func (l *Log) decorate(commit *database.Commit) string {
 		return ""
 	}
 
+	names := l.refNames(commit, true)
+	if len(names) == 0 {
+		return ""
+	}
+
+	return fmt.Sprint(
+		color.New(color.FgYellow).Sprint(" ("),
+		strings.Join(names, color.New(color.FgYellow).Sprint(", ")),
+		color.New(color.FgYellow).Sprint(")"),
+	)
+}
+
+func (l *Log) refNames(commit *database.Commit, colored bool) []string {
 	refs, ok := l.reverseRefs[commit.Oid()]
 	if !ok {
-		return ""
+		return nil
 	}
 
 	var head *repository.SymRef
@@ -156,30 +302,32 @@ func (l *Log) decorate(commit *database.Commit) string {
 
 	var names []string
 	for _, ref := range otherRefs {
-		names = append(names, l.decorationName(head, ref))
+		names = append(names, l.decorationName(head, ref, colored))
 	}
-
-	return fmt.Sprint(
-		color.New(color.FgYellow).Sprint(" ("),
-		strings.Join(names, color.New(color.FgYellow).Sprint(", ")),
-		color.New(color.FgYellow).Sprint(")"),
-	)
+	return names
 }
 
-func (l *Log) decorationName(head, ref *repository.SymRef) string {
+func (l *Log) decorationName(head, ref *repository.SymRef, colored bool) string {
 	var name string
 	switch l.options.Decorate {
-	case "short", "auto":
-		name, _ = ref.ShortName()
 	case "full":
 		name = ref.Path
+	default:
+		name, _ = ref.ShortName()
+	}
+	if strings.HasPrefix(ref.Path, repository.TagsDir()+"/") {
+		name = "tag: " + name
 	}
 
-	name = l.refColor(ref)(name)
+	if colored {
+		name = l.refColor(ref)(name)
+	}
 
 	if head != nil && ref.Path == l.currentRef.Path {
 		name = fmt.Sprintf("%s -> %s", head.Path, name)
-		name = l.refColor(head)(name)
+		if colored {
+			name = l.refColor(head)(name)
+		}
 	}
 
 	return name
@@ -189,25 +337,38 @@ func (l *Log) refColor(ref *repository.SymRef) func(a ...interface{}) string {
 	if ref.IsHead() {
 		return color.New(color.FgCyan, color.Bold).SprintFunc()
 	}
+	if strings.HasPrefix(ref.Path, repository.TagsDir()+"/") {
+		return color.New(color.FgYellow, color.Bold).SprintFunc()
+	}
 	return color.New(color.FgGreen, color.Bold).SprintFunc()
 }
 
 func (l *Log) showPatch(blankLine bool, commit *database.Commit) {
-	if !l.options.Patch {
+	if !l.options.Patch && !l.options.Stat.Any() {
 		return
 	}
 	if commit.IsMerge() {
-		l.showMergePatch(commit)
+		if l.options.Patch {
+			l.showMergePatch(blankLine, commit)
+		}
 		return
 	}
 
 	if blankLine {
 		fmt.Fprintf(l.stdout, "\n")
 	}
-	l.prindDiff.PrintCommitDiff(commit.Parent(), commit.Oid(), l.revList)
+	if l.options.Stat.Any() {
+		l.prindDiff.PrintCommitStat(commit.Parent(), commit.Oid(), l.revList)
+		if l.options.Patch {
+			fmt.Fprintf(l.stdout, "\n")
+		}
+	}
+	if l.options.Patch {
+		l.prindDiff.PrintCommitDiff(commit.Parent(), commit.Oid(), l.revList)
+	}
 }
 
-func (l *Log) showMergePatch(commit *database.Commit) {
+func (l *Log) showMergePatch(blankLine bool, commit *database.Commit) {
 	if !l.options.Combined {
 		return
 	}
@@ -231,6 +392,11 @@ func (l *Log) showMergePatch(commit *database.Commit) {
 		}
 	}
 
+	sort.Strings(paths)
+
+	if blankLine && len(paths) > 0 {
+		fmt.Fprintf(l.stdout, "\n")
+	}
 	for _, path := range paths {
 		parents := []*print_diff.Target{}
 		for _, diff := range diffs {
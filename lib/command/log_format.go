@@ -0,0 +1,264 @@
+package command
+
+import (
+	"building-git/lib/database"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// PRETTY_FORMATS are the named formats accepted by --pretty; anything else
+// is treated as a format string made of placeholders.
+var PRETTY_FORMATS = map[string]bool{
+	"oneline": true,
+	"short":   true,
+	"medium":  true,
+	"full":    true,
+	"fuller":  true,
+	"email":   true,
+	"raw":     true,
+}
+
+var (
+	COLOR_NAMES = map[string]color.Attribute{
+		"normal":  color.Reset,
+		"reset":   color.Reset,
+		"black":   color.FgBlack,
+		"red":     color.FgRed,
+		"green":   color.FgGreen,
+		"yellow":  color.FgYellow,
+		"blue":    color.FgBlue,
+		"magenta": color.FgMagenta,
+		"cyan":    color.FgCyan,
+		"white":   color.FgWhite,
+	}
+	COLOR_ATTRIBUTES = map[string]color.Attribute{
+		"bold":    color.Bold,
+		"dim":     color.Faint,
+		"italic":  color.Italic,
+		"ul":      color.Underline,
+		"blink":   color.BlinkSlow,
+		"reverse": color.ReverseVideo,
+	}
+	SHORT_COLORS = []string{"red", "green", "blue", "reset"}
+
+	PERSON_PLACEHOLDER = regexp.MustCompile(`^[ac][neNEdDrtiIs]`)
+)
+
+// IsPrettyFormat reports whether format is a named format or a format
+// string that showCommitFormat knows how to expand.
+func IsPrettyFormat(format string) bool {
+	return PRETTY_FORMATS[format] ||
+		strings.HasPrefix(format, "format:") ||
+		strings.HasPrefix(format, "tformat:") ||
+		strings.Contains(format, "%")
+}
+
+// showCommitFormat expands a format string for the commit. Strings given as
+// "format:" go between commits, so they get no newline after the last one;
+// "tformat:" and bare strings end every commit with a newline.
+func (l *Log) showCommitFormat(blankLine bool, commit *database.Commit) {
+	template, terminate := l.options.Format, true
+	switch {
+	case strings.HasPrefix(template, "format:"):
+		template, terminate = strings.TrimPrefix(template, "format:"), l.graph != nil
+	case strings.HasPrefix(template, "tformat:"):
+		template = strings.TrimPrefix(template, "tformat:")
+	}
+
+	if blankLine && !terminate {
+		fmt.Fprintf(l.stdout, "\n")
+	}
+	fmt.Fprint(l.stdout, l.expandFormat(template, commit))
+	if terminate {
+		fmt.Fprintf(l.stdout, "\n")
+	}
+}
+
+func (l *Log) expandFormat(template string, commit *database.Commit) string {
+	var out strings.Builder
+
+	for len(template) > 0 {
+		index := strings.IndexByte(template, '%')
+		if index < 0 {
+			out.WriteString(template)
+			break
+		}
+		out.WriteString(template[:index])
+		template = template[index+1:]
+
+		expansion, consumed := l.expandPlaceholder(template, commit)
+		if consumed == 0 {
+			out.WriteString("%")
+			continue
+		}
+		out.WriteString(expansion)
+		template = template[consumed:]
+	}
+
+	return out.String()
+}
+
+// expandPlaceholder expands the placeholder at the start of s, which follows
+// a "%", and returns how many bytes of s it used. Unknown placeholders use
+// nothing, so they are printed as they were written.
+func (l *Log) expandPlaceholder(s string, commit *database.Commit) (string, int) {
+	if s == "" {
+		return "", 0
+	}
+
+	switch s[0] {
+	case 'H':
+		return commit.Oid(), 1
+	case 'h':
+		return l.repo.Database.ShortOid(commit.Oid()), 1
+	case 'T':
+		return commit.Tree(), 1
+	case 't':
+		return l.repo.Database.ShortOid(commit.Tree()), 1
+	case 'P':
+		return strings.Join(commit.Parents, " "), 1
+	case 'p':
+		oids := []string{}
+		for _, oid := range commit.Parents {
+			oids = append(oids, l.repo.Database.ShortOid(oid))
+		}
+		return strings.Join(oids, " "), 1
+	case 's':
+		return commit.TitleLine(), 1
+	case 'b':
+		return messageBody(commit.Message()), 1
+	case 'B':
+		return commit.Message(), 1
+	case 'd':
+		if names := l.refNames(commit, false); len(names) > 0 {
+			return fmt.Sprintf(" (%s)", strings.Join(names, ", ")), 1
+		}
+		return "", 1
+	case 'D':
+		return strings.Join(l.refNames(commit, false), ", "), 1
+	case 'n':
+		return "\n", 1
+	case '%':
+		return "%", 1
+	case 'C':
+		return expandColor(s[1:])
+	}
+
+	if PERSON_PLACEHOLDER.MatchString(s) {
+		person := commit.Author()
+		if s[0] == 'c' {
+			person = commit.Committer()
+		}
+		return l.expandPerson(person, s[1]), 2
+	}
+
+	return "", 0
+}
+
+func (l *Log) expandPerson(person *database.Author, field byte) string {
+	switch field {
+	case 'n', 'N':
+		return person.Name
+	case 'e', 'E':
+		return person.Email
+	case 'd':
+		return person.FormatTime(l.options.Date)
+	case 'D':
+		return person.FormatTime("rfc")
+	case 'r':
+		return person.FormatTime("relative")
+	case 't':
+		return person.FormatTime("unix")
+	case 'i':
+		return person.FormatTime("iso")
+	case 'I':
+		return person.FormatTime("iso-strict")
+	case 's':
+		return person.FormatTime("short")
+	}
+	return ""
+}
+
+// expandColor handles both the %Cred shorthands and the %C(...) form, which
+// takes a list of colours and attributes such as "bold blue". Colours are
+// only written when colour output is enabled, unless "always," is given.
+func expandColor(s string) (string, int) {
+	if strings.HasPrefix(s, "(") {
+		end := strings.IndexByte(s, ')')
+		if end < 0 {
+			return "", 0
+		}
+		spec, force := s[1:end], false
+		if strings.HasPrefix(spec, "always,") {
+			spec, force = strings.TrimPrefix(spec, "always,"), true
+		}
+		spec = strings.TrimPrefix(spec, "auto,")
+
+		attributes, ok := parseColor(spec)
+		if !ok {
+			return "", 0
+		}
+		return colorCode(attributes, force), end + 2
+	}
+
+	for _, name := range SHORT_COLORS {
+		if strings.HasPrefix(s, name) {
+			return colorCode([]color.Attribute{COLOR_NAMES[name]}, false), len(name) + 1
+		}
+	}
+	return "", 0
+}
+
+// parseColor reads a colour spec the way git's config does: the first
+// colour named is the foreground, the second the background.
+func parseColor(spec string) ([]color.Attribute, bool) {
+	attributes := []color.Attribute{}
+	colors := 0
+
+	for _, word := range strings.Fields(spec) {
+		if word == "auto" {
+			continue
+		}
+		if attribute, ok := COLOR_ATTRIBUTES[word]; ok {
+			attributes = append(attributes, attribute)
+			continue
+		}
+		attribute, ok := COLOR_NAMES[word]
+		if !ok || colors > 1 {
+			return nil, false
+		}
+		colors++
+		switch {
+		case word == "normal":
+			continue
+		case colors == 2 && attribute != color.Reset:
+			attribute += color.BgBlack - color.FgBlack
+		}
+		attributes = append(attributes, attribute)
+	}
+	return attributes, true
+}
+
+func colorCode(attributes []color.Attribute, force bool) string {
+	if (color.NoColor && !force) || len(attributes) == 0 {
+		return ""
+	}
+
+	codes := []string{}
+	for _, attribute := range attributes {
+		codes = append(codes, fmt.Sprint(int(attribute)))
+	}
+	return fmt.Sprintf("\x1b[%sm", strings.Join(codes, ";"))
+}
+
+// messageBody returns the commit message after its subject paragraph.
+func messageBody(message string) string {
+	parts := strings.SplitN(message, "\n\n", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	return strings.TrimLeft(parts[1], "\n")
+}
@@ -3,9 +3,11 @@ package command
 import (
 	"building-git/lib/command/write_commit"
 	"building-git/lib/database"
+	"building-git/lib/repository"
 	"bytes"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -826,4 +828,509 @@ index 4e5ce14..4139691 100644
 			}
 		})
 	})
+
+	t.Run("draws the branches leading to a merge as a graph", func(t *testing.T) {
+		tmpDir, stdout, stderr, master, topic := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		log, _ := NewLog(tmpDir, []string{"master", "topic"}, LogOption{Format: "oneline", IsTty: false, Decorate: "auto", Graph: true}, stdout, stderr)
+		log.Run()
+
+		expected := fmt.Sprintf("* %s K\n"+
+			"*   %s J\n"+
+			"|\\  \n"+
+			"* | %s D\n"+
+			"* | %s C\n"+
+			"| | * %s H\n"+
+			"| |/  \n"+
+			"| * %s G\n"+
+			"| * %s F\n"+
+			"| * %s E\n"+
+			"|/  \n"+
+			"* %s B\n"+
+			"* %s A\n",
+			master[0],
+			master[1],
+			master[2],
+			master[3],
+			topic[0],
+			topic[1],
+			topic[2],
+			topic[3],
+			master[4],
+			master[5],
+		)
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("draws the graph alongside medium format", func(t *testing.T) {
+		tmpDir, stdout, stderr, master, topic := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		log, _ := NewLog(tmpDir, []string{"topic^^..master^"}, LogOption{IsTty: false, Decorate: "auto", Graph: true}, stdout, stderr)
+		log.Run()
+
+		r := repo(t, tmpDir)
+		date := func(oid string) string {
+			commit, _ := loadCommit(t, tmpDir, oid)
+			return commit.(*database.Commit).Author().ReadableTime()
+		}
+
+		author := "Author: A. U. Thor <author@example.com>\n"
+		expected := "*   commit " + master[1] + "\n" +
+			"|\\  Merge: " + r.Database.ShortOid(master[2]) + " " + r.Database.ShortOid(topic[1]) + "\n" +
+			"| | " + author +
+			"| | Date:  " + date(master[1]) + "\n" +
+			"| | \n" +
+			"| |     J\n" +
+			"| | \n" +
+			"| * commit " + topic[1] + "\n" +
+			"|   " + author +
+			"|   Date:  " + date(topic[1]) + "\n" +
+			"|   \n" +
+			"|       G\n" +
+			"| \n" +
+			"* commit " + master[2] + "\n" +
+			"| " + author +
+			"| Date:  " + date(master[2]) + "\n" +
+			"| \n" +
+			"|     D\n" +
+			"| \n" +
+			"* commit " + master[3] + "\n" +
+			"  " + author +
+			"  Date:  " + date(master[3]) + "\n" +
+			"  \n" +
+			"      C\n"
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("follows only the first parent of merges", func(t *testing.T) {
+		tmpDir, stdout, stderr, master, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		filter := repository.RevListFilter{FirstParent: true}
+		log, _ := NewLog(tmpDir, []string{}, LogOption{Format: "oneline", IsTty: false, Decorate: "auto", Filter: filter}, stdout, stderr)
+		log.Run()
+
+		expected := fmt.Sprintf("%s K\n%s J\n%s D\n%s C\n%s B\n%s A\n",
+			master[0], master[1], master[2], master[3], master[4], master[5])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("lists only merge commits", func(t *testing.T) {
+		tmpDir, stdout, stderr, master, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		filter := repository.RevListFilter{Merges: true}
+		log, _ := NewLog(tmpDir, []string{}, LogOption{Format: "oneline", IsTty: false, Decorate: "auto", Filter: filter}, stdout, stderr)
+		log.Run()
+
+		expected := fmt.Sprintf("%s J\n", master[1])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("leaves out merge commits", func(t *testing.T) {
+		tmpDir, stdout, stderr, master, topic := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		filter := repository.RevListFilter{NoMerges: true}
+		log, _ := NewLog(tmpDir, []string{"master^^..master", "topic^..topic"}, LogOption{Format: "oneline", IsTty: false, Decorate: "auto", Filter: filter}, stdout, stderr)
+		log.Run()
+
+		expected := fmt.Sprintf("%s K\n%s H\n", master[0], topic[0])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+}
+
+func TestLogWithFilters(t *testing.T) {
+	start := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	messages := []string{"fix: parser", "feat: graph", "fix: graph colours", "docs"}
+
+	setUp := func(t *testing.T) (tmpDir string, stdout, stderr *bytes.Buffer, commits []string) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+
+		for i, message := range messages {
+			commitFile(t, tmpDir, message, start.Add(time.Duration(i)*time.Hour))
+		}
+
+		commits = []string{}
+		for i := 0; i < len(messages); i++ {
+			rev, _ := resolveRevision(t, tmpDir, fmt.Sprintf("@~%d", i))
+			commits = append(commits, rev)
+		}
+		return
+	}
+
+	runLog := func(tmpDir string, stdout, stderr *bytes.Buffer, filter repository.RevListFilter) {
+		log, _ := NewLog(tmpDir, []string{}, LogOption{Format: "oneline", IsTty: false, Decorate: "auto", Filter: filter}, stdout, stderr)
+		log.Run()
+	}
+
+	t.Run("limits the number of commits", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		n := 2
+		runLog(tmpDir, stdout, stderr, repository.RevListFilter{MaxCount: &n})
+
+		expected := fmt.Sprintf("%s docs\n%s fix: graph colours\n", commits[0], commits[1])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("lists commits within a date range", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		runLog(tmpDir, stdout, stderr, repository.RevListFilter{
+			Since: start.Add(30 * time.Minute),
+			Until: start.Add(150 * time.Minute),
+		})
+
+		expected := fmt.Sprintf("%s fix: graph colours\n%s feat: graph\n", commits[1], commits[2])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("lists commits whose message matches any pattern", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		runLog(tmpDir, stdout, stderr, repository.RevListFilter{Grep: []string{"^fix", "^docs"}})
+
+		expected := fmt.Sprintf("%s docs\n%s fix: graph colours\n%s fix: parser\n", commits[0], commits[1], commits[3])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("lists commits whose message matches every pattern", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		runLog(tmpDir, stdout, stderr, repository.RevListFilter{Grep: []string{"fix", "graph"}, AllMatch: true})
+
+		expected := fmt.Sprintf("%s fix: graph colours\n", commits[1])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("matches patterns without regard to case", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		runLog(tmpDir, stdout, stderr, repository.RevListFilter{Grep: []string{"FEAT"}, IgnoreCase: true})
+
+		expected := fmt.Sprintf("%s feat: graph\n", commits[2])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("matches the author and committer", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		n := 1
+		runLog(tmpDir, stdout, stderr, repository.RevListFilter{Authors: []string{"Thor"}, Committers: []string{"@example.com"}, MaxCount: &n})
+
+		expected := fmt.Sprintf("%s docs\n", commits[0])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+
+		stdout.Reset()
+		runLog(tmpDir, stdout, stderr, repository.RevListFilter{Authors: []string{"nobody"}})
+		if got := stdout.String(); got != "" {
+			t.Errorf("want no commits, but got %q", got)
+		}
+	})
+
+	t.Run("lists commits that change the number of occurrences of a string", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		runLog(tmpDir, stdout, stderr, repository.RevListFilter{Pickaxe: "graph"})
+
+		expected := fmt.Sprintf("%s docs\n%s feat: graph\n", commits[0], commits[2])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("lists commits that change the occurrences of a regular expression", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		runLog(tmpDir, stdout, stderr, repository.RevListFilter{Pickaxe: "GRAPH$", PickaxeRegex: true, IgnoreCase: true})
+
+		expected := fmt.Sprintf("%s fix: graph colours\n%s feat: graph\n", commits[1], commits[2])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("lists commits whose added or removed lines match", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		runLog(tmpDir, stdout, stderr, repository.RevListFilter{DiffGrep: "colours$"})
+
+		expected := fmt.Sprintf("%s docs\n%s fix: graph colours\n", commits[0], commits[1])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("rejects -S and -G together", func(t *testing.T) {
+		tmpDir, stdout, stderr, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		_, err := NewLog(tmpDir, []string{}, LogOption{Filter: repository.RevListFilter{Pickaxe: "a", DiffGrep: "b"}}, stdout, stderr)
+		if err == nil {
+			t.Errorf("want an error for -S with -G")
+		}
+	})
+
+	t.Run("rejects an invalid pattern", func(t *testing.T) {
+		tmpDir, stdout, stderr, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		_, err := NewLog(tmpDir, []string{}, LogOption{Filter: repository.RevListFilter{Grep: []string{"("}}}, stdout, stderr)
+		if err == nil {
+			t.Errorf("want an error for an invalid pattern")
+		}
+	})
+}
+
+func TestLogWithPrettyFormats(t *testing.T) {
+	commitTime := time.Date(2023, 4, 1, 12, 0, 0, 0, time.FixedZone("", 9*60*60))
+
+	setUp := func(t *testing.T) (tmpDir string, stdout, stderr *bytes.Buffer, commits []*database.Commit) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+
+		commitFile(t, tmpDir, "First", commitTime)
+		commitFile(t, tmpDir, "Second\n\nWith a body.", commitTime.Add(time.Hour))
+
+		commits = []*database.Commit{}
+		for _, rev := range []string{"@", "@^"} {
+			cobj, _ := loadCommit(t, tmpDir, rev)
+			commits = append(commits, cobj.(*database.Commit))
+		}
+		return
+	}
+
+	runLog := func(tmpDir string, stdout, stderr *bytes.Buffer, options LogOption) {
+		options.Decorate = "auto"
+		log, _ := NewLog(tmpDir, []string{}, options, stdout, stderr)
+		log.Run()
+	}
+
+	t.Run("prints a log in short format", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		runLog(tmpDir, stdout, stderr, LogOption{Format: "short"})
+
+		expected := fmt.Sprintf(`commit %s
+Author: A. U. Thor <author@example.com>
+
+    Second
+
+commit %s
+Author: A. U. Thor <author@example.com>
+
+    First
+`, commits[0].Oid(), commits[1].Oid())
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("prints a log in fuller format with ISO dates", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		runLog(tmpDir, stdout, stderr, LogOption{Format: "fuller", Date: "iso"})
+
+		expected := fmt.Sprintf(`commit %s
+Author:     A. U. Thor <author@example.com>
+AuthorDate: 2023-04-01 13:00:00 +0900
+Commit:     A. U. Thor <author@example.com>
+CommitDate: 2023-04-01 13:00:00 +0900
+
+    Second
+    
+    With a body.
+
+commit %s
+Author:     A. U. Thor <author@example.com>
+AuthorDate: 2023-04-01 12:00:00 +0900
+Commit:     A. U. Thor <author@example.com>
+CommitDate: 2023-04-01 12:00:00 +0900
+
+    First
+`, commits[0].Oid(), commits[1].Oid())
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("prints a log in email format", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		n := 1
+		runLog(tmpDir, stdout, stderr, LogOption{Format: "email", Filter: repository.RevListFilter{MaxCount: &n}})
+
+		expected := fmt.Sprintf(`From %s Mon Sep 17 00:00:00 2001
+From: A. U. Thor <author@example.com>
+Date: Sat, 1 Apr 2023 13:00:00 +0900
+Subject: [PATCH] Second
+
+With a body.
+`, commits[0].Oid())
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("prints a log in raw format", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		n := 1
+		runLog(tmpDir, stdout, stderr, LogOption{Format: "raw", Filter: repository.RevListFilter{MaxCount: &n}})
+
+		expected := fmt.Sprintf(`commit %s
+tree %s
+parent %s
+author A. U. Thor <author@example.com> 1680321600 +0900
+committer A. U. Thor <author@example.com> 1680321600 +0900
+
+    Second
+    
+    With a body.
+`, commits[0].Oid(), commits[0].Tree(), commits[1].Oid())
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("expands placeholders in a format string", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		runLog(tmpDir, stdout, stderr, LogOption{Format: "format:%H %h %T%n%P|%an <%ae> %ad %as %cn|%s|%b|%D|100%%"})
+
+		short := func(oid string) string { return repo(t, tmpDir).Database.ShortOid(oid) }
+		expected := fmt.Sprintf("%s %s %s\n%s|A. U. Thor <author@example.com> Sat Apr 1 13:00:00 2023 +0900 2023-04-01 A. U. Thor|Second|With a body.\n|HEAD -> master|100%%\n",
+			commits[0].Oid(), short(commits[0].Oid()), commits[0].Tree(), commits[1].Oid())
+		expected += fmt.Sprintf("%s %s %s\n|A. U. Thor <author@example.com> Sat Apr 1 12:00:00 2023 +0900 2023-04-01 A. U. Thor|First|||100%%",
+			commits[1].Oid(), short(commits[1].Oid()), commits[1].Tree())
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("ends every commit with a newline in tformat", func(t *testing.T) {
+		tmpDir, stdout, stderr, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		runLog(tmpDir, stdout, stderr, LogOption{Format: "tformat:%s%d %x", Date: "unix"})
+
+		expected := "Second (HEAD -> master) %x\nFirst %x\n"
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+}
+
+func TestLogFollowingRenames(t *testing.T) {
+	lines := func(from, to int) string {
+		var text strings.Builder
+		for i := from; i <= to; i++ {
+			fmt.Fprintf(&text, "%d\n", i)
+		}
+		return text.String()
+	}
+
+	setUp := func(t *testing.T) (tmpDir string, stdout, stderr *bytes.Buffer, commits []string) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+		start := time.Now()
+
+		commitTreeHelper(t, tmpDir, "one", map[string]interface{}{"a.txt": lines(1, 20), "other.txt": "x"}, start)
+		commitTreeHelper(t, tmpDir, "two", map[string]interface{}{"a.txt": lines(1, 21)}, start.Add(time.Second))
+		commitTreeHelper(t, tmpDir, "move", map[string]interface{}{"a.txt": nil, "b.txt": lines(1, 21)}, start.Add(2*time.Second))
+		commitTreeHelper(t, tmpDir, "four", map[string]interface{}{"b.txt": lines(0, 21)}, start.Add(3*time.Second))
+		commitTreeHelper(t, tmpDir, "five", map[string]interface{}{"other.txt": "y"}, start.Add(4*time.Second))
+
+		commits = []string{}
+		for i := 0; i < 5; i++ {
+			rev, _ := resolveRevision(t, tmpDir, fmt.Sprintf("@~%d", i))
+			commits = append(commits, rev)
+		}
+		return
+	}
+
+	t.Run("stops at the commit that moved the file without --follow", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		log, _ := NewLog(tmpDir, []string{"b.txt"}, LogOption{Format: "oneline", Decorate: "auto"}, stdout, stderr)
+		log.Run()
+
+		expected := fmt.Sprintf("%s four\n%s move\n", commits[1], commits[2])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("continues with the old path across a rename", func(t *testing.T) {
+		tmpDir, stdout, stderr, commits := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		log, _ := NewLog(tmpDir, []string{"b.txt"}, LogOption{Format: "oneline", Decorate: "auto", Follow: true}, stdout, stderr)
+		log.Run()
+
+		expected := fmt.Sprintf("%s four\n%s move\n%s two\n%s one\n", commits[1], commits[2], commits[3], commits[4])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("shows the rename in the patch of the commit that moved the file", func(t *testing.T) {
+		tmpDir, stdout, stderr, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		log, _ := NewLog(tmpDir, []string{"b.txt", "@~3..@~2"}, LogOption{Format: "oneline", Decorate: "auto", Follow: true, Patch: true}, stdout, stderr)
+		log.Run()
+
+		if got := stdout.String(); !strings.Contains(got, "rename from a.txt\nrename to b.txt\n") {
+			t.Errorf("want the rename in the patch, but got %q", got)
+		}
+	})
+
+	t.Run("requires exactly one path", func(t *testing.T) {
+		tmpDir, stdout, stderr, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		_, err := NewLog(tmpDir, []string{}, LogOption{Follow: true}, stdout, stderr)
+		if err == nil {
+			t.Errorf("want an error without a path")
+		}
+	})
 }
@@ -0,0 +1,26 @@
+package command
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	if len(os.Args) > 2 {
+		switch os.Args[1] {
+		case "upload-pack":
+			uploadPack, err := NewUploadPack(os.Args[2:], os.Stdin, os.Stdout, os.Stderr)
+			if err != nil {
+				os.Exit(128)
+			}
+			os.Exit(uploadPack.Run())
+		case "receive-pack":
+			receivePack, err := NewReceivePack(os.Args[2:], os.Stdin, os.Stdout, os.Stderr)
+			if err != nil {
+				os.Exit(128)
+			}
+			os.Exit(receivePack.Run())
+		}
+	}
+	os.Exit(m.Run())
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -18,11 +19,13 @@ const (
 	Continue MergeMode = "continue"
 	Abort    MergeMode = "abort"
 	Quit     MergeMode = "quit"
+	Skip     MergeMode = "skip"
 )
 
 type MergeOption struct {
 	write_commit.ReadOption
 	Mode      MergeMode
+	Strategy  string
 	Edit      bool
 	IsTTY     bool
 	EditorCmd func(path string) editor.Executable
@@ -78,6 +81,12 @@ func (m *Merge) Run() int {
 		return 0
 	}
 
+	if m.options.Strategy != "" && !merge.IsStrategy(m.options.Strategy) {
+		fmt.Fprintf(m.stderr, "Could not find merge strategy '%s'.\n", m.options.Strategy)
+		fmt.Fprintf(m.stderr, "Available strategies are: %s.\n", strings.Join(merge.STRATEGIES, " "))
+		return 1
+	}
+
 	if m.writeCommit.PendingCommit().InProgress() {
 		if err := m.handleInProgressMerge(); err != nil {
 			fmt.Fprintf(m.stderr, "%s\n", err.Error())
@@ -122,6 +131,9 @@ func (m *Merge) resolveMerge() error {
 			fmt.Fprintf(m.stdout, info+"\n")
 		},
 	)
+	if m.options.Strategy != "" {
+		merge.SetStrategy(m.options.Strategy)
+	}
 	merge.Execute()
 
 	m.repo.Index.WriteUpdates()
@@ -196,7 +208,7 @@ func (m *Merge) handleFastForward() {
 	m.repo.Migration(treeDiff).ApplyChanges()
 
 	m.repo.Index.WriteUpdates()
-	m.repo.Refs.UpdateHead(m.inputs.RightOid())
+	m.repo.Refs.UpdateHead(m.inputs.RightOid(), fmt.Sprintf("merge %s: Fast-forward", m.inputs.RightName()))
 }
 
 func (m *Merge) handleAbort() error {
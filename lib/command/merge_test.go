@@ -1330,6 +1330,205 @@ A  nest/f.txt/g.txt
 	})
 }
 
+func TestMergeUnconflictedMergeRenameEdit(t *testing.T) {
+	tmpDir, stdout, stderr := setupTestEnvironment(t)
+	defer os.RemoveAll(tmpDir)
+
+	merge3(t, tmpDir, map[string]interface{}{
+		"f.txt": "1\n2\n3\n4\n",
+	}, map[string]interface{}{
+		"f.txt": nil,
+		"g.txt": "1\n2\n3\n4\n",
+	}, map[string]interface{}{
+		"f.txt": "1\n2\n3\nfour\n",
+	}, stdout, stderr)
+
+	assertCleanMerge(t, tmpDir)
+	assertWorkspace(t, tmpDir, map[string]string{
+		"g.txt": "1\n2\n3\nfour\n",
+	})
+}
+
+func TestMergeUnconflictedMergeEditRename(t *testing.T) {
+	tmpDir, stdout, stderr := setupTestEnvironment(t)
+	defer os.RemoveAll(tmpDir)
+
+	merge3(t, tmpDir, map[string]interface{}{
+		"f.txt": "1\n2\n3\n4\n",
+	}, map[string]interface{}{
+		"f.txt": "one\n2\n3\n4\n",
+	}, map[string]interface{}{
+		"f.txt": nil,
+		"g.txt": "1\n2\n3\nfour\n",
+	}, stdout, stderr)
+
+	assertCleanMerge(t, tmpDir)
+	assertWorkspace(t, tmpDir, map[string]string{
+		"g.txt": "one\n2\n3\nfour\n",
+	})
+}
+
+func TestMergeConflictedMergeRenameEdit(t *testing.T) {
+	setUp := func(t *testing.T) (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+
+		merge3(t, tmpDir, map[string]interface{}{
+			"f.txt": "1\n2\n3\n4\n",
+		}, map[string]interface{}{
+			"f.txt": nil,
+			"g.txt": "one\n2\n3\n4\n",
+		}, map[string]interface{}{
+			"f.txt": "uno\n2\n3\n4\n",
+		}, stdout, stderr)
+
+		return
+	}
+
+	t.Run("prints the merge conflicts", func(t *testing.T) {
+		tmpDir, stdout, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		expected := `Auto-merging g.txt
+CONFLICT (content): Merge conflict in g.txt
+Automatic merge failed; fix conflicts and then commit the result.`
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("records the conflict at the new path", func(t *testing.T) {
+		tmpDir, _, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		assertIndexEntriesPathWithStage(t, tmpDir, []struct {
+			path  string
+			stage string
+		}{
+			{"g.txt", "1"},
+			{"g.txt", "2"},
+			{"g.txt", "3"},
+		})
+	})
+
+	t.Run("reports the conflict in the status", func(t *testing.T) {
+		tmpDir, _, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		expected := `UU g.txt
+`
+		assertGitStatus(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), expected)
+	})
+}
+
+func TestMergeConflictedMergeRenameDelete(t *testing.T) {
+	setUp := func(t *testing.T) (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+
+		merge3(t, tmpDir, map[string]interface{}{
+			"f.txt": "1\n2\n3\n4\n",
+		}, map[string]interface{}{
+			"f.txt": nil,
+			"g.txt": "1\n2\n3\n4\n",
+		}, map[string]interface{}{
+			"f.txt": nil,
+		}, stdout, stderr)
+
+		return
+	}
+
+	t.Run("prints the merge conflicts", func(t *testing.T) {
+		tmpDir, stdout, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		expected := `CONFLICT (rename/delete): f.txt renamed to g.txt in HEAD, but deleted in topic.
+Automatic merge failed; fix conflicts and then commit the result.`
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("reports the conflict in the status", func(t *testing.T) {
+		tmpDir, _, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		expected := `UD g.txt
+`
+		assertGitStatus(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), expected)
+	})
+}
+
+func TestMergeConflictedMergeDeleteRename(t *testing.T) {
+	tmpDir, stdout, stderr := setupTestEnvironment(t)
+	defer os.RemoveAll(tmpDir)
+
+	merge3(t, tmpDir, map[string]interface{}{
+		"f.txt": "1\n2\n3\n4\n",
+	}, map[string]interface{}{
+		"f.txt": nil,
+	}, map[string]interface{}{
+		"f.txt": nil,
+		"g.txt": "1\n2\n3\n4\n",
+	}, stdout, stderr)
+
+	assertWorkspace(t, tmpDir, map[string]string{
+		"g.txt": "1\n2\n3\n4\n",
+	})
+
+	expected := `DU g.txt
+`
+	assertGitStatus(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), expected)
+}
+
+func TestMergeConflictedMergeRenameRename(t *testing.T) {
+	setUp := func(t *testing.T) (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+
+		merge3(t, tmpDir, map[string]interface{}{
+			"f.txt": "1\n2\n3\n4\n",
+		}, map[string]interface{}{
+			"f.txt": nil,
+			"g.txt": "1\n2\n3\n4\n",
+		}, map[string]interface{}{
+			"f.txt": nil,
+			"h.txt": "1\n2\n3\n4\n",
+		}, stdout, stderr)
+
+		return
+	}
+
+	t.Run("prints the merge conflicts", func(t *testing.T) {
+		tmpDir, stdout, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		expected := `CONFLICT (rename/rename): f.txt renamed to g.txt in HEAD and to h.txt in topic.
+Automatic merge failed; fix conflicts and then commit the result.`
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("puts both versions in the workspace", func(t *testing.T) {
+		tmpDir, _, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		assertWorkspace(t, tmpDir, map[string]string{
+			"g.txt": "1\n2\n3\n4\n",
+			"h.txt": "1\n2\n3\n4\n",
+		})
+	})
+
+	t.Run("reports the conflict in the status", func(t *testing.T) {
+		tmpDir, _, _ := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		expected := `DD f.txt
+AU g.txt
+UA h.txt
+`
+		assertGitStatus(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), expected)
+	})
+}
+
 func TestMergeMultipleCommonAncestors(t *testing.T) {
 	//   A   B   C       M1  H   M2
 	//   o---o---o-------o---o---o
@@ -1430,6 +1629,100 @@ func TestMergeMultipleCommonAncestors(t *testing.T) {
 	})
 }
 
+func TestMergeCrissCross(t *testing.T) {
+	//   A   B   M1  D
+	//   o---o---o---o [master]
+	//    \   \ /
+	//     \   X
+	//      \ / \
+	//       o---o---o [topic]
+	//       C   M2  E
+
+	setUp := func(t *testing.T) (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+
+		commitTree(t, tmpDir, "A", map[string]string{
+			"f.txt": "a\nb\nc\nd\ne\n",
+		}, time.Now())
+		commitTree(t, tmpDir, "B", map[string]string{
+			"f.txt": "A\nb\nc\nd\ne\n",
+		}, time.Now())
+
+		brunchCmd, _ := NewBranch(tmpDir, []string{"topic", "master^"}, BranchOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		brunchCmd.Run()
+		checkout(tmpDir, new(bytes.Buffer), new(bytes.Buffer), "topic")
+		commitTree(t, tmpDir, "C", map[string]string{
+			"f.txt": "a\nb\nc\nd\nE\n",
+		}, time.Now())
+		mergeCommit(t, tmpDir, "master", MergeOption{ReadOption: write_commit.ReadOption{Message: "M2"}}, new(bytes.Buffer), new(bytes.Buffer))
+		commitTree(t, tmpDir, "E", map[string]string{
+			"f.txt": "A\nb\nc\nd\nX\n",
+		}, time.Now())
+
+		checkout(tmpDir, new(bytes.Buffer), new(bytes.Buffer), "master")
+		mergeCommit(t, tmpDir, "topic^^", MergeOption{ReadOption: write_commit.ReadOption{Message: "M1"}}, new(bytes.Buffer), new(bytes.Buffer))
+		commitTree(t, tmpDir, "D", map[string]string{
+			"f.txt": "Y\nb\nC\nd\nE\n",
+		}, time.Now())
+
+		return
+	}
+
+	t.Run("conflicts when merging from a single base", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		options := MergeOption{
+			ReadOption: write_commit.ReadOption{Message: "merge topic"},
+		}
+		status := mergeCommit(t, tmpDir, "topic", options, stdout, stderr)
+
+		if status != 1 {
+			t.Errorf("want %q, but got %q", 1, status)
+		}
+	})
+
+	t.Run("merges the common ancestors into a virtual base", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		options := MergeOption{
+			ReadOption: write_commit.ReadOption{Message: "merge topic"},
+			Strategy:   "recursive",
+		}
+		status := mergeCommit(t, tmpDir, "topic", options, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %q, but got %q", 0, status)
+		}
+
+		assertWorkspace(t, tmpDir, map[string]string{
+			"f.txt": "Y\nb\nC\nd\nX\n",
+		})
+
+		assertGitStatus(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), "")
+	})
+
+	t.Run("rejects an unknown strategy", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		options := MergeOption{
+			ReadOption: write_commit.ReadOption{Message: "merge topic"},
+			Strategy:   "octopus",
+		}
+		status := mergeCommit(t, tmpDir, "topic", options, stdout, stderr)
+
+		expected := "Could not find merge strategy 'octopus'.\nAvailable strategies are: recursive resolve.\n"
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		if status != 1 {
+			t.Errorf("want %q, but got %q", 1, status)
+		}
+	})
+}
+
 func TestMergeConflictResolution(t *testing.T) {
 	setUp := func(t *testing.T) (tmpDir string, stdout, stderr *bytes.Buffer) {
 		tmpDir, stdout, stderr = setupTestEnvironment(t)
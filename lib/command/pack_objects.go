@@ -0,0 +1,92 @@
+package command
+
+import (
+	"bufio"
+	"building-git/lib/merge"
+	"building-git/lib/pack"
+	"building-git/lib/repository"
+	"building-git/lib/repository/remotes"
+	"io"
+)
+
+func writePackedObjects(repo *repository.Repository, revList *repository.RevList, output io.Writer, allowOfs bool) (*pack.Writer, error) {
+	buffer := bufio.NewWriter(output)
+	writer := pack.NewWriter(buffer, repo.Database, pack.WriterOption{AllowOfs: allowOfs})
+
+	for _, object := range revList.Each() {
+		if err := writer.Add(object.Oid(), revList.Path(object.Oid())); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.WriteObjects(); err != nil {
+		return nil, err
+	}
+	return writer, buffer.Flush()
+}
+
+func sendPackedObjects(repo *repository.Repository, conn *remotes.Protocol, revs []string) error {
+	revList, err := repository.NewRevList(repo, revs, repository.RevListOption{
+		Objects: true,
+		Missing: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = writePackedObjects(repo, revList, conn.Output(), conn.IsCapable("ofs-delta"))
+	return err
+}
+
+func recvPackedObjects(repo *repository.Repository, conn *remotes.Protocol, unpackLimit int, prefix string) error {
+	stream := pack.NewStream(conn.Input(), []byte(prefix))
+	reader := pack.NewReader(stream)
+	if err := reader.ReadHeader(); err != nil {
+		return err
+	}
+
+	var err error
+	if unpackLimit > 0 && reader.Count > unpackLimit {
+		err = pack.NewIndexer(repo.Database, reader, stream, repo.Database.PackPath()).ProcessPack()
+	} else {
+		err = pack.NewUnpacker(repo.Database, reader, stream).ProcessPack()
+	}
+	repo.Database.Reload()
+	return err
+}
+
+func fastForwardError(repo *repository.Repository, oldOid, newOid string) string {
+	if oldOid == "" || newOid == "" {
+		return ""
+	}
+	if !repo.Database.Has(oldOid) {
+		return "fetch first"
+	}
+	if !isFastForward(repo, oldOid, newOid) {
+		return "non-fast-forward"
+	}
+	return ""
+}
+
+func isFastForward(repo *repository.Repository, oldOid, newOid string) bool {
+	oldCommit, err := repo.Database.Peel(oldOid)
+	if err != nil || oldCommit.Type() != "commit" {
+		return false
+	}
+	newCommit, err := repo.Database.Peel(newOid)
+	if err != nil || newCommit.Type() != "commit" {
+		return false
+	}
+	oldOid = oldCommit.Oid()
+
+	common := merge.NewCommonAncestors(repo.Database, oldOid, []string{newCommit.Oid()})
+	common.Find()
+	return common.IsMarked(oldOid, "parent2")
+}
+
+func configInt(repo *repository.Repository, key []string) int {
+	value, _ := repo.Config.Get(key)
+	if n, ok := value.(int); ok {
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"building-git/lib/repository"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+type PackRefsOption struct {
+	All     bool
+	NoPrune bool
+}
+
+type PackRefs struct {
+	rootPath string
+	args     []string
+	options  PackRefsOption
+	repo     *repository.Repository
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+func NewPackRefs(dir string, args []string, options PackRefsOption, stdout, stderr io.Writer) (*PackRefs, error) {
+	rootPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(rootPath)
+
+	return &PackRefs{
+		rootPath: rootPath,
+		args:     args,
+		options:  options,
+		repo:     repo,
+		stdout:   stdout,
+		stderr:   stderr,
+	}, nil
+}
+
+func (p *PackRefs) Run() int {
+	err := p.repo.Refs.PackRefs(p.repo.Database, p.options.All, !p.options.NoPrune)
+	if err != nil {
+		fmt.Fprintf(p.stderr, "fatal: %v\n", err)
+		return 128
+	}
+	return 0
+}
@@ -0,0 +1,259 @@
+package command
+
+import (
+	"building-git/lib/command/write_commit"
+	"building-git/lib/repository"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPackRefs(t *testing.T) {
+	setup := func() (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+		for _, message := range []string{"first", "second"} {
+			commitTree(t, tmpDir, message, map[string]string{"file.txt": message}, time.Now())
+		}
+		branch, _ := NewBranch(tmpDir, []string{"topic", "@^"}, BranchOption{}, stdout, stderr)
+		branch.Run()
+
+		os.Setenv("GIT_AUTHOR_NAME", "A. U. Thor")
+		os.Setenv("GIT_AUTHOR_EMAIL", "author@example.com")
+		defer os.Unsetenv("GIT_AUTHOR_NAME")
+		defer os.Unsetenv("GIT_AUTHOR_EMAIL")
+
+		tag, _ := NewTag(tmpDir, []string{"v1.0"}, TagOption{}, stdout, stderr)
+		tag.Run(time.Now())
+		options := TagOption{ReadOption: write_commit.ReadOption{Message: "Release 2.0"}}
+		tag, _ = NewTag(tmpDir, []string{"v2.0"}, options, stdout, stderr)
+		tag.Run(time.Now())
+		return
+	}
+
+	packRefs := func(tmpDir string, options PackRefsOption) int {
+		cmd, _ := NewPackRefs(tmpDir, []string{}, options, new(bytes.Buffer), new(bytes.Buffer))
+		return cmd.Run()
+	}
+
+	readPackedRefs := func(tmpDir string) string {
+		data, _ := os.ReadFile(filepath.Join(tmpDir, ".git", "packed-refs"))
+		return string(data)
+	}
+
+	exists := func(tmpDir, name string) bool {
+		_, err := os.Stat(filepath.Join(tmpDir, ".git", name))
+		return err == nil
+	}
+
+	t.Run("packs only tags by default", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		status := packRefs(tmpDir, PackRefsOption{})
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		r := repo(t, tmpDir)
+		v1, _ := r.Refs.ReadRef("refs/tags/v1.0")
+		v2, _ := r.Refs.ReadRef("refs/tags/v2.0")
+		head, _ := resolveRevision(t, tmpDir, "@")
+
+		expected := fmt.Sprintf(`# pack-refs with: peeled fully-peeled sorted 
+%s refs/tags/v1.0
+%s refs/tags/v2.0
+^%s
+`, v1, v2, head)
+		if got := readPackedRefs(tmpDir); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		for _, name := range []string{"refs/tags/v1.0", "refs/tags/v2.0"} {
+			if exists(tmpDir, name) {
+				t.Errorf("want %s to be pruned", name)
+			}
+		}
+		if !exists(tmpDir, "refs/heads/master") {
+			t.Errorf("want refs/heads/master to remain loose")
+		}
+	})
+
+	t.Run("packs every ref with --all", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		expected := map[string]string{}
+		for _, name := range []string{"master", "topic", "v1.0", "v2.0"} {
+			expected[name], _ = resolveRevision(t, tmpDir, name)
+		}
+
+		packRefs(tmpDir, PackRefsOption{All: true})
+
+		if exists(tmpDir, "refs/heads/master") || exists(tmpDir, "refs/heads/topic") {
+			t.Errorf("want branches to be pruned")
+		}
+		for name, oid := range expected {
+			if got, _ := resolveRevision(t, tmpDir, name); got != oid {
+				t.Errorf("%s: want %q, but got %q", name, oid, got)
+			}
+		}
+	})
+
+	t.Run("keeps loose refs with --no-prune", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		packRefs(tmpDir, PackRefsOption{All: true, NoPrune: true})
+
+		if !exists(tmpDir, "refs/heads/topic") {
+			t.Errorf("want refs/heads/topic to remain loose")
+		}
+	})
+
+	t.Run("lists packed branches and tags", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		packRefs(tmpDir, PackRefsOption{All: true})
+
+		branch, _ := NewBranch(tmpDir, []string{}, BranchOption{}, stdout, stderr)
+		branch.Run()
+		if expected := "* master\n  topic\n"; stdout.String() != expected {
+			t.Errorf("want %q, but got %q", expected, stdout.String())
+		}
+
+		stdout.Reset()
+		tag, _ := NewTag(tmpDir, []string{}, TagOption{}, stdout, stderr)
+		tag.Run(time.Now())
+		if expected := "v1.0\nv2.0\n"; stdout.String() != expected {
+			t.Errorf("want %q, but got %q", expected, stdout.String())
+		}
+	})
+
+	t.Run("prefers a loose ref over a packed one", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		packRefs(tmpDir, PackRefsOption{All: true})
+		commitTree(t, tmpDir, "third", map[string]string{"file.txt": "third"}, time.Now())
+
+		if !exists(tmpDir, "refs/heads/master") {
+			t.Errorf("want refs/heads/master to be written loose")
+		}
+		head, _ := repo(t, tmpDir).Refs.ReadHead()
+		if got, _ := resolveRevision(t, tmpDir, "master"); got != head {
+			t.Errorf("want %q, but got %q", head, got)
+		}
+
+		branch, _ := NewBranch(tmpDir, []string{}, BranchOption{}, stdout, stderr)
+		branch.Run()
+		if expected := "* master\n  topic\n"; stdout.String() != expected {
+			t.Errorf("want %q, but got %q", expected, stdout.String())
+		}
+	})
+
+	t.Run("checks out a packed branch", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		packRefs(tmpDir, PackRefsOption{All: true})
+		checkout(tmpDir, stdout, stderr, "topic")
+
+		ref, _ := repo(t, tmpDir).Refs.CurrentRef("")
+		if ref.Path != "refs/heads/topic" {
+			t.Errorf("want %q, but got %q", "refs/heads/topic", ref.Path)
+		}
+		assertWorkspace(t, tmpDir, map[string]string{"file.txt": "first"})
+	})
+
+	t.Run("removes deleted branches and tags from packed-refs", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		packRefs(tmpDir, PackRefsOption{All: true})
+
+		branch, _ := NewBranch(tmpDir, []string{"topic"}, BranchOption{Delete: true, Force: true}, stdout, stderr)
+		if status := branch.Run(); status != 0 {
+			t.Errorf("want %d, but got %d: %s", 0, status, stderr.String())
+		}
+		tag, _ := NewTag(tmpDir, []string{"v2.0"}, TagOption{Delete: true}, stdout, stderr)
+		tag.Run(time.Now())
+
+		master, _ := resolveRevision(t, tmpDir, "master")
+		v1, _ := resolveRevision(t, tmpDir, "v1.0")
+		expected := fmt.Sprintf(`# pack-refs with: peeled fully-peeled sorted 
+%s refs/heads/master
+%s refs/tags/v1.0
+`, master, v1)
+		if got := readPackedRefs(tmpDir); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		if _, err := resolveRevision(t, tmpDir, "topic"); err == nil {
+			t.Errorf("expected topic to be deleted")
+		}
+	})
+
+	t.Run("reads a packed-refs file with peeled lines", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		first, _ := resolveRevision(t, tmpDir, "@^")
+		head, _ := resolveRevision(t, tmpDir, "@")
+		v2, _ := repo(t, tmpDir).Refs.ReadRef("refs/tags/v2.0")
+		content := fmt.Sprintf("# pack-refs with: peeled fully-peeled sorted \n%s refs/remotes/origin/master\n%s refs/tags/release\n^%s\n", first, v2, head)
+		writeFile(t, tmpDir, ".git/packed-refs", content)
+
+		if got, _ := resolveRevision(t, tmpDir, "origin/master"); got != first {
+			t.Errorf("want %q, but got %q", first, got)
+		}
+		if got, _ := resolveRevision(t, tmpDir, "release"); got != v2 {
+			t.Errorf("want %q, but got %q", v2, got)
+		}
+		if got, _ := repository.NewRevision(repo(t, tmpDir), "release").Resolve(repository.COMMIT); got != head {
+			t.Errorf("want %q, but got %q", head, got)
+		}
+	})
+
+	t.Run("keeps lookups on the same refs in step with packed-refs", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		refs := repo(t, tmpDir).Refs
+		topic, _ := refs.ReadRef("topic")
+		if err := refs.PackRefs(repo(t, tmpDir).Database, true, true); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := refs.ReadRef("topic"); got != topic {
+			t.Errorf("want %q, but got %q", topic, got)
+		}
+
+		if _, err := refs.DeleteBranch("topic"); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := refs.ReadRef("topic"); got != "" {
+			t.Errorf("want topic to be deleted, but got %q", got)
+		}
+	})
+	t.Run("sees packed-refs written through other refs", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		refs, other := repo(t, tmpDir).Refs, repo(t, tmpDir).Refs
+		topic, _ := refs.ReadRef("topic")
+		if err := other.PackRefs(repo(t, tmpDir).Database, true, true); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := refs.ReadRef("topic"); got != topic {
+			t.Errorf("want %q, but got %q", topic, got)
+		}
+
+		if _, err := other.DeleteBranch("topic"); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := refs.ReadRef("topic"); got != "" {
+			t.Errorf("want topic to be deleted, but got %q", got)
+		}
+	})
+}
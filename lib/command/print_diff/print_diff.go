@@ -43,10 +43,13 @@ func (t *Target) diffPath() string {
 }
 
 type PrintDiff struct {
-	rootPath string
-	repo     *repository.Repository
-	stdout   io.Writer
-	stderr   io.Writer
+	rootPath  string
+	repo      *repository.Repository
+	renames   *database.RenameOption
+	algorithm diff.Algorithm
+	stat      StatOption
+	stdout    io.Writer
+	stderr    io.Writer
 }
 
 func NewPrintDiff(dir string, stdout, stderr io.Writer) (*PrintDiff, error) {
@@ -57,13 +60,27 @@ func NewPrintDiff(dir string, stdout, stderr io.Writer) (*PrintDiff, error) {
 	repo := repository.NewRepository(rootPath)
 
 	return &PrintDiff{
-		rootPath: rootPath,
-		repo:     repo,
-		stdout:   stdout,
-		stderr:   stderr,
+		rootPath:  rootPath,
+		repo:      repo,
+		algorithm: repo.DiffAlgorithm(),
+		stat:      StatOption{Stat: true},
+		stdout:    stdout,
+		stderr:    stderr,
 	}, nil
 }
 
+func (p *PrintDiff) DetectRenames(options database.RenameOption) {
+	p.renames = &options
+}
+
+func (p *PrintDiff) SetAlgorithm(algorithm diff.Algorithm) {
+	p.algorithm = algorithm
+}
+
+func (p *PrintDiff) SetStat(options StatOption) {
+	p.stat = options
+}
+
 func (p *PrintDiff) FromEntry(path string, entry database.TreeObject) *Target {
 	if entry == nil || entry.IsNil() {
 		return p.FromNothing(path)
@@ -92,17 +109,36 @@ func (p *PrintDiff) PrintCommitDiff(a, b string, differ Differ) {
 	if differ == nil {
 		differ = p.repo.Database
 	}
-	diff := differ.TreeDiff(a, b, nil)
-	paths := []string{}
-	for k := range diff {
-		paths = append(paths, k)
+	p.PrintChanges(differ.TreeDiff(a, b, nil))
+}
+
+func (p *PrintDiff) PrintChanges(changes map[string][2]database.TreeObject) {
+	changes, renames := p.detectRenames(changes)
+
+	for _, path := range sortedPaths(changes) {
+		oldEntry, newEntry := changes[path][0], changes[path][1]
+		if rename, ok := renames[path]; ok {
+			p.PrintRename(rename)
+		} else {
+			p.PrintDiff(p.FromEntry(path, oldEntry), p.FromEntry(path, newEntry))
+		}
 	}
-	sort.Strings(paths)
+}
+
+func (p *PrintDiff) detectRenames(changes map[string][2]database.TreeObject) (map[string][2]database.TreeObject, map[string]*database.Rename) {
+	if p.renames == nil {
+		return changes, map[string]*database.Rename{}
+	}
+	return p.repo.Database.DetectRenames(changes, *p.renames)
+}
 
-	for _, path := range paths {
-		oldEntry, newEntry := diff[path][0], diff[path][1]
-		p.PrintDiff(p.FromEntry(path, oldEntry), p.FromEntry(path, newEntry))
+func sortedPaths(changes map[string][2]database.TreeObject) []string {
+	paths := []string{}
+	for path := range changes {
+		paths = append(paths, path)
 	}
+	sort.Strings(paths)
+	return paths
 }
 
 func (p *PrintDiff) PrintDiff(a, b *Target) {
@@ -118,6 +154,26 @@ func (p *PrintDiff) PrintDiff(a, b *Target) {
 	p.printDiffContent(a, b)
 }
 
+func (p *PrintDiff) PrintRename(rename *database.Rename) {
+	a := p.FromEntry(rename.OldPath, rename.Old)
+	b := p.FromEntry(rename.NewPath, rename.New)
+
+	a.path = filepath.Join("a", a.path)
+	b.path = filepath.Join("b", b.path)
+
+	kind := "rename"
+	if rename.Copy {
+		kind = "copy"
+	}
+
+	fmt.Fprintf(p.stdout, "diff --git %s %s\n", a.path, b.path)
+	p.printDiffMode(a, b)
+	color.New(color.Bold).Fprintf(p.stdout, "similarity index %d%%\n", rename.Score)
+	color.New(color.Bold).Fprintf(p.stdout, "%s from %s\n", kind, rename.OldPath)
+	color.New(color.Bold).Fprintf(p.stdout, "%s to %s\n", kind, rename.NewPath)
+	p.printDiffContent(a, b)
+}
+
 func (p *PrintDiff) printDiffMode(a, b *Target) {
 	if a.mode == "" {
 		color.New(color.Bold).Fprintf(p.stdout, "new file mode %s\n", b.mode)
@@ -143,10 +199,15 @@ func (p *PrintDiff) printDiffContent(a, b *Target) {
 		oidRange += fmt.Sprintf(" %s", a.mode)
 	}
 	fmt.Fprintf(p.stdout, "%s\n", oidRange)
+
+	if isBinary(a.data) || isBinary(b.data) {
+		fmt.Fprintf(p.stdout, "Binary files %s and %s differ\n", a.diffPath(), b.diffPath())
+		return
+	}
 	fmt.Fprintf(p.stdout, "--- %s\n", a.diffPath())
 	fmt.Fprintf(p.stdout, "+++ %s\n", b.diffPath())
 
-	hunks := diff.DiffHunk(a.data, b.data)
+	hunks := diff.DiffHunkWith(p.algorithm, a.data, b.data)
 	for _, hunk := range hunks {
 		p.printDiffHunk(hunk)
 	}
@@ -184,7 +245,7 @@ func (p *PrintDiff) PrintCombinedDiff(as []*Target, b *Target) {
 	for i, a := range as {
 		datas[i] = fmt.Sprint(a.data)
 	}
-	hunks := diff.DiffCombinedHunks(datas, b.data)
+	hunks := diff.DiffCombinedHunksWith(p.algorithm, datas, b.data)
 	for _, hunk := range hunks {
 		p.printDiffHunk(hunk)
 	}
@@ -0,0 +1,459 @@
+package print_diff
+
+import (
+	"building-git/lib/database"
+	"building-git/lib/diff"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+const (
+	DEFAULT_STAT_WIDTH       = 80
+	DEFAULT_DIRSTAT_PERMILLE = 30
+	BINARY_CHECK_SIZE        = 8000
+	DIRSTAT_BINARY_CHUNK     = 64
+)
+
+// StatOption picks which summaries of a diff are printed. Width limits how
+// wide --stat lines may grow, and NameWidth and GraphWidth cap the file name
+// and histogram parts of them; zero leaves the choice to PrintStats.
+type StatOption struct {
+	Stat       bool
+	NumStat    bool
+	ShortStat  bool
+	DirStat    *DirStatOption
+	Width      int
+	NameWidth  int
+	GraphWidth int
+}
+
+func (o StatOption) Any() bool {
+	return o.Stat || o.NumStat || o.ShortStat || o.DirStat != nil
+}
+
+// DirStatOption controls --dirstat. Changes are weighed by lines added and
+// removed, or with Files by the number of files touched, and directories
+// with less than Permille thousandths of the total are not listed.
+type DirStatOption struct {
+	Files      bool
+	Cumulative bool
+	Permille   int
+}
+
+// ParseDirStat reads the comma separated parameters of --dirstat.
+func ParseDirStat(params string) (*DirStatOption, error) {
+	option := &DirStatOption{Permille: DEFAULT_DIRSTAT_PERMILLE}
+	if params == "" {
+		return option, nil
+	}
+
+	for _, param := range strings.Split(params, ",") {
+		switch param {
+		case "changes", "lines":
+			option.Files = false
+		case "files":
+			option.Files = true
+		case "cumulative":
+			option.Cumulative = true
+		case "noncumulative":
+			option.Cumulative = false
+		default:
+			percent, err := strconv.ParseFloat(param, 64)
+			if err != nil || percent < 0 {
+				return nil, fmt.Errorf("Failed to parse --dirstat/-X option parameter:\n  Unknown dirstat parameter '%s'", param)
+			}
+			option.Permille = int(percent * 10)
+		}
+	}
+	return option, nil
+}
+
+// FileStat counts the lines one file gained and lost. Binary files are
+// measured by their size in bytes before and after instead.
+type FileStat struct {
+	name       string
+	path       string
+	insertions int
+	deletions  int
+	binary     bool
+	unmerged   bool
+	changed    bool
+}
+
+func (p *PrintDiff) StatFor(a, b *Target) *FileStat {
+	stat := &FileStat{name: b.path, path: b.path, changed: a.oid != b.oid}
+
+	if isBinary(a.data) || isBinary(b.data) {
+		stat.binary = true
+		if a.oid != b.oid {
+			stat.insertions, stat.deletions = len(b.data), len(a.data)
+		}
+		return stat
+	}
+
+	for _, edit := range diff.DiffWith(p.algorithm, a.data, b.data) {
+		switch edit.Type() {
+		case diff.INS:
+			stat.insertions++
+		case diff.DEL:
+			stat.deletions++
+		}
+	}
+	return stat
+}
+
+func UnmergedStat(path string) *FileStat {
+	return &FileStat{name: path, path: path, unmerged: true, changed: true}
+}
+
+func isBinary(data string) bool {
+	if len(data) > BINARY_CHECK_SIZE {
+		data = data[:BINARY_CHECK_SIZE]
+	}
+	return strings.IndexByte(data, 0) >= 0
+}
+
+func (p *PrintDiff) PrintCommitStat(a, b string, differ Differ) {
+	if differ == nil {
+		differ = p.repo.Database
+	}
+	p.PrintStat(differ.TreeDiff(a, b, nil))
+}
+
+func (p *PrintDiff) PrintStat(changes map[string][2]database.TreeObject) {
+	changes, renames := p.detectRenames(changes)
+
+	stats := []*FileStat{}
+	for _, path := range sortedPaths(changes) {
+		var stat *FileStat
+		if rename, ok := renames[path]; ok {
+			stat = p.StatFor(p.FromEntry(rename.OldPath, rename.Old), p.FromEntry(rename.NewPath, rename.New))
+			stat.name = renameName(rename.OldPath, rename.NewPath)
+		} else {
+			stat = p.StatFor(p.FromEntry(path, changes[path][0]), p.FromEntry(path, changes[path][1]))
+		}
+		stats = append(stats, stat)
+	}
+	p.PrintStats(stats)
+}
+
+// PrintStats prints each of the summaries selected with SetStat, in the
+// order git does: --numstat, --stat, --shortstat and then --dirstat.
+func (p *PrintDiff) PrintStats(stats []*FileStat) {
+	if p.stat.NumStat {
+		p.printNumStat(stats)
+	}
+	if p.stat.Stat {
+		p.printStat(stats)
+	}
+	if p.stat.ShortStat {
+		p.printShortStat(stats)
+	}
+	if p.stat.DirStat != nil {
+		p.printDirStat(stats)
+	}
+}
+
+func (p *PrintDiff) printNumStat(stats []*FileStat) {
+	for _, stat := range stats {
+		if stat.binary {
+			fmt.Fprintf(p.stdout, "-\t-\t%s\n", stat.name)
+		} else {
+			fmt.Fprintf(p.stdout, "%d\t%d\t%s\n", stat.insertions, stat.deletions, stat.name)
+		}
+	}
+}
+
+// printStat draws a line for each file with a histogram of its changes. The
+// name and histogram share whatever the width leaves after the fixed parts
+// of the line; when they do not fit, the histogram is given up to 3/8 of the
+// width, long names lose their leading directories, and the bars are scaled
+// down to the largest change.
+func (p *PrintDiff) printStat(stats []*FileStat) {
+	if len(stats) == 0 {
+		return
+	}
+
+	maxLen, maxChange, binWidth, numberWidth := 0, 0, 0, 0
+	for _, stat := range stats {
+		if width := utf8.RuneCountInString(stat.name); width > maxLen {
+			maxLen = width
+		}
+		switch {
+		case stat.binary:
+			if width := 14 + decimalWidth(stat.insertions) + decimalWidth(stat.deletions); width > binWidth {
+				binWidth = width
+			}
+			numberWidth = 3
+		case stat.unmerged:
+			if binWidth < 8 {
+				binWidth = 8
+			}
+		default:
+			if change := stat.insertions + stat.deletions; change > maxChange {
+				maxChange = change
+			}
+		}
+	}
+	if width := decimalWidth(maxChange); width > numberWidth {
+		numberWidth = width
+	}
+
+	width := p.stat.Width
+	if width == 0 {
+		width = DEFAULT_STAT_WIDTH
+	}
+	if width < 16+6+numberWidth {
+		width = 16 + 6 + numberWidth
+	}
+
+	graphWidth := maxChange
+	if maxChange+4 <= binWidth {
+		graphWidth = binWidth - 4
+	}
+	if p.stat.GraphWidth > 0 && p.stat.GraphWidth < graphWidth {
+		graphWidth = p.stat.GraphWidth
+	}
+	nameWidth := maxLen
+	if p.stat.NameWidth > 0 && p.stat.NameWidth < maxLen {
+		nameWidth = p.stat.NameWidth
+	}
+
+	if nameWidth+numberWidth+6+graphWidth > width {
+		if graphWidth > width*3/8-numberWidth-6 {
+			graphWidth = width*3/8 - numberWidth - 6
+			if graphWidth < 6 {
+				graphWidth = 6
+			}
+		}
+		if p.stat.GraphWidth > 0 && graphWidth > p.stat.GraphWidth {
+			graphWidth = p.stat.GraphWidth
+		}
+		if nameWidth > width-numberWidth-6-graphWidth {
+			nameWidth = width - numberWidth - 6 - graphWidth
+		} else {
+			graphWidth = width - numberWidth - 6 - nameWidth
+		}
+	}
+
+	files, insertions, deletions := 0, 0, 0
+	for _, stat := range stats {
+		name := fitName(stat.name, nameWidth)
+
+		switch {
+		case stat.binary:
+			fmt.Fprintf(p.stdout, " %s | %*s", name, numberWidth, "Bin")
+			if stat.insertions != 0 || stat.deletions != 0 {
+				fmt.Fprintf(p.stdout, " %s -> %s bytes",
+					color.New(color.FgRed).Sprint(stat.deletions),
+					color.New(color.FgGreen).Sprint(stat.insertions))
+			}
+			fmt.Fprintln(p.stdout)
+		case stat.unmerged:
+			fmt.Fprintf(p.stdout, " %s | %*s\n", name, numberWidth, "Unmerged")
+			continue
+		default:
+			p.printStatGraph(stat, name, numberWidth, graphWidth, maxChange)
+			insertions += stat.insertions
+			deletions += stat.deletions
+		}
+		files++
+	}
+	fmt.Fprintln(p.stdout, statSummary(files, insertions, deletions))
+}
+
+func (p *PrintDiff) printStatGraph(stat *FileStat, name string, numberWidth, graphWidth, maxChange int) {
+	add, del := stat.insertions, stat.deletions
+	change := add + del
+
+	if graphWidth <= maxChange {
+		total := scaleLinear(change, graphWidth, maxChange)
+		if total < 2 && add > 0 && del > 0 {
+			total = 2
+		}
+		if add < del {
+			add = scaleLinear(add, graphWidth, maxChange)
+			del = total - add
+		} else {
+			del = scaleLinear(del, graphWidth, maxChange)
+			add = total - del
+		}
+	}
+
+	fmt.Fprintf(p.stdout, " %s | %*d", name, numberWidth, change)
+	if change > 0 {
+		fmt.Fprint(p.stdout, " ")
+	}
+	color.New(color.FgGreen).Fprint(p.stdout, strings.Repeat("+", add))
+	color.New(color.FgRed).Fprint(p.stdout, strings.Repeat("-", del))
+	fmt.Fprintln(p.stdout)
+}
+
+func scaleLinear(n, width, max int) int {
+	if n == 0 {
+		return 0
+	}
+	return 1 + n*(width-1)/max
+}
+
+func decimalWidth(n int) int {
+	return len(strconv.Itoa(n))
+}
+
+// fitName pads a name out to width, or shortens it to fit by replacing its
+// start with "..." and as much of its leading directories as it takes.
+func fitName(name string, width int) string {
+	length := utf8.RuneCountInString(name)
+	if length <= width {
+		return name + strings.Repeat(" ", width-length)
+	}
+
+	runes := []rune(name)
+	tail := string(runes[length-(width-3):])
+	if slash := strings.IndexByte(tail, '/'); slash >= 0 {
+		tail = tail[slash:]
+	}
+	name = "..." + tail
+	if padding := width - utf8.RuneCountInString(name); padding > 0 {
+		name += strings.Repeat(" ", padding)
+	}
+	return name
+}
+
+func (p *PrintDiff) printShortStat(stats []*FileStat) {
+	files, insertions, deletions := 0, 0, 0
+	for _, stat := range stats {
+		if stat.unmerged {
+			continue
+		}
+		files++
+		if !stat.binary {
+			insertions += stat.insertions
+			deletions += stat.deletions
+		}
+	}
+	if len(stats) > 0 {
+		fmt.Fprintln(p.stdout, statSummary(files, insertions, deletions))
+	}
+}
+
+type dirStatFile struct {
+	path    string
+	changed int
+}
+
+// printDirStat shows how the changes are spread across directories. Files
+// whose contents are unchanged, such as pure renames, do not count. Each
+// directory is listed with the share of the changes made inside it, unless
+// all of them were made in a single one of its subdirectories. Without
+// Cumulative, changes counted for a directory are not counted again for
+// the directories above it.
+func (p *PrintDiff) printDirStat(stats []*FileStat) {
+	option := p.stat.DirStat
+	files := []dirStatFile{}
+	total := 0
+
+	for _, stat := range stats {
+		damage := stat.insertions + stat.deletions
+		switch {
+		case !stat.changed:
+			damage = 0
+		case option.Files:
+			damage = 1
+		case stat.binary:
+			damage = (damage + DIRSTAT_BINARY_CHUNK - 1) / DIRSTAT_BINARY_CHUNK
+		}
+		if damage == 0 {
+			continue
+		}
+		files = append(files, dirStatFile{stat.path, damage})
+		total += damage
+	}
+	if total == 0 {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+
+	var gather func(base string) int
+	gather = func(base string) int {
+		changed, sources := 0, 0
+
+		for len(files) > 0 && strings.HasPrefix(files[0].path, base) {
+			file := files[0]
+			damage := file.changed
+			if slash := strings.IndexByte(file.path[len(base):], '/'); slash >= 0 {
+				damage = gather(file.path[:len(base)+slash+1])
+				sources++
+			} else {
+				files = files[1:]
+				sources += 2
+			}
+			changed += damage
+		}
+
+		if base != "" && sources != 1 && changed > 0 {
+			permille := changed * 1000 / total
+			if permille >= option.Permille {
+				fmt.Fprintf(p.stdout, "%4d.%01d%% %s\n", permille/10, permille%10, base)
+				if !option.Cumulative {
+					return 0
+				}
+			}
+		}
+		return changed
+	}
+	gather("")
+}
+
+// renameName abbreviates a rename as git's diffstat does, pulling the
+// directories both paths share out of braces: "lib/{a.go => b.go}".
+func renameName(oldPath, newPath string) string {
+	prefix := 0
+	for i := 0; i < len(oldPath) && i < len(newPath) && oldPath[i] == newPath[i]; i++ {
+		if oldPath[i] == '/' {
+			prefix = i + 1
+		}
+	}
+
+	suffix := 0
+	for i := 1; i <= len(oldPath)-prefix && i <= len(newPath)-prefix && oldPath[len(oldPath)-i] == newPath[len(newPath)-i]; i++ {
+		if oldPath[len(oldPath)-i] == '/' {
+			suffix = i
+		}
+	}
+
+	if prefix == 0 && suffix == 0 {
+		return fmt.Sprintf("%s => %s", oldPath, newPath)
+	}
+	return fmt.Sprintf("%s{%s => %s}%s",
+		oldPath[:prefix],
+		oldPath[prefix:len(oldPath)-suffix],
+		newPath[prefix:len(newPath)-suffix],
+		oldPath[len(oldPath)-suffix:],
+	)
+}
+
+func statSummary(files, insertions, deletions int) string {
+	summary := fmt.Sprintf(" %d %s changed", files, plural(files, "file", "files"))
+	if insertions > 0 || deletions == 0 {
+		summary += fmt.Sprintf(", %d %s(+)", insertions, plural(insertions, "insertion", "insertions"))
+	}
+	if deletions > 0 || insertions == 0 {
+		summary += fmt.Sprintf(", %d %s(-)", deletions, plural(deletions, "deletion", "deletions"))
+	}
+	return summary
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
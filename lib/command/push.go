@@ -0,0 +1,313 @@
+package command
+
+import (
+	"building-git/lib/repository"
+	"building-git/lib/repository/remotes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	PUSH_CAPABILITIES = []string{"report-status"}
+	STATUS_LINE       = regexp.MustCompile(`^(ok|ng) (\S+)(.*)$`)
+)
+
+const RECEIVE_PACK = "jit receive-pack"
+
+type PushOption struct {
+	Force    bool
+	Receiver string
+}
+
+type pushUpdate struct {
+	source  string
+	ffError string
+	oldOid  string
+	newOid  string
+}
+
+type pushError struct {
+	refNames []string
+	message  string
+}
+
+type Push struct {
+	rootPath   string
+	args       []string
+	options    PushOption
+	repo       *repository.Repository
+	client     *remoteClient
+	pushUrl    string
+	receiver   string
+	fetchSpecs []string
+	pushSpecs  []string
+	updates    map[string]*pushUpdate
+	errors     []*pushError
+	stdout     io.Writer
+	stderr     io.Writer
+}
+
+func NewPush(dir string, args []string, options PushOption, stdout, stderr io.Writer) (*Push, error) {
+	rootPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(rootPath)
+
+	return &Push{
+		rootPath: rootPath,
+		args:     args,
+		options:  options,
+		repo:     repo,
+		client:   newRemoteClient(repo, stderr),
+		updates:  map[string]*pushUpdate{},
+		errors:   []*pushError{},
+		stdout:   stdout,
+		stderr:   stderr,
+	}, nil
+}
+
+func (p *Push) Run() int {
+	if err := p.configure(); err != nil {
+		fmt.Fprintf(p.stderr, "fatal: %v\n", err)
+		return 128
+	}
+	if err := p.client.startAgent("push", p.receiver, p.pushUrl, PUSH_CAPABILITIES); err != nil {
+		fmt.Fprintf(p.stderr, "fatal: %v\n", err)
+		return 128
+	}
+
+	if err := p.push(); err != nil {
+		p.client.finishAgent()
+		fmt.Fprintf(p.stderr, "fatal: %v\n", err)
+		return 128
+	}
+	if err := p.client.finishAgent(); err != nil {
+		fmt.Fprintf(p.stderr, "fatal: %v\n", err)
+		return 128
+	}
+
+	if len(p.errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func (p *Push) configure() error {
+	currentBranch := ""
+	if ref, err := p.repo.Refs.CurrentRef(""); err == nil && !ref.IsHead() {
+		currentBranch, _ = ref.ShortName()
+	}
+	branchRemote, _ := p.repo.Config.Get([]string{"branch", currentBranch, "remote"})
+	branchMerge, _ := p.repo.Config.Get([]string{"branch", currentBranch, "merge"})
+
+	name := repository.DEFAULT_REMOTE
+	if len(p.args) > 0 {
+		name = p.args[0]
+	} else if remoteName, ok := branchRemote.(string); ok {
+		name = remoteName
+	}
+	remote := p.repo.Remotes().Get(name)
+
+	if remote != nil {
+		p.pushUrl, _ = remote.PushUrl()
+		p.fetchSpecs, _ = remote.FetchSpecs()
+		p.receiver, _ = remote.Receiver()
+	}
+	if p.pushUrl == "" && len(p.args) > 0 {
+		p.pushUrl = p.args[0]
+	}
+	if p.pushUrl == "" {
+		return fmt.Errorf("'%s' does not appear to be a git repository", name)
+	}
+
+	if p.options.Receiver != "" {
+		p.receiver = p.options.Receiver
+	}
+	if p.receiver == "" {
+		p.receiver = RECEIVE_PACK
+	}
+
+	if len(p.args) > 1 {
+		p.pushSpecs = p.args[1:]
+	} else if merge, ok := branchMerge.(string); ok && currentBranch != "" {
+		spec := remotes.NewRefSpec(currentBranch, merge, false)
+		p.pushSpecs = []string{spec.String()}
+	} else if remote != nil {
+		p.pushSpecs, _ = remote.PushSpecs()
+	}
+	return nil
+}
+
+func (p *Push) push() error {
+	if err := p.client.recvReferences(); err != nil {
+		return err
+	}
+	if err := p.sendUpdateRequests(); err != nil {
+		return err
+	}
+	if err := p.sendObjects(); err != nil {
+		return err
+	}
+	p.printSummary()
+	return p.recvReportStatus()
+}
+
+func (p *Push) sendUpdateRequests() error {
+	localRefs := []string{}
+	for _, ref := range p.repo.Refs.ListAllRefs() {
+		localRefs = append(localRefs, ref.Path)
+	}
+	sort.Strings(localRefs)
+
+	targets := remotes.ExpandRefspecs(p.pushSpecs, localRefs)
+	for _, target := range sortedKeys(targets) {
+		source := targets[target][0].(string)
+		forced := targets[target][1].(bool)
+		if err := p.selectUpdate(target, source, forced); err != nil {
+			return err
+		}
+	}
+
+	for _, ref := range p.updateRefs() {
+		update := p.updates[ref]
+		line := fmt.Sprintf("%s %s %s", repository.NilToZero(update.oldOid), repository.NilToZero(update.newOid), ref)
+		if err := p.client.conn.SendPacket(line); err != nil {
+			return err
+		}
+	}
+	return p.client.conn.SendFlush()
+}
+
+func (p *Push) selectUpdate(target, source string, forced bool) error {
+	if source == "" {
+		p.selectDeletion(target)
+		return nil
+	}
+
+	oldOid := p.client.remoteRefs[target]
+	newOid, err := repository.NewRevision(p.repo, source).Resolve("")
+	if err != nil {
+		return err
+	}
+	if oldOid == newOid {
+		return nil
+	}
+
+	ffError := fastForwardError(p.repo, oldOid, newOid)
+	if p.options.Force || forced || ffError == "" {
+		p.updates[target] = &pushUpdate{source, ffError, oldOid, newOid}
+	} else {
+		p.errors = append(p.errors, &pushError{[]string{source, target}, ffError})
+	}
+	return nil
+}
+
+func (p *Push) selectDeletion(target string) {
+	if p.client.conn.IsCapable("delete-refs") {
+		p.updates[target] = &pushUpdate{oldOid: p.client.remoteRefs[target]}
+	} else {
+		p.errors = append(p.errors, &pushError{[]string{"", target}, "remote does not support deleting refs"})
+	}
+}
+
+func (p *Push) sendObjects() error {
+	revs := []string{}
+	for _, ref := range p.updateRefs() {
+		if oid := p.updates[ref].newOid; oid != "" {
+			revs = append(revs, oid)
+		}
+	}
+	if len(revs) == 0 {
+		return nil
+	}
+
+	for _, oid := range p.client.remoteRefs {
+		revs = append(revs, "^"+oid)
+	}
+	return sendPackedObjects(p.repo, p.client.conn, revs)
+}
+
+func (p *Push) printSummary() {
+	if len(p.updates) == 0 && len(p.errors) == 0 {
+		fmt.Fprintln(p.stderr, "Everything up-to-date")
+		return
+	}
+
+	fmt.Fprintf(p.stderr, "To %s\n", p.pushUrl)
+	for _, e := range p.errors {
+		p.client.reportRefUpdate(e.refNames, e.message, "", "", false)
+	}
+}
+
+func (p *Push) recvReportStatus() error {
+	if !p.client.conn.IsCapable("report-status") || len(p.updates) == 0 {
+		return nil
+	}
+
+	line, _, err := p.client.conn.RecvPacket()
+	if err != nil {
+		return err
+	}
+	if result := strings.TrimPrefix(line, "unpack "); result != "ok" {
+		fmt.Fprintf(p.stderr, "error: remote unpack failed: %s\n", result)
+	}
+
+	return p.client.conn.RecvUntil("", func(line string) error {
+		return p.handleStatus(line)
+	})
+}
+
+func (p *Push) handleStatus(line string) error {
+	match := STATUS_LINE.FindStringSubmatch(line)
+	if match == nil {
+		return nil
+	}
+
+	ref := match[2]
+	message := ""
+	if match[1] != "ok" {
+		message = strings.TrimSpace(match[3])
+		p.errors = append(p.errors, &pushError{[]string{ref}, message})
+	}
+
+	update, ok := p.updates[ref]
+	if !ok {
+		return nil
+	}
+	p.client.reportRefUpdate([]string{update.source, ref}, message, update.oldOid, update.newOid, update.ffError == "")
+	if message != "" {
+		return nil
+	}
+
+	targets := remotes.ExpandRefspecs(p.fetchSpecs, []string{ref})
+	for _, localRef := range sortedKeys(targets) {
+		remoteRef := targets[localRef][0].(string)
+		if err := p.repo.Refs.UpdateRef(localRef, p.updates[remoteRef].newOid, "update by push"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Push) updateRefs() []string {
+	refs := []string{}
+	for ref := range p.updates {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+func sortedKeys(mappings map[string][]interface{}) []string {
+	keys := []string{}
+	for key := range mappings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,358 @@
+package command
+
+import (
+	"building-git/lib/command/write_commit"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPush(t *testing.T) {
+	receiver := os.Args[0] + " receive-pack"
+
+	setup := func() (remoteDir, tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+		now := time.Now()
+		for i, message := range []string{"one", "two", "three"} {
+			commitTree(t, tmpDir, message, map[string]string{
+				fmt.Sprintf("%s.txt", message): message,
+				"dir/notes.txt":                fmt.Sprintf("notes for %s\n", message),
+			}, now.Add(time.Duration(i)*time.Second))
+		}
+
+		remoteDir, _, _ = setupTestEnvironment(t)
+		config, _ := NewConfig(remoteDir, []string{"receive.denyCurrentBranch", "false"}, ConfigOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		config.Run()
+
+		remote, _ := NewRemote(tmpDir, []string{"add", "origin", remoteDir}, RemoteOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		remote.Run()
+		return
+	}
+
+	push := func(tmpDir string, args []string, options PushOption, stdout, stderr *bytes.Buffer) int {
+		options.Receiver = receiver
+		cmd, _ := NewPush(tmpDir, args, options, stdout, stderr)
+		return cmd.Run()
+	}
+
+	t.Run("displays the new branch being pushed", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		status := push(tmpDir, []string{"origin", "master"}, PushOption{}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected := fmt.Sprintf("To %s\n * [new branch] master -> master\n", remoteDir)
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("updates the remote's ref and the local remote ref", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		push(tmpDir, []string{"origin", "master"}, PushOption{}, stdout, stderr)
+
+		expected, _ := repo(t, tmpDir).Refs.ReadRef("refs/heads/master")
+		if got, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master"); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		if got, _ := repo(t, tmpDir).Refs.ReadRef("refs/remotes/origin/master"); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("sends every object to the remote", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		push(tmpDir, []string{"origin", "master"}, PushOption{}, stdout, stderr)
+
+		localObjects, _ := filepath.Glob(filepath.Join(tmpDir, ".git", "objects", "??", "*"))
+		remote := repo(t, remoteDir)
+		for _, path := range localObjects {
+			oid := filepath.Base(filepath.Dir(path)) + filepath.Base(path)
+			if !remote.Database.Has(oid) {
+				t.Errorf("want object %s to be pushed", oid)
+			}
+		}
+	})
+
+	t.Run("pushes into the ref named by a refspec", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		status := push(tmpDir, []string{"origin", "master:topic"}, PushOption{}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected, _ := repo(t, tmpDir).Refs.ReadRef("refs/heads/master")
+		if got, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/topic"); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("prints a message when already up to date", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		push(tmpDir, []string{"origin", "master"}, PushOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		status := push(tmpDir, []string{"origin", "master"}, PushOption{}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		if got := stderr.String(); got != "Everything up-to-date\n" {
+			t.Errorf("want %q, but got %q", "Everything up-to-date\n", got)
+		}
+	})
+
+	t.Run("fast-forwards a remote branch", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		push(tmpDir, []string{"origin", "master"}, PushOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		oldOid, _ := repo(t, tmpDir).Refs.ReadRef("refs/heads/master")
+		commitTree(t, tmpDir, "four", map[string]string{"four.txt": "four"}, time.Now().Add(time.Minute))
+		newOid, _ := repo(t, tmpDir).Refs.ReadRef("refs/heads/master")
+
+		push(tmpDir, []string{"origin", "master"}, PushOption{}, stdout, stderr)
+
+		expected := fmt.Sprintf("To %s\n   %s..%s master -> master\n", remoteDir, oldOid[:7], newOid[:7])
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		if got, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master"); got != newOid {
+			t.Errorf("want %q, but got %q", newOid, got)
+		}
+	})
+
+	t.Run("rejects a non-fast-forward update", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		push(tmpDir, []string{"origin", "master"}, PushOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		oldOid, _ := rewriteHistory(t, tmpDir)
+
+		status := push(tmpDir, []string{"origin", "master"}, PushOption{}, stdout, stderr)
+
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		expected := fmt.Sprintf("To %s\n ! [rejected] master -> master (non-fast-forward)\n", remoteDir)
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		if got, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master"); got != oldOid {
+			t.Errorf("want %q, but got %q", oldOid, got)
+		}
+	})
+
+	t.Run("forces a non-fast-forward update with --force", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		push(tmpDir, []string{"origin", "master"}, PushOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		oldOid, newOid := rewriteHistory(t, tmpDir)
+
+		status := push(tmpDir, []string{"origin", "master"}, PushOption{Force: true}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected := fmt.Sprintf("To %s\n + %s...%s master -> master (forced update)\n", remoteDir, oldOid[:7], newOid[:7])
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		if got, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master"); got != newOid {
+			t.Errorf("want %q, but got %q", newOid, got)
+		}
+	})
+
+	t.Run("forces a non-fast-forward update with a forced refspec", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		push(tmpDir, []string{"origin", "master"}, PushOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		_, newOid := rewriteHistory(t, tmpDir)
+
+		status := push(tmpDir, []string{"origin", "+master"}, PushOption{}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		if got, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master"); got != newOid {
+			t.Errorf("want %q, but got %q", newOid, got)
+		}
+	})
+
+	t.Run("lets the remote deny non-fast-forward updates", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		config, _ := NewConfig(remoteDir, []string{"receive.denyNonFastForwards", "true"}, ConfigOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		config.Run()
+		push(tmpDir, []string{"origin", "master"}, PushOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		oldOid, _ := rewriteHistory(t, tmpDir)
+
+		status := push(tmpDir, []string{"origin", "master"}, PushOption{Force: true}, stdout, stderr)
+
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		expected := fmt.Sprintf("To %s\n ! [rejected] master -> master (non-fast-forward)\n", remoteDir)
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		if got, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master"); got != oldOid {
+			t.Errorf("want %q, but got %q", oldOid, got)
+		}
+	})
+
+	t.Run("deletes a remote branch", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		push(tmpDir, []string{"origin", "master:topic"}, PushOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		status := push(tmpDir, []string{"origin", ":topic"}, PushOption{}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected := fmt.Sprintf("To %s\n - [deleted] topic\n", remoteDir)
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		if got, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/topic"); got != "" {
+			t.Errorf("want %q, but got %q", "", got)
+		}
+		if got, _ := repo(t, tmpDir).Refs.ReadRef("refs/remotes/origin/topic"); got != "" {
+			t.Errorf("want %q, but got %q", "", got)
+		}
+	})
+
+	t.Run("refuses to update the checked-out branch of a non-bare repository", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		config, _ := NewConfig(remoteDir, []string{}, ConfigOption{Unset: "receive.denyCurrentBranch"}, new(bytes.Buffer), new(bytes.Buffer))
+		config.Run()
+
+		status := push(tmpDir, []string{"origin", "master"}, PushOption{}, stdout, stderr)
+
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		expected := fmt.Sprintf("To %s\n ! [rejected] master -> master (branch is currently checked out)\n", remoteDir)
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		if got, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/master"); got != "" {
+			t.Errorf("want %q, but got %q", "", got)
+		}
+	})
+
+	t.Run("updates the current branch of a bare repository", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		config, _ := NewConfig(remoteDir, []string{}, ConfigOption{Unset: "receive.denyCurrentBranch"}, new(bytes.Buffer), new(bytes.Buffer))
+		config.Run()
+		bareDir := filepath.Join(remoteDir, "bare.git")
+		if err := os.Rename(filepath.Join(remoteDir, ".git"), bareDir); err != nil {
+			t.Fatal(err)
+		}
+		config, _ = NewConfig(tmpDir, []string{"remote.origin.url", bareDir}, ConfigOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		config.Run()
+
+		status := push(tmpDir, []string{"origin", "master"}, PushOption{}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected := fmt.Sprintf("To %s\n * [new branch] master -> master\n", bareDir)
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("pushes an annotated tag with its tag object", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		os.Setenv("GIT_AUTHOR_NAME", "A. U. Thor")
+		os.Setenv("GIT_AUTHOR_EMAIL", "author@example.com")
+		tag, _ := NewTag(tmpDir, []string{"v1.0"}, TagOption{ReadOption: write_commit.ReadOption{Message: "one"}}, new(bytes.Buffer), new(bytes.Buffer))
+		tag.Run(time.Now())
+		os.Unsetenv("GIT_AUTHOR_NAME")
+		os.Unsetenv("GIT_AUTHOR_EMAIL")
+
+		status := push(tmpDir, []string{"origin", "refs/tags/v1.0"}, PushOption{}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected := fmt.Sprintf("To %s\n * [new tag] v1.0 -> v1.0\n", remoteDir)
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		oid, _ := repo(t, tmpDir).Refs.ReadRef("refs/tags/v1.0")
+		if got, _ := repo(t, remoteDir).Refs.ReadRef("refs/tags/v1.0"); got != oid {
+			t.Errorf("want %q, but got %q", oid, got)
+		}
+		if !repo(t, remoteDir).Database.Has(oid) {
+			t.Errorf("want tag object %s to be pushed", oid)
+		}
+	})
+
+	t.Run("pushes a tag named by its short name", func(t *testing.T) {
+		remoteDir, tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(remoteDir)
+		defer os.RemoveAll(tmpDir)
+
+		os.Setenv("GIT_AUTHOR_NAME", "A. U. Thor")
+		os.Setenv("GIT_AUTHOR_EMAIL", "author@example.com")
+		tag, _ := NewTag(tmpDir, []string{"v1.0"}, TagOption{ReadOption: write_commit.ReadOption{Message: "one"}}, new(bytes.Buffer), new(bytes.Buffer))
+		tag.Run(time.Now())
+		os.Unsetenv("GIT_AUTHOR_NAME")
+		os.Unsetenv("GIT_AUTHOR_EMAIL")
+
+		status := push(tmpDir, []string{"origin", "v1.0"}, PushOption{}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected := fmt.Sprintf("To %s\n * [new tag] v1.0 -> v1.0\n", remoteDir)
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		oid, _ := repo(t, tmpDir).Refs.ReadRef("refs/tags/v1.0")
+		if got, _ := repo(t, remoteDir).Refs.ReadRef("refs/tags/v1.0"); got != oid {
+			t.Errorf("want %q, but got %q", oid, got)
+		}
+		if got, _ := repo(t, remoteDir).Refs.ReadRef("refs/heads/v1.0"); got != "" {
+			t.Errorf("want no branch named v1.0, but got %q", got)
+		}
+	})
+}
@@ -0,0 +1,327 @@
+package command
+
+import (
+	"building-git/lib/database"
+	"building-git/lib/editor"
+	"building-git/lib/merge"
+	"building-git/lib/repository"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	REBASE_HEAD_NAME = "head-name"
+	REBASE_ONTO      = "onto"
+	REBASE_ORIG_HEAD = "head"
+	REBASE_SAFETY    = "abort-safety"
+)
+
+const REBASE_TODO_NOTES = `
+Commands:
+p, pick <commit> = use commit
+r, reword <commit> = use commit, but edit the commit message
+e, edit <commit> = use commit, but stop for amending
+s, squash <commit> = use commit, but meld into previous commit
+f, fixup <commit> = like "squash", but discard this commit's log message
+x, exec <command> = run command (the rest of the line) using shell
+b, break = stop here (continue rebase later with 'jit rebase --continue')
+d, drop <commit> = remove commit
+
+These lines can be re-ordered; they are executed from top to bottom.
+
+If you remove a line here THAT COMMIT WILL BE LOST.
+
+However, if you remove everything, the rebase will be aborted.`
+
+type RebaseOption struct {
+	SequencingOption
+	Onto        string
+	Interactive bool
+	Autosquash  bool
+}
+
+type Rebase struct {
+	*sequencing
+	args    []string
+	options RebaseOption
+}
+
+func NewRebase(dir string, args []string, options RebaseOption, stdout, stderr io.Writer) (*Rebase, error) {
+	rootPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(rootPath)
+	sequencing := newSequencing(rootPath, repo, options.SequencingOption, stdout, stderr)
+	sequencing.pickAction = "rebase (pick)"
+	sequencing.dropEmpty = true
+
+	return &Rebase{
+		sequencing: sequencing,
+		args:       args,
+		options:    options,
+	}, nil
+}
+
+func (r *Rebase) Run() int {
+	switch r.options.Mode {
+	case Continue, Skip, Abort, Quit:
+		return r.resumeRebase()
+	}
+
+	if len(r.args) != 1 {
+		fmt.Fprintln(r.stderr, "fatal: usage: jit rebase [-i] [--onto <newbase>] <upstream>")
+		return 128
+	}
+	if r.sequencer.IsInProgress() {
+		fmt.Fprintln(r.stderr, "fatal: It seems that there is already a rebase or cherry-pick in progress.")
+		return 128
+	}
+
+	upstream, err := repository.NewRevision(r.repo, r.args[0]).Resolve(repository.COMMIT)
+	if err != nil {
+		fmt.Fprintf(r.stderr, "fatal: invalid upstream '%s'\n", r.args[0])
+		return 128
+	}
+	onto := upstream
+	if r.options.Onto != "" {
+		if onto, err = repository.NewRevision(r.repo, r.options.Onto).Resolve(repository.COMMIT); err != nil {
+			fmt.Fprintf(r.stderr, "fatal: Does not point to a valid commit: '%s'\n", r.options.Onto)
+			return 128
+		}
+	}
+
+	if err := r.checkCleanWorkspace(); err != nil {
+		fmt.Fprintf(r.stderr, "error: %v\n", err)
+		return 1
+	}
+
+	headOid, _ := r.repo.Refs.ReadHead()
+	headName := repository.HEAD
+	if ref, err := r.repo.Refs.CurrentRef(""); err == nil {
+		headName = ref.Path
+	}
+
+	commits := r.commitsToReplay(upstream)
+	commands := r.todoList(commits)
+	if !r.options.Interactive && isPlainPicks(commands) && r.isUpToDate(headOid, upstream, onto) {
+		shortName, _ := r.repo.Refs.ShortName(headName)
+		fmt.Fprintf(r.stdout, "Current branch %s is up to date.\n", shortName)
+		return 0
+	}
+
+	code := r.run(func() error {
+		if r.options.Interactive {
+			if commands, err = r.editTodoList(commands, upstream, headOid, onto); err != nil {
+				return err
+			}
+		}
+		r.sequencer.WriteState(REBASE_HEAD_NAME, headName)
+		r.sequencer.WriteState(REBASE_ONTO, onto)
+		r.detachHead(onto, r.args[0])
+		r.sequencer.WriteState(REBASE_SAFETY, onto)
+		for _, command := range commands {
+			r.sequencer.Push(command)
+		}
+		return nil
+	})
+	if code != 0 {
+		return code
+	}
+	return r.finishRebase(headName, headOid)
+}
+
+func (r *Rebase) resumeRebase() int {
+	headName := r.sequencer.ReadState(REBASE_HEAD_NAME)
+	origHead := r.sequencer.ReadState(REBASE_ORIG_HEAD)
+	if headName == "" {
+		fmt.Fprintln(r.stderr, "fatal: No rebase in progress?")
+		return 128
+	}
+
+	code := r.run(nil)
+	switch {
+	case r.options.Mode == Abort:
+		r.reattachHead(headName)
+	case code == 0 && r.options.Mode != Quit:
+		return r.finishRebase(headName, origHead)
+	}
+	return code
+}
+
+func (r *Rebase) commitsToReplay(upstream string) []*database.Commit {
+	revList, _ := repository.NewRevList(r.repo, []string{upstream + ".." + repository.HEAD}, repository.RevListOption{})
+
+	commits := []*database.Commit{}
+	for _, object := range revList.ReverseEach() {
+		commit := object.(*database.Commit)
+		if !commit.IsMerge() {
+			commits = append(commits, commit)
+		}
+	}
+	return commits
+}
+
+func (r *Rebase) todoList(commits []*database.Commit) []*repository.SequencerCommand {
+	commands := []*repository.SequencerCommand{}
+	for _, commit := range commits {
+		commands = append(commands, &repository.SequencerCommand{Action: repository.PICK, Commit: commit})
+	}
+	if !r.options.Autosquash {
+		return commands
+	}
+
+	fixups := map[*repository.SequencerCommand][]*repository.SequencerCommand{}
+	moved := map[*repository.SequencerCommand]bool{}
+	for i, command := range commands {
+		action, subject := autosquashSubject(command.Commit.TitleLine())
+		if action == "" {
+			continue
+		}
+		for _, target := range commands[:i] {
+			if moved[target] || !isAutosquashTarget(target.Commit, subject) {
+				continue
+			}
+			command.Action = action
+			fixups[target] = append(fixups[target], command)
+			moved[command] = true
+			break
+		}
+	}
+
+	sorted := []*repository.SequencerCommand{}
+	for _, command := range commands {
+		if moved[command] {
+			continue
+		}
+		sorted = append(sorted, command)
+		sorted = append(sorted, fixups[command]...)
+	}
+	return sorted
+}
+
+func isPlainPicks(commands []*repository.SequencerCommand) bool {
+	for _, command := range commands {
+		if command.Action != repository.PICK {
+			return false
+		}
+	}
+	return true
+}
+
+func autosquashSubject(title string) (string, string) {
+	for _, action := range []string{repository.FIXUP, repository.SQUASH} {
+		if prefix := action + "! "; strings.HasPrefix(title, prefix) {
+			return action, strings.TrimPrefix(title, prefix)
+		}
+	}
+	return "", ""
+}
+
+func isAutosquashTarget(commit *database.Commit, subject string) bool {
+	if commit.TitleLine() == subject {
+		return true
+	}
+	return len(subject) >= 4 && strings.HasPrefix(commit.Oid(), subject)
+}
+
+func (r *Rebase) editTodoList(commands []*repository.SequencerCommand, upstream, headOid, onto string) ([]*repository.SequencerCommand, error) {
+	path := r.sequencer.EditPath()
+	text := editor.EditFile(path, r.options.EditorCmd(path), r.options.IsTTY, func(e *editor.Editor) {
+		for _, command := range commands {
+			e.Puts(r.sequencer.FormatCommand(command))
+		}
+		e.Puts("")
+
+		short := r.repo.Database.ShortOid
+		e.Note(fmt.Sprintf("Rebase %s..%s onto %s (%d commands)", short(upstream), short(headOid), short(onto), len(commands)))
+		e.Note(REBASE_TODO_NOTES)
+	})
+
+	edited := []*repository.SequencerCommand{}
+	hasCommit := false
+	for i, line := range strings.Split(text, "\n") {
+		command, err := r.sequencer.ParseCommand(line)
+		if err != nil {
+			return nil, fmt.Errorf("%v\nerror: invalid line %d: %s", err, i+1, line)
+		}
+		if command == nil || command.Action == repository.DROP {
+			continue
+		}
+		if (command.Action == repository.SQUASH || command.Action == repository.FIXUP) && !hasCommit {
+			return nil, fmt.Errorf("cannot '%s' without a previous commit", command.Action)
+		}
+		hasCommit = hasCommit || command.IsPick()
+		edited = append(edited, command)
+	}
+
+	if len(edited) == 0 {
+		return nil, fmt.Errorf("nothing to do")
+	}
+	return edited, nil
+}
+
+// isUpToDate reports whether HEAD already descends from onto and forks from
+// upstream there, in which case replaying its commits would change nothing.
+func (r *Rebase) isUpToDate(headOid, upstream, onto string) bool {
+	return r.mergeBase(headOid, onto) == onto && r.mergeBase(headOid, upstream) == onto
+}
+
+func (r *Rebase) mergeBase(one, two string) string {
+	if one == two {
+		return one
+	}
+	bases := merge.NewBases(r.repo.Database, one, two).Find()
+	if len(bases) != 1 {
+		return ""
+	}
+	return bases[0]
+}
+
+func (r *Rebase) checkCleanWorkspace() error {
+	r.repo.Index.Load()
+	status, err := r.repo.Status("")
+	if err != nil {
+		return err
+	}
+	if status.IndexChanges.Len() > 0 || status.WorkspaceChanges.Len() > 0 {
+		return fmt.Errorf("cannot rebase: You have unstaged changes.\nerror: Please commit or stash them.")
+	}
+	return nil
+}
+
+func (r *Rebase) detachHead(onto, upstreamName string) {
+	r.repo.Index.LoadForUpdate()
+	r.repo.HardReset(onto)
+	r.repo.Index.WriteUpdates()
+	r.repo.Refs.SetHead(onto, onto, "rebase (start): checkout "+upstreamName)
+}
+
+func (r *Rebase) finishRebase(headName, origHead string) int {
+	if r.sequencer.IsInProgress() {
+		return 0
+	}
+
+	headOid, _ := r.repo.Refs.ReadHead()
+	onto := r.repo.Database.ShortOid(headOid)
+	if headName != repository.HEAD {
+		message := fmt.Sprintf("rebase (finish): %s onto %s", headName, onto)
+		if err := r.repo.Refs.UpdateRef(headName, headOid, message); err != nil {
+			fmt.Fprintf(r.stderr, "fatal: %v\n", err)
+			return 128
+		}
+		r.reattachHead(headName)
+	}
+	r.repo.Refs.UpateRef(repository.ORIG_HEAD, origHead)
+
+	fmt.Fprintf(r.stdout, "Successfully rebased and updated %s.\n", headName)
+	return 0
+}
+
+func (r *Rebase) reattachHead(headName string) {
+	if headName != repository.HEAD {
+		r.repo.Refs.UpdateSymbolicRef(repository.HEAD, headName)
+	}
+}
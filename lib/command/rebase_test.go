@@ -0,0 +1,593 @@
+package command
+
+import (
+	"building-git/lib/command/write_commit"
+	"building-git/lib/database"
+	"building-git/lib/editor"
+	"building-git/lib/repository"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func setUpForTestRebase(t *testing.T, upstreamFile string) (tmpDir string, stdout, stderr *bytes.Buffer) {
+	tmpDir, stdout, stderr = setupTestEnvironment(t)
+
+	for _, message := range []string{"one", "two"} {
+		commitTree(t, tmpDir, message, map[string]string{
+			"f.txt": message,
+		}, getTime())
+	}
+	branchCmd, _ := NewBranch(tmpDir, []string{"topic"}, BranchOption{}, new(bytes.Buffer), new(bytes.Buffer))
+	branchCmd.Run()
+
+	commitTree(t, tmpDir, "five", map[string]string{
+		upstreamFile: "five",
+	}, getTime())
+
+	checkout(tmpDir, new(bytes.Buffer), new(bytes.Buffer), "topic")
+	commitTree(t, tmpDir, "three", map[string]string{
+		"g.txt": "three",
+	}, getTime())
+	commitTree(t, tmpDir, "four", map[string]string{
+		"h.txt": "four",
+	}, getTime())
+
+	return
+}
+
+func assertHeadRef(t *testing.T, tmpDir, expected string) {
+	t.Helper()
+
+	ref, _ := repo(t, tmpDir).Refs.CurrentRef("")
+	if ref.Path != expected {
+		t.Errorf("want %q, but got %q", expected, ref.Path)
+	}
+}
+
+func TestRebaseOntoUpstream(t *testing.T) {
+	t.Run("replays the branch onto the upstream", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestRebase(t, "i.txt")
+		defer os.RemoveAll(tmpDir)
+
+		origHead := mustResolve(t, tmpDir, "topic")
+		status := rebase(t, tmpDir, stdout, stderr, []string{"master"}, RebaseOption{})
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+
+		expected := []string{"four", "three", "five", "two"}
+		if got := titleLines(t, tmpDir, "@", "@^", "@~2", "@~3"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("want %v, but got %v", expected, got)
+		}
+		if got := mustResolve(t, tmpDir, "topic"); got != mustResolve(t, tmpDir, "@") {
+			t.Errorf("want topic to be at HEAD, but got %s", got)
+		}
+		if got := mustResolve(t, tmpDir, repository.ORIG_HEAD); got != origHead {
+			t.Errorf("want %q, but got %q", origHead, got)
+		}
+		assertHeadRef(t, tmpDir, "refs/heads/topic")
+		assertWorkspace(t, tmpDir, map[string]string{
+			"f.txt": "two",
+			"g.txt": "three",
+			"h.txt": "four",
+			"i.txt": "five",
+		})
+		if !strings.HasSuffix(stdout.String(), "Successfully rebased and updated refs/heads/topic.\n") {
+			t.Errorf("want a success message, but got %q", stdout.String())
+		}
+	})
+
+	t.Run("does nothing when the branch is up to date", func(t *testing.T) {
+		tmpDir, _, _ := setUpForTestRebase(t, "i.txt")
+		defer os.RemoveAll(tmpDir)
+
+		rebase(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), []string{"master"}, RebaseOption{})
+		head := mustResolve(t, tmpDir, "@")
+
+		stdout := new(bytes.Buffer)
+		status := rebase(t, tmpDir, stdout, new(bytes.Buffer), []string{"master"}, RebaseOption{})
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		if expected := "Current branch topic is up to date.\n"; stdout.String() != expected {
+			t.Errorf("want %q, but got %q", expected, stdout.String())
+		}
+		if got := mustResolve(t, tmpDir, "@"); got != head {
+			t.Errorf("want %q, but got %q", head, got)
+		}
+	})
+
+	t.Run("does nothing when the branch has merged the upstream", func(t *testing.T) {
+		tmpDir, _, _ := setUpForTestRebase(t, "i.txt")
+		defer os.RemoveAll(tmpDir)
+
+		mergeCommit(t, tmpDir, "master", MergeOption{ReadOption: write_commit.ReadOption{Message: "merge master"}}, new(bytes.Buffer), new(bytes.Buffer))
+		head := mustResolve(t, tmpDir, "@")
+
+		stdout := new(bytes.Buffer)
+		status := rebase(t, tmpDir, stdout, new(bytes.Buffer), []string{"master"}, RebaseOption{})
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		if expected := "Current branch topic is up to date.\n"; stdout.String() != expected {
+			t.Errorf("want %q, but got %q", expected, stdout.String())
+		}
+		if got := mustResolve(t, tmpDir, "@"); got != head {
+			t.Errorf("want %q, but got %q", head, got)
+		}
+	})
+
+	t.Run("replays only the commits after upstream with --onto", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestRebase(t, "i.txt")
+		defer os.RemoveAll(tmpDir)
+
+		status := rebase(t, tmpDir, stdout, stderr, []string{"topic^"}, RebaseOption{Onto: "master"})
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+
+		expected := []string{"four", "five", "two"}
+		if got := titleLines(t, tmpDir, "@", "@^", "@~2"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("want %v, but got %v", expected, got)
+		}
+		assertWorkspace(t, tmpDir, map[string]string{
+			"f.txt": "two",
+			"h.txt": "four",
+			"i.txt": "five",
+		})
+	})
+
+	t.Run("drops commits that are already upstream", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestRebase(t, "i.txt")
+		defer os.RemoveAll(tmpDir)
+
+		checkout(tmpDir, new(bytes.Buffer), new(bytes.Buffer), "master")
+		commitTree(t, tmpDir, "four upstream", map[string]string{
+			"h.txt": "four",
+		}, getTime())
+		checkout(tmpDir, new(bytes.Buffer), new(bytes.Buffer), "topic")
+
+		status := rebase(t, tmpDir, stdout, stderr, []string{"master"}, RebaseOption{})
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+
+		expected := []string{"three", "four upstream", "five", "two"}
+		if got := titleLines(t, tmpDir, "@", "@^", "@~2", "@~3"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("want %v, but got %v", expected, got)
+		}
+	})
+
+	t.Run("refuses to rebase with local changes", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestRebase(t, "i.txt")
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "f.txt", "dirty")
+		status := rebase(t, tmpDir, stdout, stderr, []string{"master"}, RebaseOption{})
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		expected := "error: cannot rebase: You have unstaged changes.\nerror: Please commit or stash them.\n"
+		if stderr.String() != expected {
+			t.Errorf("want %q, but got %q", expected, stderr.String())
+		}
+	})
+}
+
+func TestRebaseWithConflict(t *testing.T) {
+	before := func(t *testing.T) (tmpDir string, stdout, stderr *bytes.Buffer, status int) {
+		tmpDir, stdout, stderr = setUpForTestRebase(t, "g.txt")
+		status = rebase(t, tmpDir, stdout, stderr, []string{"master"}, RebaseOption{})
+		return
+	}
+
+	t.Run("stops with a detached HEAD", func(t *testing.T) {
+		tmpDir, _, _, status := before(t)
+		defer os.RemoveAll(tmpDir)
+
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		assertHeadRef(t, tmpDir, repository.HEAD)
+		assertGitStatus(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), "AA g.txt\n")
+	})
+
+	t.Run("continues after resolving the conflict", func(t *testing.T) {
+		tmpDir, stdout, stderr, _ := before(t)
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "g.txt", "resolved")
+		Add(tmpDir, []string{"g.txt"}, new(bytes.Buffer), new(bytes.Buffer))
+		status := rebase(t, tmpDir, stdout, stderr, []string{}, RebaseOption{SequencingOption: SequencingOption{Mode: Continue}})
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+
+		expected := []string{"four", "three", "five", "two"}
+		if got := titleLines(t, tmpDir, "topic", "topic^", "topic~2", "topic~3"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("want %v, but got %v", expected, got)
+		}
+		assertHeadRef(t, tmpDir, "refs/heads/topic")
+		assertWorkspace(t, tmpDir, map[string]string{
+			"f.txt": "two",
+			"g.txt": "resolved",
+			"h.txt": "four",
+		})
+	})
+
+	t.Run("skips the conflicting commit", func(t *testing.T) {
+		tmpDir, stdout, stderr, _ := before(t)
+		defer os.RemoveAll(tmpDir)
+
+		status := rebase(t, tmpDir, stdout, stderr, []string{}, RebaseOption{SequencingOption: SequencingOption{Mode: Skip}})
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+
+		expected := []string{"four", "five", "two"}
+		if got := titleLines(t, tmpDir, "topic", "topic^", "topic~2"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("want %v, but got %v", expected, got)
+		}
+		assertHeadRef(t, tmpDir, "refs/heads/topic")
+		assertGitStatus(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), "")
+	})
+
+	t.Run("aborts back to the original branch", func(t *testing.T) {
+		tmpDir, stdout, stderr, _ := before(t)
+		defer os.RemoveAll(tmpDir)
+
+		status := rebase(t, tmpDir, stdout, stderr, []string{}, RebaseOption{SequencingOption: SequencingOption{Mode: Abort}})
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+
+		expected := []string{"four", "three", "two"}
+		if got := titleLines(t, tmpDir, "@", "@^", "@~2"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("want %v, but got %v", expected, got)
+		}
+		assertHeadRef(t, tmpDir, "refs/heads/topic")
+		assertGitStatus(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), "")
+		if repo(t, tmpDir).PendingCommit.InProgress() {
+			t.Errorf("want %v, but got %v", false, true)
+		}
+	})
+}
+
+type noopEditor struct{}
+
+func (e *noopEditor) Run() error {
+	return nil
+}
+
+type editorFunc func() error
+
+func (f editorFunc) Run() error {
+	return f()
+}
+
+func setUpForTestInteractiveRebase(t *testing.T) (tmpDir string, stdout, stderr *bytes.Buffer) {
+	tmpDir, stdout, stderr = setupTestEnvironment(t)
+
+	commitTree(t, tmpDir, "one", map[string]string{"f.txt": "one"}, getTime())
+	commitTree(t, tmpDir, "two", map[string]string{"g.txt": "two"}, getTime())
+	commitTree(t, tmpDir, "three", map[string]string{"h.txt": "three"}, getTime())
+	commitTree(t, tmpDir, "four", map[string]string{"i.txt": "four"}, getTime())
+
+	return
+}
+
+func interactiveRebase(t *testing.T, tmpDir string, stdout, stderr *bytes.Buffer, todo []string, message string) int {
+	t.Helper()
+
+	edits := map[string]string{
+		"git-rebase-todo": strings.Join(todo, "\n") + "\n",
+		"COMMIT_EDITMSG":  message,
+	}
+	options := RebaseOption{
+		SequencingOption: SequencingOption{
+			IsTTY: true,
+			EditorCmd: func(path string) editor.Executable {
+				if edit := edits[filepath.Base(path)]; edit != "" {
+					return NewMockEditor(path, edit)
+				}
+				return &noopEditor{}
+			},
+		},
+		Interactive: true,
+	}
+	return rebase(t, tmpDir, stdout, stderr, []string{"@~3"}, options)
+}
+
+func todoLine(t *testing.T, tmpDir, action, expression string) string {
+	t.Helper()
+
+	object, _ := loadCommit(t, tmpDir, expression)
+	commit := object.(*database.Commit)
+	return fmt.Sprintf("%s %s %s", action, repo(t, tmpDir).Database.ShortOid(commit.Oid()), commit.TitleLine())
+}
+
+func TestRebaseInteractive(t *testing.T) {
+	continueRebase := func(t *testing.T, tmpDir string) int {
+		options := RebaseOption{SequencingOption: SequencingOption{
+			Mode: Continue,
+			EditorCmd: func(path string) editor.Executable {
+				return &noopEditor{}
+			},
+		}}
+		return rebase(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), []string{}, options)
+	}
+
+	// Picking four first drops g.txt, so squashing three onto it conflicts.
+	setUpForConflictedSquash := func(t *testing.T) (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+
+		commitTree(t, tmpDir, "one", map[string]string{"f.txt": "one"}, getTime())
+		commitTree(t, tmpDir, "two", map[string]string{"g.txt": "two"}, getTime())
+		commitTree(t, tmpDir, "three", map[string]string{"g.txt": "three"}, getTime())
+		commitTree(t, tmpDir, "four", map[string]string{"i.txt": "four"}, getTime())
+
+		return
+	}
+
+	t.Run("reorders and drops commits", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestInteractiveRebase(t)
+		defer os.RemoveAll(tmpDir)
+
+		todo := []string{todoLine(t, tmpDir, "pick", "@"), todoLine(t, tmpDir, "pick", "@~2"), todoLine(t, tmpDir, "drop", "@^")}
+		status := interactiveRebase(t, tmpDir, stdout, stderr, todo, "")
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+
+		expected := []string{"two", "four", "one"}
+		if got := titleLines(t, tmpDir, "@", "@^", "@~2"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("want %v, but got %v", expected, got)
+		}
+		assertHeadRef(t, tmpDir, "refs/heads/master")
+		assertWorkspace(t, tmpDir, map[string]string{"f.txt": "one", "g.txt": "two", "i.txt": "four"})
+	})
+
+	t.Run("rewords a commit", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestInteractiveRebase(t)
+		defer os.RemoveAll(tmpDir)
+
+		todo := []string{todoLine(t, tmpDir, "pick", "@~2"), todoLine(t, tmpDir, "r", "@^"), todoLine(t, tmpDir, "pick", "@")}
+		interactiveRebase(t, tmpDir, stdout, stderr, todo, "three, reworded\n")
+
+		expected := []string{"four", "three, reworded", "two", "one"}
+		if got := titleLines(t, tmpDir, "@", "@^", "@~2", "@~3"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("want %v, but got %v", expected, got)
+		}
+	})
+
+	t.Run("squashes commits and combines their messages", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestInteractiveRebase(t)
+		defer os.RemoveAll(tmpDir)
+
+		todo := []string{todoLine(t, tmpDir, "pick", "@~2"), todoLine(t, tmpDir, "squash", "@^"), todoLine(t, tmpDir, "pick", "@")}
+		interactiveRebase(t, tmpDir, stdout, stderr, todo, "")
+
+		object, _ := loadCommit(t, tmpDir, "@^")
+		if got := object.(*database.Commit).Message(); got != "two\n\nthree\n" {
+			t.Errorf("want %q, but got %q", "two\n\nthree\n", got)
+		}
+		expected := []string{"four", "two", "one"}
+		if got := titleLines(t, tmpDir, "@", "@^", "@~2"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("want %v, but got %v", expected, got)
+		}
+		assertWorkspace(t, tmpDir, map[string]string{"f.txt": "one", "g.txt": "two", "h.txt": "three", "i.txt": "four"})
+	})
+
+	t.Run("fixes up a commit and discards its message", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestInteractiveRebase(t)
+		defer os.RemoveAll(tmpDir)
+
+		todo := []string{todoLine(t, tmpDir, "pick", "@~2"), todoLine(t, tmpDir, "f", "@^"), todoLine(t, tmpDir, "pick", "@")}
+		interactiveRebase(t, tmpDir, stdout, stderr, todo, "")
+
+		object, _ := loadCommit(t, tmpDir, "@^")
+		if got := object.(*database.Commit).Message(); got != "two\n" {
+			t.Errorf("want %q, but got %q", "two\n", got)
+		}
+		expected := []string{"four", "two", "one"}
+		if got := titleLines(t, tmpDir, "@", "@^", "@~2"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("want %v, but got %v", expected, got)
+		}
+	})
+
+	for action, message := range map[string]string{"squash": "four\n\nthree\n", "fixup": "four\n"} {
+		t.Run("folds a conflicted "+action+" into the previous commit on continue", func(t *testing.T) {
+			tmpDir, stdout, stderr := setUpForConflictedSquash(t)
+			defer os.RemoveAll(tmpDir)
+
+			todo := []string{todoLine(t, tmpDir, "pick", "@"), todoLine(t, tmpDir, action, "@^")}
+			if status := interactiveRebase(t, tmpDir, stdout, stderr, todo, ""); status != 1 {
+				t.Errorf("want %d, but got %d", 1, status)
+			}
+			if got := titleLines(t, tmpDir, "@", "@^"); !reflect.DeepEqual(got, []string{"four", "one"}) {
+				t.Errorf("want %v, but got %v", []string{"four", "one"}, got)
+			}
+
+			writeFile(t, tmpDir, "g.txt", "resolved")
+			Add(tmpDir, []string{"g.txt"}, new(bytes.Buffer), new(bytes.Buffer))
+			if status := continueRebase(t, tmpDir); status != 0 {
+				t.Errorf("want %d, but got %d", 0, status)
+			}
+
+			object, _ := loadCommit(t, tmpDir, "@")
+			if got := object.(*database.Commit).Message(); got != message {
+				t.Errorf("want %q, but got %q", message, got)
+			}
+			if got := titleLines(t, tmpDir, "@", "@^"); !reflect.DeepEqual(got, []string{"four", "one"}) {
+				t.Errorf("want %v, but got %v", []string{"four", "one"}, got)
+			}
+			assertHeadRef(t, tmpDir, "refs/heads/master")
+			assertWorkspace(t, tmpDir, map[string]string{"f.txt": "one", "g.txt": "resolved", "i.txt": "four"})
+			assertGitStatus(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), "")
+		})
+	}
+
+	t.Run("stops to edit a commit", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestInteractiveRebase(t)
+		defer os.RemoveAll(tmpDir)
+
+		short := repo(t, tmpDir).Database.ShortOid(mustResolve(t, tmpDir, "@^"))
+		todo := []string{todoLine(t, tmpDir, "pick", "@~2"), todoLine(t, tmpDir, "edit", "@^"), todoLine(t, tmpDir, "pick", "@")}
+		status := interactiveRebase(t, tmpDir, stdout, stderr, todo, "")
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+
+		expected := fmt.Sprintf("Stopped at %s... three\n", short)
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("want %q in %q", expected, stdout.String())
+		}
+		assertHeadRef(t, tmpDir, repository.HEAD)
+		if got := titleLines(t, tmpDir, "@"); !reflect.DeepEqual(got, []string{"three"}) {
+			t.Errorf("want %v, but got %v", []string{"three"}, got)
+		}
+
+		if status := continueRebase(t, tmpDir); status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		if got := titleLines(t, tmpDir, "@", "@^", "@~2"); !reflect.DeepEqual(got, []string{"four", "three", "two"}) {
+			t.Errorf("want %v, but got %v", []string{"four", "three", "two"}, got)
+		}
+		assertHeadRef(t, tmpDir, "refs/heads/master")
+	})
+
+	t.Run("pauses at a break", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestInteractiveRebase(t)
+		defer os.RemoveAll(tmpDir)
+
+		todo := []string{todoLine(t, tmpDir, "pick", "@~2"), "break", todoLine(t, tmpDir, "pick", "@")}
+		interactiveRebase(t, tmpDir, stdout, stderr, todo, "")
+
+		if got := titleLines(t, tmpDir, "@"); !reflect.DeepEqual(got, []string{"two"}) {
+			t.Errorf("want %v, but got %v", []string{"two"}, got)
+		}
+
+		continueRebase(t, tmpDir)
+		if got := titleLines(t, tmpDir, "@", "@^", "@~2"); !reflect.DeepEqual(got, []string{"four", "two", "one"}) {
+			t.Errorf("want %v, but got %v", []string{"four", "two", "one"}, got)
+		}
+	})
+
+	t.Run("runs exec commands and stops when they fail", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestInteractiveRebase(t)
+		defer os.RemoveAll(tmpDir)
+
+		todo := []string{todoLine(t, tmpDir, "pick", "@~2"), "exec touch ran.txt", "x false", todoLine(t, tmpDir, "pick", "@^")}
+		status := interactiveRebase(t, tmpDir, stdout, stderr, todo, "")
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "ran.txt")); err != nil {
+			t.Errorf("want ran.txt to exist, but got %v", err)
+		}
+		if !strings.Contains(stderr.String(), "warning: execution failed: false\n") {
+			t.Errorf("want an execution failure, but got %q", stderr.String())
+		}
+
+		continueRebase(t, tmpDir)
+		if got := titleLines(t, tmpDir, "@", "@^"); !reflect.DeepEqual(got, []string{"three", "two"}) {
+			t.Errorf("want %v, but got %v", []string{"three", "two"}, got)
+		}
+	})
+
+	t.Run("names the --onto base in the todo list", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestInteractiveRebase(t)
+		defer os.RemoveAll(tmpDir)
+
+		short := repo(t, tmpDir).Database.ShortOid
+		upstream, head, onto := mustResolve(t, tmpDir, "@^"), mustResolve(t, tmpDir, "@"), mustResolve(t, tmpDir, "@~3")
+
+		var todo []byte
+		options := RebaseOption{
+			SequencingOption: SequencingOption{
+				IsTTY: true,
+				EditorCmd: func(path string) editor.Executable {
+					return editorFunc(func() (err error) {
+						todo, err = os.ReadFile(path)
+						return err
+					})
+				},
+			},
+			Onto:        "@~3",
+			Interactive: true,
+		}
+		rebase(t, tmpDir, stdout, stderr, []string{"@^"}, options)
+
+		expected := fmt.Sprintf("# Rebase %s..%s onto %s (1 commands)\n", short(upstream), short(head), short(onto))
+		if !strings.Contains(string(todo), expected) {
+			t.Errorf("want %q in %q", expected, todo)
+		}
+		if got := titleLines(t, tmpDir, "@", "@^"); !reflect.DeepEqual(got, []string{"four", "one"}) {
+			t.Errorf("want %v, but got %v", []string{"four", "one"}, got)
+		}
+	})
+
+	t.Run("rejects an invalid todo list", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestInteractiveRebase(t)
+		defer os.RemoveAll(tmpDir)
+
+		head := mustResolve(t, tmpDir, "@")
+		status := interactiveRebase(t, tmpDir, stdout, stderr, []string{"frob something"}, "")
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+
+		expected := "error: invalid command 'frob'\nerror: invalid line 1: frob something\n"
+		if stderr.String() != expected {
+			t.Errorf("want %q, but got %q", expected, stderr.String())
+		}
+		if got := mustResolve(t, tmpDir, "@"); got != head {
+			t.Errorf("want %q, but got %q", head, got)
+		}
+		assertHeadRef(t, tmpDir, "refs/heads/master")
+	})
+
+	t.Run("aborts when the todo list is emptied", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestInteractiveRebase(t)
+		defer os.RemoveAll(tmpDir)
+
+		status := interactiveRebase(t, tmpDir, stdout, stderr, []string{"# nothing"}, "")
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		if expected := "error: nothing to do\n"; stderr.String() != expected {
+			t.Errorf("want %q, but got %q", expected, stderr.String())
+		}
+	})
+}
+
+func TestRebaseAutosquash(t *testing.T) {
+	tmpDir, stdout, stderr := setupTestEnvironment(t)
+	defer os.RemoveAll(tmpDir)
+
+	commitTree(t, tmpDir, "one", map[string]string{"f.txt": "one"}, getTime())
+	commitTree(t, tmpDir, "two", map[string]string{"g.txt": "two"}, getTime())
+	commitTree(t, tmpDir, "three", map[string]string{"h.txt": "three"}, getTime())
+	commitTree(t, tmpDir, "fixup! two", map[string]string{"g.txt": "two, fixed"}, getTime())
+
+	status := rebase(t, tmpDir, stdout, stderr, []string{"@~3"}, RebaseOption{Autosquash: true})
+	if status != 0 {
+		t.Errorf("want %d, but got %d", 0, status)
+	}
+
+	expected := []string{"three", "two", "one"}
+	if got := titleLines(t, tmpDir, "@", "@^", "@~2"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("want %v, but got %v", expected, got)
+	}
+	object, _ := loadCommit(t, tmpDir, "@^")
+	tree := repo(t, tmpDir).Database.LoadTreeList(object.Oid(), "")
+	blob, _ := repo(t, tmpDir).Database.Load(tree["g.txt"].Oid())
+	if got := blob.String(); got != "two, fixed" {
+		t.Errorf("want %q, but got %q", "two, fixed", got)
+	}
+}
@@ -0,0 +1,171 @@
+package command
+
+import (
+	"building-git/lib/repository"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var RECEIVE_PACK_CAPABILITIES = []string{"no-thin", "report-status", "delete-refs", "ofs-delta"}
+
+type receiveRequest struct {
+	ref    string
+	oldOid string
+	newOid string
+}
+
+type ReceivePack struct {
+	args        []string
+	agent       *remoteAgent
+	stdin       io.Reader
+	stdout      io.Writer
+	stderr      io.Writer
+	requests    []*receiveRequest
+	unpackError error
+}
+
+func NewReceivePack(args []string, stdin io.Reader, stdout, stderr io.Writer) (*ReceivePack, error) {
+	agent, err := newRemoteAgent(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReceivePack{
+		args:     args,
+		agent:    agent,
+		stdin:    stdin,
+		stdout:   stdout,
+		stderr:   stderr,
+		requests: []*receiveRequest{},
+	}, nil
+}
+
+func (r *ReceivePack) Run() int {
+	r.agent.acceptClient("receive-pack", r.stdin, r.stdout, RECEIVE_PACK_CAPABILITIES)
+
+	if err := r.receivePack(); err != nil {
+		fmt.Fprintf(r.stderr, "fatal: %v\n", err)
+		return 128
+	}
+	return 0
+}
+
+func (r *ReceivePack) receivePack() error {
+	if err := r.agent.sendReferences(); err != nil {
+		return err
+	}
+	if err := r.recvUpdateRequests(); err != nil {
+		return err
+	}
+	if len(r.requests) == 0 {
+		return nil
+	}
+
+	if err := r.recvObjects(); err != nil {
+		return err
+	}
+	return r.updateRefs()
+}
+
+func (r *ReceivePack) recvUpdateRequests() error {
+	return r.agent.conn.RecvUntil("", func(line string) error {
+		parts := strings.SplitN(line, " ", 3)
+		if len(parts) != 3 {
+			return fmt.Errorf("protocol error: unexpected line '%s'", line)
+		}
+		r.requests = append(r.requests, &receiveRequest{
+			ref:    parts[2],
+			oldOid: repository.ZeroToNil(strings.ToLower(parts[0])),
+			newOid: repository.ZeroToNil(strings.ToLower(parts[1])),
+		})
+		return nil
+	})
+}
+
+func (r *ReceivePack) recvObjects() error {
+	for _, request := range r.requests {
+		if request.newOid == "" {
+			continue
+		}
+		unpackLimit := configInt(r.agent.repo, []string{"receive", "unpackLimit"})
+		r.unpackError = recvPackedObjects(r.agent.repo, r.agent.conn, unpackLimit, "")
+		break
+	}
+	if r.unpackError != nil {
+		return r.reportStatus(fmt.Sprintf("unpack %v", r.unpackError))
+	}
+	return r.reportStatus("unpack ok")
+}
+
+func (r *ReceivePack) updateRefs() error {
+	for _, request := range r.requests {
+		if err := r.updateRef(request); err != nil {
+			return err
+		}
+	}
+	return r.agent.conn.SendFlush()
+}
+
+func (r *ReceivePack) updateRef(request *receiveRequest) error {
+	if r.unpackError != nil {
+		return r.reportStatus(fmt.Sprintf("ng %s unpacker error", request.ref))
+	}
+
+	if message := r.validateUpdate(request); message != "" {
+		return r.reportStatus(fmt.Sprintf("ng %s %s", request.ref, message))
+	}
+
+	err := r.agent.repo.Refs.CompareAndSwap(request.ref, request.oldOid, request.newOid, "push")
+	if err != nil {
+		return r.reportStatus(fmt.Sprintf("ng %s %v", request.ref, err))
+	}
+	return r.reportStatus(fmt.Sprintf("ok %s", request.ref))
+}
+
+func (r *ReceivePack) validateUpdate(request *receiveRequest) string {
+	repo := r.agent.repo
+
+	if request.newOid != "" && !repo.Database.Has(request.newOid) {
+		return "missing necessary objects"
+	}
+	if r.configBool("receive", "denyDeletes", false) && request.newOid == "" {
+		return "deletion prohibited"
+	}
+	if r.configBool("receive", "denyNonFastForwards", false) {
+		if fastForwardError(repo, request.oldOid, request.newOid) != "" {
+			return "non-fast-forward"
+		}
+	}
+
+	if repo.IsBare() {
+		return ""
+	}
+	current, err := repo.Refs.CurrentRef("")
+	if err != nil || current.Path != request.ref {
+		return ""
+	}
+
+	if request.newOid != "" && r.configBool("receive", "denyCurrentBranch", true) {
+		return "branch is currently checked out"
+	}
+	if request.newOid == "" && r.configBool("receive", "denyDeleteCurrent", true) {
+		return "deletion of the current branch prohibited"
+	}
+	return ""
+}
+
+func (r *ReceivePack) configBool(section, name string, fallback bool) bool {
+	value, _ := r.agent.repo.Config.Get([]string{section, name})
+	if b, ok := value.(bool); ok {
+		return b
+	}
+	return fallback
+}
+
+func (r *ReceivePack) reportStatus(line string) error {
+	if !r.agent.conn.IsCapable("report-status") {
+		return nil
+	}
+	return r.agent.conn.SendPacket(line)
+}
@@ -0,0 +1,241 @@
+package command
+
+import (
+	"building-git/lib/repository"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const DEFAULT_REFLOG_EXPIRE = "90.days.ago"
+
+var (
+	EXPIRE_RELATIVE = regexp.MustCompile(`^(\d+)\.(second|minute|hour|day|week|month|year)s?\.ago$`)
+	EXPIRE_UNITS    = map[string]time.Duration{
+		"second": time.Second,
+		"minute": time.Minute,
+		"hour":   time.Hour,
+		"day":    24 * time.Hour,
+		"week":   7 * 24 * time.Hour,
+		"month":  30 * 24 * time.Hour,
+		"year":   365 * 24 * time.Hour,
+	}
+)
+
+type ReflogOption struct {
+	Expire string
+	All    bool
+}
+
+type Reflog struct {
+	rootPath string
+	args     []string
+	options  ReflogOption
+	repo     *repository.Repository
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+func NewReflog(dir string, args []string, options ReflogOption, stdout, stderr io.Writer) (*Reflog, error) {
+	rootPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(rootPath)
+
+	return &Reflog{
+		rootPath: rootPath,
+		args:     args,
+		options:  options,
+		repo:     repo,
+		stdout:   stdout,
+		stderr:   stderr,
+	}, nil
+}
+
+func (r *Reflog) Run(now time.Time) int {
+	subcommand := "show"
+	if len(r.args) > 0 {
+		switch r.args[0] {
+		case "show", "expire", "delete":
+			subcommand, r.args = r.args[0], r.args[1:]
+		}
+	}
+
+	var err error
+	switch subcommand {
+	case "show":
+		err = r.showReflog()
+	case "expire":
+		err = r.expireReflogs(now)
+	case "delete":
+		err = r.deleteEntries()
+	}
+
+	if err != nil {
+		fmt.Fprintf(r.stderr, "fatal: %v\n", err)
+		return 128
+	}
+	return 0
+}
+
+func (r *Reflog) showReflog() error {
+	name := repository.HEAD
+	if len(r.args) > 0 {
+		name = r.args[0]
+	}
+	refName, err := r.refName(name)
+	if err != nil {
+		return err
+	}
+
+	entries, err := r.repo.Refs.Reflog().Read(refName)
+	if err != nil {
+		return err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		short := r.repo.Database.ShortOid(entry.NewOid)
+		fmt.Fprintf(r.stdout, "%s %s@{%d}: %s\n", short, name, len(entries)-1-i, entry.Message)
+	}
+	return nil
+}
+
+func (r *Reflog) expireReflogs(now time.Time) error {
+	expire := r.options.Expire
+	if expire == "" {
+		value, _ := r.repo.Config.Get([]string{"gc", "reflogExpire"})
+		expire, _ = value.(string)
+	}
+	if expire == "" {
+		expire = DEFAULT_REFLOG_EXPIRE
+	}
+	cutoff, err := parseExpiry(expire, now)
+	if err != nil {
+		return err
+	}
+
+	refNames, err := r.selectReflogs()
+	if err != nil {
+		return err
+	}
+
+	reflog := r.repo.Refs.Reflog()
+	for _, refName := range refNames {
+		entries, err := reflog.Read(refName)
+		if err != nil {
+			return err
+		}
+		kept := []*repository.ReflogEntry{}
+		for _, entry := range entries {
+			if !entry.Identity.Time().Before(cutoff) {
+				kept = append(kept, entry)
+			}
+		}
+		if len(kept) == len(entries) {
+			continue
+		}
+		if err := reflog.Write(refName, kept); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectReflogs picks the reflogs to expire: every reflog with --all, the
+// named refs otherwise, and only HEAD's when no ref is given.
+func (r *Reflog) selectReflogs() ([]string, error) {
+	if r.options.All {
+		names, err := r.repo.Refs.Reflog().ListLogs()
+		sort.Strings(names)
+		return names, err
+	}
+
+	args := r.args
+	if len(args) == 0 {
+		args = []string{repository.HEAD}
+	}
+
+	names := []string{}
+	for _, name := range args {
+		refName, err := r.refName(name)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, refName)
+	}
+	return names, nil
+}
+
+func (r *Reflog) deleteEntries() error {
+	if len(r.args) == 0 {
+		return fmt.Errorf("no reflog specified to delete")
+	}
+
+	reflog := r.repo.Refs.Reflog()
+	for _, arg := range r.args {
+		match := repository.REFLOG.FindStringSubmatch(arg)
+		if match == nil {
+			return fmt.Errorf("not a reflog: %s", arg)
+		}
+		refName, err := r.refName(match[1])
+		if err != nil {
+			return err
+		}
+		n, _ := strconv.Atoi(match[2])
+
+		entries, err := reflog.Read(refName)
+		if err != nil {
+			return err
+		}
+		if n >= len(entries) {
+			return fmt.Errorf("log for '%s' only has %d entries", match[1], len(entries))
+		}
+		index := len(entries) - 1 - n
+		entries = append(entries[:index], entries[index+1:]...)
+		if err := reflog.Write(refName, entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reflog) refName(name string) (string, error) {
+	if name == "" || name == "@" {
+		current, err := r.repo.Refs.CurrentRef("")
+		if err != nil {
+			return "", err
+		}
+		return current.Path, nil
+	}
+
+	refName, err := r.repo.Refs.ExpandName(name)
+	if err != nil {
+		return "", fmt.Errorf("ambiguous argument '%s': unknown revision or path not in the working tree.", name)
+	}
+	return refName, nil
+}
+
+func parseExpiry(value string, now time.Time) (time.Time, error) {
+	switch value {
+	case "now":
+		return now, nil
+	case "all":
+		return now.Add(time.Second), nil
+	case "never", "false":
+		return time.Time{}, nil
+	}
+
+	if match := EXPIRE_RELATIVE.FindStringSubmatch(value); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		return now.Add(-time.Duration(n) * EXPIRE_UNITS[match[2]]), nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid expire time: %s", value)
+}
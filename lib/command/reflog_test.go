@@ -0,0 +1,196 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReflog(t *testing.T) {
+	setup := func() (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+		for _, message := range []string{"first", "second", "third"} {
+			commitTree(t, tmpDir, message, map[string]string{"file.txt": message}, time.Now())
+		}
+		return
+	}
+
+	reflog := func(tmpDir string, args []string, options ReflogOption, now time.Time) (string, string, int) {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		cmd, _ := NewReflog(tmpDir, args, options, stdout, stderr)
+		status := cmd.Run(now)
+		return stdout.String(), stderr.String(), status
+	}
+
+	short := func(tmpDir, expression string) string {
+		oid, _ := resolveRevision(t, tmpDir, expression)
+		return repo(t, tmpDir).Database.ShortOid(oid)
+	}
+
+	t.Run("records commits in the HEAD log", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		stdout, _, _ := reflog(tmpDir, []string{}, ReflogOption{}, time.Now())
+
+		expected := fmt.Sprintf(`%s HEAD@{0}: commit: third
+%s HEAD@{1}: commit: second
+%s HEAD@{2}: commit (initial): first
+`, short(tmpDir, "@"), short(tmpDir, "@^"), short(tmpDir, "@^^"))
+		if stdout != expected {
+			t.Errorf("want %q, but got %q", expected, stdout)
+		}
+	})
+
+	t.Run("records checkouts in the HEAD log only", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		branch, _ := NewBranch(tmpDir, []string{"topic", "@^"}, BranchOption{}, stdout, stderr)
+		branch.Run()
+		checkout(tmpDir, stdout, stderr, "topic")
+
+		head, _, _ := reflog(tmpDir, []string{"show", "HEAD"}, ReflogOption{}, time.Now())
+		expected := fmt.Sprintf("%s HEAD@{0}: checkout: moving from master to topic\n", short(tmpDir, "topic"))
+		if !strings.HasPrefix(head, expected) {
+			t.Errorf("want prefix %q, but got %q", expected, head)
+		}
+
+		topic, _, _ := reflog(tmpDir, []string{"show", "topic"}, ReflogOption{}, time.Now())
+		expected = fmt.Sprintf("%s topic@{0}: branch: Created from @^\n", short(tmpDir, "topic"))
+		if topic != expected {
+			t.Errorf("want %q, but got %q", expected, topic)
+		}
+	})
+
+	t.Run("resolves @{n} against the reflog", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		for expression, target := range map[string]string{
+			"@{0}":        "@",
+			"HEAD@{1}":    "@^",
+			"master@{2}":  "@^^",
+			"master@{1}^": "@^^",
+			"HEAD@{0}~2":  "@^^",
+		} {
+			got, err := resolveRevision(t, tmpDir, expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, _ := resolveRevision(t, tmpDir, target)
+			if got != expected {
+				t.Errorf("%s: want %q, but got %q", expression, expected, got)
+			}
+		}
+	})
+
+	t.Run("fails to resolve entries beyond the end of the log", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		if _, err := resolveRevision(t, tmpDir, "master@{3}"); err == nil {
+			t.Errorf("expected an error for a missing reflog entry")
+		}
+	})
+
+	t.Run("resolves @{-n} to previously checked out branches", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		branch, _ := NewBranch(tmpDir, []string{"topic", "@^"}, BranchOption{}, stdout, stderr)
+		branch.Run()
+		checkout(tmpDir, stdout, stderr, "topic")
+
+		got, _ := resolveRevision(t, tmpDir, "@{-1}")
+		expected, _ := resolveRevision(t, tmpDir, "master")
+		if got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+
+		checkout(tmpDir, stdout, stderr, "@{-1}")
+		ref, _ := repo(t, tmpDir).Refs.CurrentRef("")
+		if name, _ := ref.ShortName(); name != "master" {
+			t.Errorf("want %q, but got %q", "master", name)
+		}
+	})
+
+	t.Run("deletes a single reflog entry", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		_, _, status := reflog(tmpDir, []string{"delete", "HEAD@{1}"}, ReflogOption{}, time.Now())
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+
+		stdout, _, _ := reflog(tmpDir, []string{}, ReflogOption{}, time.Now())
+		expected := fmt.Sprintf(`%s HEAD@{0}: commit: third
+%s HEAD@{1}: commit (initial): first
+`, short(tmpDir, "@"), short(tmpDir, "@^^"))
+		if stdout != expected {
+			t.Errorf("want %q, but got %q", expected, stdout)
+		}
+	})
+
+	t.Run("fails to delete a missing reflog entry", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		_, stderr, status := reflog(tmpDir, []string{"delete", "master@{5}"}, ReflogOption{}, time.Now())
+		if status != 128 {
+			t.Errorf("want %d, but got %d", 128, status)
+		}
+		expected := "fatal: log for 'master' only has 3 entries\n"
+		if stderr != expected {
+			t.Errorf("want %q, but got %q", expected, stderr)
+		}
+	})
+
+	t.Run("keeps entries newer than the expiry time", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		reflog(tmpDir, []string{"expire"}, ReflogOption{All: true}, time.Now())
+
+		stdout, _, _ := reflog(tmpDir, []string{}, ReflogOption{}, time.Now())
+		if lines := strings.Count(stdout, "\n"); lines != 3 {
+			t.Errorf("want %d, but got %d", 3, lines)
+		}
+	})
+
+	t.Run("expires entries older than the expiry time", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		later := time.Now().Add(48 * time.Hour)
+		options := ReflogOption{Expire: "1.day.ago", All: true}
+		reflog(tmpDir, []string{"expire"}, options, later)
+
+		for _, name := range []string{"HEAD", "master"} {
+			stdout, _, _ := reflog(tmpDir, []string{"show", name}, ReflogOption{}, time.Now())
+			if stdout != "" {
+				t.Errorf("want %q, but got %q", "", stdout)
+			}
+		}
+	})
+	t.Run("expires only the HEAD log when no ref is given", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		later := time.Now().Add(48 * time.Hour)
+		reflog(tmpDir, []string{"expire"}, ReflogOption{Expire: "1.day.ago"}, later)
+
+		stdout, _, _ := reflog(tmpDir, []string{"show", "HEAD"}, ReflogOption{}, time.Now())
+		if stdout != "" {
+			t.Errorf("want %q, but got %q", "", stdout)
+		}
+		stdout, _, _ = reflog(tmpDir, []string{"show", "master"}, ReflogOption{}, time.Now())
+		if lines := strings.Count(stdout, "\n"); lines != 3 {
+			t.Errorf("want %d, but got %d", 3, lines)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package command
+
+import (
+	"building-git/lib/pathutils"
+	"building-git/lib/repository"
+	"building-git/lib/repository/remotes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type remoteAgent struct {
+	repo *repository.Repository
+	conn *remotes.Protocol
+}
+
+func newRemoteAgent(dir string) (*remoteAgent, error) {
+	pathname, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, candidate := range pathutils.Ascend(pathname) {
+		for _, gitPath := range []string{candidate, filepath.Join(candidate, ".git")} {
+			if !isGitRepository(gitPath) {
+				continue
+			}
+			if filepath.Base(gitPath) == ".git" {
+				return &remoteAgent{repo: repository.NewRepository(filepath.Dir(gitPath))}, nil
+			}
+			return &remoteAgent{repo: repository.NewBareRepository(gitPath)}, nil
+		}
+	}
+	return nil, fmt.Errorf("'%s' does not appear to be a git repository", dir)
+}
+
+func isGitRepository(dirname string) bool {
+	head, err := os.Stat(filepath.Join(dirname, "HEAD"))
+	if err != nil || !head.Mode().IsRegular() {
+		return false
+	}
+	for _, name := range []string{"objects", "refs"} {
+		stat, err := os.Stat(filepath.Join(dirname, name))
+		if err != nil || !stat.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *remoteAgent) acceptClient(name string, input io.Reader, output io.Writer, capabilities []string) {
+	a.conn = remotes.NewProtocol(name, input, output, capabilities)
+}
+
+func (a *remoteAgent) sendReferences() error {
+	refs := a.repo.Refs.ListAllRefs()
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Path < refs[j].Path
+	})
+
+	sent := false
+	for _, symRef := range refs {
+		oid, err := symRef.ReadOid()
+		if err != nil || oid == "" {
+			continue
+		}
+		if err := a.conn.SendPacket(fmt.Sprintf("%s %s", strings.ToLower(oid), symRef.Path)); err != nil {
+			return err
+		}
+		sent = true
+	}
+
+	if !sent {
+		if err := a.conn.SendPacket(fmt.Sprintf("%s capabilities^{}", repository.ZERO_OID)); err != nil {
+			return err
+		}
+	}
+	return a.conn.SendFlush()
+}
@@ -0,0 +1,150 @@
+package command
+
+import (
+	"building-git/lib/repository"
+	"building-git/lib/repository/remotes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var REF_LINE = regexp.MustCompile(`^([0-9a-f]+) (.*)$`)
+
+type remoteClient struct {
+	repo       *repository.Repository
+	conn       *remotes.Protocol
+	agent      *exec.Cmd
+	remoteRefs map[string]string
+	stderr     io.Writer
+}
+
+func newRemoteClient(repo *repository.Repository, stderr io.Writer) *remoteClient {
+	return &remoteClient{
+		repo:       repo,
+		remoteRefs: map[string]string{},
+		stderr:     stderr,
+	}
+}
+
+func (c *remoteClient) startAgent(name, program, rawUrl string, capabilities []string) error {
+	argv, err := c.buildAgentCommand(program, rawUrl)
+	if err != nil {
+		return err
+	}
+
+	agent := exec.Command(argv[0], argv[1:]...)
+	agent.Stderr = os.Stderr
+
+	input, err := agent.StdinPipe()
+	if err != nil {
+		return err
+	}
+	output, err := agent.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := agent.Start(); err != nil {
+		return err
+	}
+
+	c.agent = agent
+	c.conn = remotes.NewProtocol(name, output, input, capabilities)
+	return nil
+}
+
+func (c *remoteClient) buildAgentCommand(program, rawUrl string) ([]string, error) {
+	uri, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	if uri.Scheme != "" && uri.Scheme != "file" {
+		return nil, fmt.Errorf("unsupported URL protocol: %s", rawUrl)
+	}
+	return append(strings.Fields(program), uri.Path), nil
+}
+
+func (c *remoteClient) finishAgent() error {
+	if closer, ok := c.conn.Output().(io.Closer); ok {
+		closer.Close()
+	}
+	return c.agent.Wait()
+}
+
+func (c *remoteClient) recvReferences() error {
+	return c.conn.RecvUntil("", func(line string) error {
+		match := REF_LINE.FindStringSubmatch(line)
+		if match == nil {
+			return fmt.Errorf("protocol error: unexpected line '%s'", line)
+		}
+		oid, ref := strings.ToLower(match[1]), match[2]
+		if oid != repository.ZERO_OID {
+			c.remoteRefs[ref] = oid
+		}
+		return nil
+	})
+}
+
+func (c *remoteClient) remoteRefNames() []string {
+	names := []string{}
+	for name := range c.remoteRefs {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (c *remoteClient) reportRefUpdate(refNames []string, err, oldOid, newOid string, isFF bool) {
+	if err != "" {
+		c.showRefUpdate("!", "[rejected]", refNames, err)
+		return
+	}
+	if oldOid == newOid {
+		return
+	}
+
+	if oldOid == "" && strings.HasPrefix(refNames[len(refNames)-1], repository.TagsDir()+"/") {
+		c.showRefUpdate("*", "[new tag]", refNames, "")
+	} else if oldOid == "" {
+		c.showRefUpdate("*", "[new branch]", refNames, "")
+	} else if newOid == "" {
+		c.showRefUpdate("-", "[deleted]", refNames, "")
+	} else {
+		c.reportRangeUpdate(refNames, oldOid, newOid, isFF)
+	}
+}
+
+func (c *remoteClient) reportRangeUpdate(refNames []string, oldOid, newOid string, isFF bool) {
+	oldOid = c.repo.Database.ShortOid(oldOid)
+	newOid = c.repo.Database.ShortOid(newOid)
+
+	if isFF {
+		revisions := fmt.Sprintf("%s..%s", oldOid, newOid)
+		c.showRefUpdate(" ", revisions, refNames, "")
+	} else {
+		revisions := fmt.Sprintf("%s...%s", oldOid, newOid)
+		c.showRefUpdate("+", revisions, refNames, "forced update")
+	}
+}
+
+func (c *remoteClient) showRefUpdate(flag, summary string, refNames []string, reason string) {
+	names := []string{}
+	for _, name := range refNames {
+		if name == "" {
+			continue
+		}
+		short, err := c.repo.Refs.ShortName(name)
+		if err != nil {
+			short = name
+		}
+		names = append(names, short)
+	}
+
+	message := fmt.Sprintf(" %s %s %s", flag, summary, strings.Join(names, " -> "))
+	if reason != "" {
+		message += fmt.Sprintf(" (%s)", reason)
+	}
+	fmt.Fprintln(c.stderr, message)
+}
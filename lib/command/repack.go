@@ -0,0 +1,132 @@
+package command
+
+import (
+	"bufio"
+	"building-git/lib/pack"
+	"building-git/lib/repository"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type RepackOption struct {
+	Delete bool
+}
+
+type Repack struct {
+	rootPath string
+	args     []string
+	options  RepackOption
+	repo     *repository.Repository
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+func NewRepack(dir string, args []string, options RepackOption, stdout, stderr io.Writer) (*Repack, error) {
+	rootPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(rootPath)
+
+	return &Repack{
+		rootPath: rootPath,
+		args:     args,
+		options:  options,
+		repo:     repo,
+		stdout:   stdout,
+		stderr:   stderr,
+	}, nil
+}
+
+func (r *Repack) Run() int {
+	revList, err := repository.NewRevList(r.repo, []string{}, repository.RevListOption{
+		All:     true,
+		Objects: true,
+	})
+	if err != nil {
+		fmt.Fprintf(r.stderr, "fatal: %v\n", err)
+		return 128
+	}
+
+	writer, packPath, err := r.writePack(revList)
+	if err != nil {
+		fmt.Fprintf(r.stderr, "fatal: %v\n", err)
+		return 128
+	}
+
+	deltas := 0
+	for _, entry := range writer.Entries() {
+		if entry.Delta() != nil {
+			deltas++
+		}
+	}
+	fmt.Fprintf(r.stderr, "Total %d (delta %d)\n", len(writer.Entries()), deltas)
+
+	if !r.options.Delete {
+		return 0
+	}
+	if err := r.repo.Database.RemoveRedundantPacks(packPath); err != nil {
+		fmt.Fprintf(r.stderr, "fatal: %v\n", err)
+		return 128
+	}
+	if _, err := r.repo.Database.PrunePacked(); err != nil {
+		fmt.Fprintf(r.stderr, "fatal: %v\n", err)
+		return 128
+	}
+	return 0
+}
+
+func (r *Repack) writePack(revList *repository.RevList) (*pack.Writer, string, error) {
+	dirname := r.repo.Database.PackPath()
+	if err := os.MkdirAll(dirname, os.ModePerm); err != nil {
+		return nil, "", err
+	}
+
+	packFile, err := os.CreateTemp(dirname, "tmp_pack_")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.Remove(packFile.Name())
+	defer packFile.Close()
+
+	writer, err := writePackedObjects(r.repo, revList, packFile, true)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := packFile.Close(); err != nil {
+		return nil, "", err
+	}
+
+	basename := filepath.Join(dirname, "pack-"+hex.EncodeToString(writer.Checksum()))
+	if err := r.writeIndex(writer, basename+".idx"); err != nil {
+		return nil, "", err
+	}
+	if err := os.Rename(packFile.Name(), basename+".pack"); err != nil {
+		return nil, "", err
+	}
+	return writer, basename + ".pack", nil
+}
+
+func (r *Repack) writeIndex(writer *pack.Writer, path string) error {
+	indexFile, err := os.CreateTemp(filepath.Dir(path), "tmp_idx_")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(indexFile.Name())
+	defer indexFile.Close()
+
+	output := bufio.NewWriter(indexFile)
+	if err := writer.WriteIndex(output); err != nil {
+		return err
+	}
+	if err := output.Flush(); err != nil {
+		return err
+	}
+	if err := indexFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(indexFile.Name(), path)
+}
@@ -0,0 +1,136 @@
+package command
+
+import (
+	"building-git/lib/repository"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepack(t *testing.T) {
+	lines := func(n int) string {
+		var b strings.Builder
+		for i := 1; i <= 40; i++ {
+			if i == n {
+				b.WriteString("changed line\n")
+			} else {
+				b.WriteString("this is line number " + strings.Repeat("x", i) + "\n")
+			}
+		}
+		return b.String()
+	}
+
+	setup := func() (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+
+		now := time.Now()
+		for i := 1; i <= 5; i++ {
+			commitTree(t, tmpDir, "commit", map[string]string{
+				"a/b/file.txt": lines(i),
+				"README":       lines(0),
+			}, now.Add(time.Duration(i)*time.Second))
+		}
+		return
+	}
+
+	looseObjects := func(t *testing.T, tmpDir string) []string {
+		t.Helper()
+		paths, _ := filepath.Glob(filepath.Join(tmpDir, ".git", "objects", "??", "*"))
+		return paths
+	}
+
+	packFiles := func(t *testing.T, tmpDir string) []string {
+		t.Helper()
+		paths, _ := filepath.Glob(filepath.Join(tmpDir, ".git", "objects", "pack", "*"))
+		return paths
+	}
+
+	t.Run("writes a pack and index using deltas", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		repack, _ := NewRepack(tmpDir, []string{}, RepackOption{}, stdout, stderr)
+		status := repack.Run()
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected := "Total 26 (delta 5)\n"
+		if stderr.String() != expected {
+			t.Errorf("want %q, but got %q", expected, stderr.String())
+		}
+		if len(packFiles(t, tmpDir)) != 2 {
+			t.Errorf("want %d, but got %d", 2, len(packFiles(t, tmpDir)))
+		}
+		if len(looseObjects(t, tmpDir)) != 26 {
+			t.Errorf("want %d, but got %d", 26, len(looseObjects(t, tmpDir)))
+		}
+	})
+
+	t.Run("removes loose objects when deleting", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		repack, _ := NewRepack(tmpDir, []string{}, RepackOption{Delete: true}, stdout, stderr)
+		repack.Run()
+
+		if len(looseObjects(t, tmpDir)) != 0 {
+			t.Errorf("want %d, but got %d", 0, len(looseObjects(t, tmpDir)))
+		}
+	})
+
+	t.Run("reads every object back from the pack", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		repack, _ := NewRepack(tmpDir, []string{}, RepackOption{Delete: true}, stdout, stderr)
+		repack.Run()
+
+		r := repo(t, tmpDir)
+		revList, _ := repository.NewRevList(r, []string{}, repository.RevListOption{Objects: true})
+		objects := revList.Each()
+
+		if len(objects) != 26 {
+			t.Errorf("want %d, but got %d", 26, len(objects))
+		}
+		for _, object := range objects {
+			loaded, err := r.Database.Load(object.Oid())
+			if err != nil {
+				t.Fatal(err)
+			}
+			oid, _ := r.Database.HashObject(loaded)
+			if oid != object.Oid() {
+				t.Errorf("want %q, but got %q", object.Oid(), oid)
+			}
+		}
+
+		assertWorkspace(t, tmpDir, map[string]string{
+			"a/b/file.txt": lines(5),
+			"README":       lines(0),
+		})
+	})
+
+	t.Run("replaces redundant packs", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		repack, _ := NewRepack(tmpDir, []string{}, RepackOption{Delete: true}, stdout, stderr)
+		repack.Run()
+		commitTree(t, tmpDir, "commit", map[string]string{
+			"a/b/file.txt": lines(6),
+		}, time.Now().Add(time.Minute))
+
+		repack, _ = NewRepack(tmpDir, []string{}, RepackOption{Delete: true}, stdout, stderr)
+		repack.Run()
+
+		if len(packFiles(t, tmpDir)) != 2 {
+			t.Errorf("want %d, but got %d", 2, len(packFiles(t, tmpDir)))
+		}
+		if len(looseObjects(t, tmpDir)) != 0 {
+			t.Errorf("want %d, but got %d", 0, len(looseObjects(t, tmpDir)))
+		}
+	})
+}
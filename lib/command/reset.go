@@ -2,6 +2,7 @@ package command
 
 import (
 	"building-git/lib/repository"
+	"fmt"
 	"io"
 	"path/filepath"
 )
@@ -24,6 +25,7 @@ type Reset struct {
 	options   ResetOption
 	repo      *repository.Repository
 	commitOid string
+	revision  string
 	stdout    io.Writer
 	stderr    io.Writer
 }
@@ -53,7 +55,7 @@ func (r *Reset) Run() int {
 	r.repo.Index.WriteUpdates()
 
 	if len(r.args) == 0 {
-		headOid, _ := r.repo.Refs.UpdateHead(r.commitOid)
+		headOid, _ := r.repo.Refs.UpdateHead(r.commitOid, fmt.Sprintf("reset: moving to %s", r.revision))
 		r.repo.Refs.UpateRef(repository.ORIG_HEAD, headOid)
 	}
 
@@ -71,12 +73,14 @@ func (r *Reset) selectCommitOid() {
 	if err != nil {
 		headOid, _ := r.repo.Refs.ReadHead()
 		r.commitOid = headOid
+		r.revision = repository.HEAD
 		return
 	}
 	if len(r.args) > 0 {
 		r.args = r.args[1:]
 	}
 	r.commitOid = oid
+	r.revision = revision
 }
 
 func (r *Reset) resetFiles() {
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"building-git/lib/database"
+	"building-git/lib/repository"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+type RevertOption = SequencingOption
+
+type Revert struct {
+	*sequencing
+	args []string
+}
+
+func NewRevert(dir string, args []string, options RevertOption, stdout, stderr io.Writer) (*Revert, error) {
+	rootPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(rootPath)
+	return &Revert{
+		sequencing: newSequencing(rootPath, repo, options, stdout, stderr),
+		args:       args,
+	}, nil
+}
+
+func (r *Revert) Run() int {
+	return r.run(r.storeCommitSequence)
+}
+
+func (r *Revert) storeCommitSequence() error {
+	walk := false
+	commits, _ := repository.NewRevList(r.repo, r.args, repository.RevListOption{Walk: &walk})
+	for _, object := range commits.Each() {
+		commit := object.(*database.Commit)
+		if commit.IsMerge() {
+			return fmt.Errorf("commit %s is a merge but no -m option was given.", commit.Oid())
+		}
+		r.sequencer.Revert(commit)
+	}
+	return nil
+}
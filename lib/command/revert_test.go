@@ -0,0 +1,225 @@
+package command
+
+import (
+	"building-git/lib/command/write_commit"
+	"building-git/lib/database"
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func setUpForTestRevertWithChainOfCommits(t *testing.T) (tmpDir string, stdout, stderr *bytes.Buffer) {
+	tmpDir, stdout, stderr = setupTestEnvironment(t)
+
+	for _, message := range []string{"one", "two", "three", "four"} {
+		commitTree(t, tmpDir, message, map[string]string{
+			"f.txt": message,
+		}, getTime())
+	}
+	commitTree(t, tmpDir, "five", map[string]string{
+		"g.txt": "five",
+	}, getTime())
+	commitTree(t, tmpDir, "six", map[string]string{
+		"f.txt": "six",
+	}, getTime())
+	commitTree(t, tmpDir, "seven", map[string]string{
+		"g.txt": "seven",
+	}, getTime())
+	commitTree(t, tmpDir, "eight", map[string]string{
+		"g.txt": "eight",
+	}, getTime())
+
+	return
+}
+
+func titleLines(t *testing.T, tmpDir string, expressions ...string) []string {
+	t.Helper()
+
+	titles := []string{}
+	for _, expression := range expressions {
+		obj, _ := loadCommit(t, tmpDir, expression)
+		titles = append(titles, obj.(*database.Commit).TitleLine())
+	}
+	return titles
+}
+
+func TestRevertWithChainOfCommits(t *testing.T) {
+	t.Run("reverts a commit on top of the current HEAD", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestRevertWithChainOfCommits(t)
+		defer os.RemoveAll(tmpDir)
+
+		reverted, _ := resolveRevision(t, tmpDir, "@~2")
+		status := revert(t, tmpDir, stdout, stderr, []string{"@~2"}, RevertOption{})
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+
+		obj, _ := loadCommit(t, tmpDir, "@")
+		commit := obj.(*database.Commit)
+		expected := fmt.Sprintf("Revert \"six\"\n\nThis reverts commit %s.\n", reverted)
+		if commit.Message() != expected {
+			t.Errorf("want %q, but got %q", expected, commit.Message())
+		}
+		if got := titleLines(t, tmpDir, "@^"); !reflect.DeepEqual(got, []string{"eight"}) {
+			t.Errorf("want %v, but got %v", []string{"eight"}, got)
+		}
+
+		assertIndexEntries(t, tmpDir, map[string]string{
+			"f.txt": "four",
+			"g.txt": "eight",
+		})
+		assertWorkspace(t, tmpDir, map[string]string{
+			"f.txt": "four",
+			"g.txt": "eight",
+		})
+	})
+
+	t.Run("fails to revert a content conflict", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestRevertWithChainOfCommits(t)
+		defer os.RemoveAll(tmpDir)
+
+		short := repo(t, tmpDir).Database.ShortOid(mustResolve(t, tmpDir, "@~4"))
+		status := revert(t, tmpDir, stdout, stderr, []string{"@~4"}, RevertOption{})
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+
+		expected := fmt.Sprintf("error: could not apply parent of %s... four\n", short)
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("want %q in %q", expected, stdout.String())
+		}
+		assertGitStatus(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), "UU f.txt\n")
+	})
+
+	t.Run("fails to revert a modify/delete conflict", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestRevertWithChainOfCommits(t)
+		defer os.RemoveAll(tmpDir)
+
+		status := revert(t, tmpDir, stdout, stderr, []string{"@~3"}, RevertOption{})
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		assertGitStatus(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), "UD g.txt\n")
+	})
+
+	t.Run("reverts multiple commits in the given order", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestRevertWithChainOfCommits(t)
+		defer os.RemoveAll(tmpDir)
+
+		status := revert(t, tmpDir, stdout, stderr, []string{"@", "@^", "@~2"}, RevertOption{})
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+
+		expected := []string{`Revert "six"`, `Revert "seven"`, `Revert "eight"`, "eight"}
+		if got := titleLines(t, tmpDir, "@", "@^", "@~2", "@~3"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("want %v, but got %v", expected, got)
+		}
+		assertWorkspace(t, tmpDir, map[string]string{
+			"f.txt": "four",
+			"g.txt": "five",
+		})
+	})
+
+	t.Run("records revert actions in the todo file", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestRevertWithChainOfCommits(t)
+		defer os.RemoveAll(tmpDir)
+
+		db := repo(t, tmpDir).Database
+		four, three := db.ShortOid(mustResolve(t, tmpDir, "@~4")), db.ShortOid(mustResolve(t, tmpDir, "@~5"))
+		revert(t, tmpDir, stdout, stderr, []string{"@~4", "@~5"}, RevertOption{})
+
+		todo, _ := os.ReadFile(tmpDir + "/.git/sequencer/todo")
+		expected := fmt.Sprintf("revert %s four\nrevert %s three\n", four, three)
+		if string(todo) != expected {
+			t.Errorf("want %q, but got %q", expected, string(todo))
+		}
+	})
+
+	t.Run("continues a conflicted revert", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUpForTestRevertWithChainOfCommits(t)
+		defer os.RemoveAll(tmpDir)
+
+		reverted := mustResolve(t, tmpDir, "@~4")
+		revert(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), []string{"@~4", "@"}, RevertOption{})
+		writeFile(t, tmpDir, "f.txt", "three")
+		Add(tmpDir, []string{"f.txt"}, new(bytes.Buffer), new(bytes.Buffer))
+
+		status := revert(t, tmpDir, stdout, stderr, []string{}, RevertOption{Mode: Continue})
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+
+		obj, _ := loadCommit(t, tmpDir, "@^")
+		expected := fmt.Sprintf("Revert \"four\"\n\nThis reverts commit %s.\n", reverted)
+		if got := obj.(*database.Commit).Message(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		if got := titleLines(t, tmpDir, "@", "@~2"); !reflect.DeepEqual(got, []string{`Revert "eight"`, "eight"}) {
+			t.Errorf("want %v, but got %v", []string{`Revert "eight"`, "eight"}, got)
+		}
+		assertWorkspace(t, tmpDir, map[string]string{
+			"f.txt": "three",
+			"g.txt": "seven",
+		})
+	})
+}
+
+func TestRevertAbortingInConflictedState(t *testing.T) {
+	tmpDir, stdout, stderr := setUpForTestRevertWithChainOfCommits(t)
+	defer os.RemoveAll(tmpDir)
+
+	revert(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), []string{"@~4"}, RevertOption{})
+	status := revert(t, tmpDir, stdout, stderr, []string{}, RevertOption{Mode: Abort})
+
+	if status != 0 {
+		t.Errorf("want %d, but got %d", 0, status)
+	}
+	if got := titleLines(t, tmpDir, "@"); !reflect.DeepEqual(got, []string{"eight"}) {
+		t.Errorf("want %v, but got %v", []string{"eight"}, got)
+	}
+	assertGitStatus(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), "")
+	if repo(t, tmpDir).PendingCommit.InProgress() {
+		t.Errorf("want %v, but got %v", false, true)
+	}
+}
+
+func TestRevertMergeCommit(t *testing.T) {
+	tmpDir, stdout, stderr := setUpForTestRevertWithChainOfCommits(t)
+	defer os.RemoveAll(tmpDir)
+
+	branch, _ := NewBranch(tmpDir, []string{"topic", "@^"}, BranchOption{}, new(bytes.Buffer), new(bytes.Buffer))
+	branch.Run()
+	checkout(tmpDir, new(bytes.Buffer), new(bytes.Buffer), "topic")
+	commitTree(t, tmpDir, "nine", map[string]string{"h.txt": "nine"}, getTime())
+	checkout(tmpDir, new(bytes.Buffer), new(bytes.Buffer), "master")
+	mergeCommit(t, tmpDir, "topic", MergeOption{ReadOption: write_commit.ReadOption{Message: "merge topic"}}, new(bytes.Buffer), new(bytes.Buffer))
+
+	head := mustResolve(t, tmpDir, "@")
+	status := revert(t, tmpDir, stdout, stderr, []string{"@"}, RevertOption{})
+
+	if status != 1 {
+		t.Errorf("want %d, but got %d", 1, status)
+	}
+	expected := fmt.Sprintf("error: commit %s is a merge but no -m option was given.\n", head)
+	if got := stderr.String(); got != expected {
+		t.Errorf("want %q, but got %q", expected, got)
+	}
+	if got := mustResolve(t, tmpDir, "@"); got != head {
+		t.Errorf("want %q, but got %q", head, got)
+	}
+	assertGitStatus(t, tmpDir, new(bytes.Buffer), new(bytes.Buffer), "")
+}
+
+func mustResolve(t *testing.T, tmpDir, expression string) string {
+	t.Helper()
+
+	oid, err := resolveRevision(t, tmpDir, expression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return oid
+}
@@ -0,0 +1,509 @@
+package command
+
+import (
+	"building-git/lib/command/write_commit"
+	"building-git/lib/database"
+	"building-git/lib/editor"
+	"building-git/lib/merge"
+	"building-git/lib/repository"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// SEQUENCER_ACTION records the action of a command that stopped on a
+// conflict, so that --continue can finish it once the conflict is resolved.
+const SEQUENCER_ACTION = "action"
+
+type SequencingOption struct {
+	Mode      MergeMode
+	EditorCmd func(path string) editor.Executable
+	IsTTY     bool
+}
+
+type sequencerStop struct {
+	message string
+}
+
+func (e *sequencerStop) Error() string {
+	return e.message
+}
+
+type sequencing struct {
+	rootPath    string
+	repo        *repository.Repository
+	options     SequencingOption
+	writeCommit *write_commit.WriteCommit
+	sequencer   *repository.Sequencer
+	pickAction  string
+	dropEmpty   bool
+	stdout      io.Writer
+	stderr      io.Writer
+}
+
+func newSequencing(rootPath string, repo *repository.Repository, options SequencingOption, stdout, stderr io.Writer) *sequencing {
+	return &sequencing{
+		rootPath:    rootPath,
+		repo:        repo,
+		options:     options,
+		writeCommit: write_commit.NewWriteCommit(repo, options.EditorCmd),
+		sequencer:   repository.NewSequencer(repo),
+		pickAction:  "cherry-pick",
+		stdout:      stdout,
+		stderr:      stderr,
+	}
+}
+
+func (s *sequencing) run(storeCommitSequence func() error) int {
+	switch s.options.Mode {
+	case Continue:
+		err := s.handleContinue()
+		if stop, ok := err.(*sequencerStop); ok {
+			fmt.Fprint(s.stdout, stop.message)
+			return 0
+		}
+		if err != nil {
+			if _, ok := err.(*repository.PendingCommitError); ok {
+				fmt.Fprintf(s.stderr, "fatal: %v", err)
+			} else {
+				fmt.Fprint(s.stderr, err)
+			}
+			return 128
+		}
+		return 0
+	case Abort:
+		s.handleAbort()
+		return 0
+	case Quit:
+		s.handleQuit()
+		return 0
+	case Skip:
+		return s.exitStatus(s.handleSkip())
+	}
+
+	s.sequencer.Start()
+	if err := storeCommitSequence(); err != nil {
+		s.sequencer.Quit()
+		fmt.Fprintf(s.stderr, "error: %v\n", err)
+		return 1
+	}
+	return s.exitStatus(s.resumeSequencer())
+}
+
+func (s *sequencing) exitStatus(err error) int {
+	if stop, ok := err.(*sequencerStop); ok {
+		fmt.Fprint(s.stdout, stop.message)
+		return 0
+	}
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+func (s *sequencing) pick(commit *database.Commit) error {
+	inputs := s.pickMergeInputs(commit)
+	err := s.resolveMerge(inputs)
+	if err != nil {
+		return err
+	}
+
+	if s.repo.Index.IsConflict() {
+		s.failOnConflict(inputs, commit.Message(), repository.CherryPick)
+		return fmt.Errorf("detect conflict")
+	}
+
+	tree := s.writeCommit.WriteTree()
+	if s.dropEmpty && s.isUnchanged(inputs.LeftOid(), tree.Oid()) {
+		return nil
+	}
+
+	picked := database.NewCommit(
+		[]string{inputs.LeftOid()},
+		tree.Oid(),
+		commit.Author(),
+		s.writeCommit.CurrentAuthor(time.Now()),
+		commit.Message(),
+	)
+	s.finishCommit(picked, s.pickAction)
+	return nil
+}
+
+func (s *sequencing) reword(commit *database.Commit) error {
+	before, _ := s.repo.Refs.ReadHead()
+	if err := s.pick(commit); err != nil {
+		return err
+	}
+	if head := s.loadHead(); head.Oid() == before {
+		return nil
+	}
+	s.rewordHead()
+	return nil
+}
+
+func (s *sequencing) rewordHead() {
+	head := s.loadHead()
+	message := s.editMessage(func(e *editor.Editor) {
+		e.Puts(head.Message())
+		e.Puts("")
+		e.Note(write_commit.COMMIT_NOTES)
+	})
+	s.amendHead(head, head.Tree(), message, repository.REWORD)
+}
+
+func (s *sequencing) edit(commit *database.Commit) error {
+	if err := s.pick(commit); err != nil {
+		return err
+	}
+	return s.stopToEdit(commit)
+}
+
+func (s *sequencing) stopToEdit(commit *database.Commit) error {
+	s.sequencer.Pause()
+
+	short := s.repo.Database.ShortOid(commit.Oid())
+	return &sequencerStop{fmt.Sprintf(`Stopped at %s... %s
+You can amend the commit now, with
+
+  jit commit --amend
+
+Once you are satisfied with your changes, run
+
+  jit rebase --continue
+`, short, commit.TitleLine())}
+}
+
+func (s *sequencing) squash(commit *database.Commit, action string) error {
+	inputs := s.pickMergeInputs(commit)
+	err := s.resolveMerge(inputs)
+	if err != nil {
+		return err
+	}
+
+	if s.repo.Index.IsConflict() {
+		s.failOnConflict(inputs, commit.Message(), repository.CherryPick)
+		return fmt.Errorf("detect conflict")
+	}
+
+	s.squashHead(commit, action)
+	return nil
+}
+
+func (s *sequencing) squashHead(commit *database.Commit, action string) {
+	head := s.loadHead()
+	message := head.Message()
+	if action == repository.SQUASH {
+		message = s.editMessage(func(e *editor.Editor) {
+			e.Note("This is a combination of 2 commits.")
+			e.Note("This is the 1st commit message:")
+			e.Puts("")
+			e.Puts(head.Message())
+			e.Note("This is the commit message #2:")
+			e.Puts("")
+			e.Puts(commit.Message())
+		})
+	}
+	s.amendHead(head, s.writeCommit.WriteTree().Oid(), message, action)
+}
+
+func (s *sequencing) exec(command string) error {
+	fmt.Fprintf(s.stdout, "Executing: %s\n", command)
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = s.rootPath
+	cmd.Stdout = s.stdout
+	cmd.Stderr = s.stderr
+	if err := cmd.Run(); err != nil {
+		s.sequencer.Pause()
+		fmt.Fprintf(s.stderr, `warning: execution failed: %s
+You can fix the problem, and then run
+
+  jit rebase --continue
+
+`, command)
+		return err
+	}
+	return nil
+}
+
+func (s *sequencing) editMessage(fn func(e *editor.Editor)) string {
+	path := s.writeCommit.CommitMessagePath()
+	return editor.EditFile(path, s.options.EditorCmd(path), s.options.IsTTY, fn)
+}
+
+func (s *sequencing) amendHead(head *database.Commit, tree, message, action string) {
+	commit := database.NewCommit(
+		head.Parents,
+		tree,
+		head.Author(),
+		s.writeCommit.CurrentAuthor(time.Now()),
+		message,
+	)
+	s.finishCommit(commit, fmt.Sprintf("rebase (%s)", action))
+}
+
+func (s *sequencing) loadHead() *database.Commit {
+	headOid, _ := s.repo.Refs.ReadHead()
+	object, _ := s.repo.Database.Load(headOid)
+	return object.(*database.Commit)
+}
+
+func (s *sequencing) isUnchanged(parentOid, treeOid string) bool {
+	parent, err := s.repo.Database.Load(parentOid)
+	if err != nil {
+		return false
+	}
+	return parent.(*database.Commit).Tree() == treeOid
+}
+
+func (s *sequencing) revert(commit *database.Commit) error {
+	inputs := s.revertMergeInputs(commit)
+	message := revertCommitMessage(commit)
+	err := s.resolveMerge(inputs)
+	if err != nil {
+		return err
+	}
+
+	if s.repo.Index.IsConflict() {
+		s.failOnConflict(inputs, message, repository.Revert)
+		return fmt.Errorf("detect conflict")
+	}
+
+	message = s.editRevertMessage(message)
+	author := s.writeCommit.CurrentAuthor(time.Now())
+	reverted := database.NewCommit(
+		[]string{inputs.LeftOid()},
+		s.writeCommit.WriteTree().Oid(),
+		author,
+		author,
+		message,
+	)
+	s.finishCommit(reverted, "revert")
+	return nil
+}
+
+func (s *sequencing) pickMergeInputs(commit *database.Commit) merge.ResolveInputs {
+	short := s.repo.Database.ShortOid(commit.Oid())
+	leftName := repository.HEAD
+	leftOid, _ := s.repo.Refs.ReadHead()
+	rightName := fmt.Sprintf("%s... %s", short, commit.TitleLine())
+	rightOid := commit.Oid()
+
+	return merge.NewCherryPick(
+		s.repo,
+		leftName, rightName,
+		leftOid, rightOid,
+		[]string{commit.Parent()},
+	)
+}
+
+func (s *sequencing) revertMergeInputs(commit *database.Commit) merge.ResolveInputs {
+	short := s.repo.Database.ShortOid(commit.Oid())
+	leftName := repository.HEAD
+	leftOid, _ := s.repo.Refs.ReadHead()
+	rightName := fmt.Sprintf("parent of %s... %s", short, commit.TitleLine())
+	rightOid := commit.Parent()
+
+	return merge.NewCherryPick(
+		s.repo,
+		leftName, rightName,
+		leftOid, rightOid,
+		[]string{commit.Oid()},
+	)
+}
+
+func revertCommitMessage(commit *database.Commit) string {
+	return fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %s.\n", commit.TitleLine(), commit.Oid())
+}
+
+func (s *sequencing) editRevertMessage(message string) string {
+	return s.editMessage(func(e *editor.Editor) {
+		e.Puts(message)
+		e.Puts("")
+		e.Note(write_commit.COMMIT_NOTES)
+	})
+}
+
+func (s *sequencing) resolveMerge(inputs merge.ResolveInputs) error {
+	s.repo.Index.LoadForUpdate()
+	resolve := merge.NewResolve(s.repo, inputs, func(fn func() string) {
+		info := fn()
+		fmt.Fprintf(s.stdout, info+"\n")
+	})
+	err := resolve.Execute()
+	if err != nil {
+		return err
+	}
+	s.repo.Index.WriteUpdates()
+	return nil
+}
+
+func (s *sequencing) failOnConflict(inputs merge.ResolveInputs, message string, mtype repository.MergeType) {
+	s.sequencer.Dump()
+	pendingCommit := s.writeCommit.PendingCommit()
+	pendingCommit.Start(inputs.RightOid(), mtype)
+
+	path := pendingCommit.MessagePath
+	editor.EditFile(path, s.options.EditorCmd(path), s.options.IsTTY, func(e *editor.Editor) {
+		e.Puts(message)
+		e.Puts("")
+		e.Note("Conflicts:")
+		for name := range s.repo.Index.ConflictPaths() {
+			e.Note("\t" + name)
+		}
+		e.Close()
+	})
+
+	fmt.Fprintf(s.stdout, "error: could not apply %s\n", inputs.RightName())
+	for _, line := range strings.Split(write_commit.CONFLICT_NOTES, "\n") {
+		fmt.Fprintf(s.stdout, "hint: %s\n", line)
+	}
+}
+
+func (s *sequencing) finishCommit(commit *database.Commit, action string) {
+	s.repo.Database.Store(commit)
+	s.repo.Refs.UpdateHead(commit.Oid(), action+": "+commit.TitleLine())
+	s.writeCommit.PrintCommit(commit, s.stdout)
+}
+
+func (s *sequencing) handleContinue() error {
+	s.repo.Index.Load()
+	pendingCommit := s.writeCommit.PendingCommit()
+	if pendingCommit.InProgress() {
+		if err := s.resumeConflict(s.sequencer.ReadState(SEQUENCER_ACTION)); err != nil {
+			return err
+		}
+	}
+	s.sequencer.Load()
+	s.sequencer.DropCommand()
+	return s.resumeSequencer()
+}
+
+// resumeConflict commits the resolution of the command that stopped on a
+// conflict and then finishes that command. Squashes and fixups fold the
+// resolution into HEAD, while rewords and edits go on to their editor or
+// pause once the picked commit is made.
+func (s *sequencing) resumeConflict(action string) error {
+	pendingCommit := s.writeCommit.PendingCommit()
+	mtype := pendingCommit.MergeType()
+
+	var commit *database.Commit
+	if mtype == repository.CherryPick {
+		oid, _ := pendingCommit.MergeOID(repository.CherryPick)
+		if object, err := s.repo.Database.Load(oid); err == nil {
+			commit, _ = object.(*database.Commit)
+		}
+	}
+	if commit == nil {
+		return s.writeCommit.ResumeMerge(mtype, s.options.IsTTY)
+	}
+
+	switch action {
+	case repository.SQUASH, repository.FIXUP:
+		if err := s.writeCommit.HandleConflictedIndex(); err != nil {
+			return err
+		}
+		pendingCommit.Clear(repository.CherryPick)
+		s.squashHead(commit, action)
+		return nil
+	case repository.REWORD:
+		if err := s.writeCommit.ResumeMerge(mtype, false); err != nil {
+			return err
+		}
+		s.rewordHead()
+		return nil
+	}
+
+	if err := s.writeCommit.ResumeMerge(mtype, s.options.IsTTY); err != nil {
+		return err
+	}
+	if action == repository.EDIT {
+		return s.stopToEdit(commit)
+	}
+	return nil
+}
+
+func (s *sequencing) resumeSequencer() error {
+	for {
+		command := s.sequencer.NextCommand()
+		if command == nil {
+			break
+		}
+
+		var err error
+		switch command.Action {
+		case repository.PICK:
+			err = s.pick(command.Commit)
+		case repository.REVERT:
+			err = s.revert(command.Commit)
+		case repository.REWORD:
+			err = s.reword(command.Commit)
+		case repository.EDIT:
+			err = s.edit(command.Commit)
+		case repository.SQUASH, repository.FIXUP:
+			err = s.squash(command.Commit, command.Action)
+		case repository.EXEC:
+			err = s.exec(command.Exec)
+		case repository.BREAK:
+			s.sequencer.Pause()
+			err = &sequencerStop{"Stopped at HEAD\n"}
+		}
+		if err != nil {
+			if s.writeCommit.PendingCommit().InProgress() {
+				s.sequencer.WriteState(SEQUENCER_ACTION, command.Action)
+			}
+			return err
+		}
+		s.sequencer.DropCommand()
+	}
+	s.sequencer.Quit()
+	return nil
+}
+
+func (s *sequencing) handleSkip() error {
+	pendingCommit := s.writeCommit.PendingCommit()
+	if pendingCommit.InProgress() {
+		pendingCommit.Clear(pendingCommit.MergeType())
+	}
+
+	s.repo.Index.LoadForUpdate()
+	headOid, _ := s.repo.Refs.ReadHead()
+	s.repo.HardReset(headOid)
+	s.repo.Index.WriteUpdates()
+
+	s.sequencer.Load()
+	s.sequencer.DropCommand()
+	return s.resumeSequencer()
+}
+
+func (s *sequencing) handleAbort() {
+	pendingCommit := s.writeCommit.PendingCommit()
+	if pendingCommit.InProgress() {
+		err := s.writeCommit.PendingCommit().Clear(pendingCommit.MergeType())
+		if err != nil {
+			return
+		}
+	}
+	s.repo.Index.LoadForUpdate()
+
+	err := s.sequencer.Abort()
+	if err != nil {
+		fmt.Fprintf(s.stderr, "warning: %s\n", err.Error())
+	}
+
+	s.repo.Index.WriteUpdates()
+}
+
+func (s *sequencing) handleQuit() error {
+	pendingCommit := s.writeCommit.PendingCommit()
+	if pendingCommit.InProgress() {
+		err := s.repo.PendingCommit.Clear(pendingCommit.MergeType())
+		if err != nil {
+			return err
+		}
+	}
+	s.sequencer.Quit()
+	return nil
+}
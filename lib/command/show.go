@@ -0,0 +1,172 @@
+package command
+
+import (
+	"building-git/lib/command/print_diff"
+	"building-git/lib/database"
+	"building-git/lib/diff"
+	"building-git/lib/repository"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+type ShowOption struct {
+	Abbrev    bool
+	Format    string
+	Date      string
+	Decorate  string
+	IsTty     bool
+	Patch     bool
+	Stat      print_diff.StatOption
+	Algorithm diff.Algorithm
+}
+
+type Show struct {
+	rootPath string
+	args     []string
+	options  ShowOption
+	repo     *repository.Repository
+	stdout   io.Writer
+	stderr   io.Writer
+	log      *Log
+	shown    map[string]bool
+	printed  bool
+}
+
+func NewShow(dir string, args []string, options ShowOption, stdout, stderr io.Writer) (*Show, error) {
+	rootPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(rootPath)
+
+	log, err := NewLog(dir, []string{}, LogOption{
+		Abbrev:    options.Abbrev,
+		Format:    options.Format,
+		Date:      options.Date,
+		Decorate:  options.Decorate,
+		IsTty:     options.IsTty,
+		Patch:     options.Patch,
+		Stat:      options.Stat,
+		Combined:  true,
+		Algorithm: options.Algorithm,
+	}, stdout, stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Show{
+		rootPath: rootPath,
+		args:     args,
+		options:  options,
+		repo:     repo,
+		stdout:   stdout,
+		stderr:   stderr,
+		log:      log,
+		shown:    map[string]bool{},
+	}, nil
+}
+
+func (s *Show) Run() int {
+	s.log.loadRefs()
+
+	revs := s.args
+	if len(revs) == 0 {
+		revs = []string{repository.HEAD}
+	}
+
+	for _, rev := range revs {
+		revision := repository.NewRevision(s.repo, rev)
+		oid, err := revision.Resolve("")
+		if err != nil {
+			for _, he := range revision.Errors {
+				fmt.Fprintf(s.stderr, "error: %v\n", he.Message)
+				for _, line := range he.Hint {
+					fmt.Fprintf(s.stderr, "hint: %v\n", line)
+				}
+			}
+			fmt.Fprintf(s.stderr, "fatal: %v\n", err)
+			return 128
+		}
+
+		object, err := s.repo.Database.Load(oid)
+		if err != nil {
+			fmt.Fprintf(s.stderr, "fatal: %v\n", err)
+			return 128
+		}
+		s.showObject(rev, object)
+	}
+
+	return 0
+}
+
+// showObject prints each kind of object the way git show does. Commits are
+// set apart from the commits shown before them by the log format, while tags
+// and trees get a blank line after any earlier output.
+func (s *Show) showObject(name string, object database.GitObject) {
+	switch object := object.(type) {
+	case *database.Commit:
+		s.showCommit(len(s.shown) > 0, object)
+	case *database.Tag:
+		s.showTag(object)
+	case *database.Tree:
+		s.showTree(name, object)
+	case *database.Blob:
+		fmt.Fprint(s.stdout, object.String())
+	}
+	s.printed = true
+}
+
+func (s *Show) showCommit(blankLine bool, commit *database.Commit) {
+	if s.shown[commit.Oid()] {
+		return
+	}
+	s.log.showCommit(blankLine, commit)
+	s.shown[commit.Oid()] = true
+}
+
+func (s *Show) showTag(tag *database.Tag) {
+	s.separator()
+	fmt.Fprintf(s.stdout, "tag %s\n", tag.Name())
+	if tagger := tag.Tagger(); tagger != nil && s.options.Format != "oneline" {
+		fmt.Fprintf(s.stdout, "Tagger: %s <%s>\n", tagger.Name, tagger.Email)
+		fmt.Fprintf(s.stdout, "Date:  %s\n", tagger.FormatTime(s.options.Date))
+	}
+	fmt.Fprintf(s.stdout, "\n%s", tag.Message())
+	s.printed = true
+
+	target, err := s.repo.Database.Load(tag.Object())
+	if err != nil {
+		return
+	}
+	if commit, ok := target.(*database.Commit); ok {
+		s.showCommit(true, commit)
+		return
+	}
+	s.showObject(tag.Object(), target)
+}
+
+func (s *Show) showTree(name string, tree *database.Tree) {
+	s.separator()
+	fmt.Fprintf(s.stdout, "tree %s\n\n", name)
+
+	names := []string{}
+	for name := range tree.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if tree.Entries[name].Mode() == database.TREE_MODE {
+			name += "/"
+		}
+		fmt.Fprintf(s.stdout, "%s\n", name)
+	}
+}
+
+func (s *Show) separator() {
+	if s.printed {
+		fmt.Fprintf(s.stdout, "\n")
+	}
+}
@@ -0,0 +1,203 @@
+package command
+
+import (
+	"building-git/lib/command/write_commit"
+	"building-git/lib/database"
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestShow(t *testing.T) {
+	//   A   C   J
+	//   o---o---o [master]
+	//    \     /
+	//     o---o [topic]
+	//         B
+
+	var setUp = func(t *testing.T) (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+
+		commitTree(t, tmpDir, "A", map[string]string{
+			"f.txt":       "1\n2\n3\n4\n5\n6\n",
+			"lib/g.txt":   "g\n",
+			"lib/x/h.txt": "h\n",
+		}, time.Now())
+
+		brunchCmd, _ := NewBranch(tmpDir, []string{"topic"}, BranchOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		brunchCmd.Run()
+		checkout(tmpDir, new(bytes.Buffer), new(bytes.Buffer), "topic")
+		commitTree(t, tmpDir, "B", map[string]string{"f.txt": "1\n2\n3\n4\n5\ntopic\n"}, time.Now())
+
+		checkout(tmpDir, new(bytes.Buffer), new(bytes.Buffer), "master")
+		commitTree(t, tmpDir, "C", map[string]string{"f.txt": "master\n2\n3\n4\n5\n6\n"}, time.Now())
+		mergeCommit(t, tmpDir, "topic", MergeOption{ReadOption: write_commit.ReadOption{Message: "J"}}, new(bytes.Buffer), new(bytes.Buffer))
+
+		return
+	}
+
+	var show = func(tmpDir string, args []string, options ShowOption, stdout, stderr *bytes.Buffer) int {
+		options.Decorate = "no"
+		cmd, _ := NewShow(tmpDir, args, options, stdout, stderr)
+		return cmd.Run()
+	}
+
+	t.Run("shows the current commit with its combined diff", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		show(tmpDir, []string{}, ShowOption{Format: "oneline", Patch: true}, stdout, stderr)
+
+		head, _ := resolveRevision(t, tmpDir, "HEAD")
+		expected := fmt.Sprintf(`%s J
+diff --cc f.txt
+index 6f795df,aa64649..bdf9113
+--- a/f.txt
++++ b/f.txt
+@@@ -1,6 -1,6 +1,6 @@@
+ -1
+ +master
+  2
+  3
+  4
+  5
+- 6
++ topic
+`, head)
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("shows commits with their patches", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		show(tmpDir, []string{"topic", "master^"}, ShowOption{Format: "%s", Patch: true}, stdout, stderr)
+
+		expected := `B
+
+diff --git a/f.txt b/f.txt
+index b414108..aa64649 100644
+--- a/f.txt
++++ b/f.txt
+@@ -3,4 +3,4 @@
+ 3
+ 4
+ 5
+-6
++topic
+C
+
+diff --git a/f.txt b/f.txt
+index b414108..6f795df 100644
+--- a/f.txt
++++ b/f.txt
+@@ -1,4 +1,4 @@
+-1
++master
+ 2
+ 3
+ 4
+`
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("shows a tree as a listing of its entries", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		show(tmpDir, []string{"master:", "master~2:lib"}, ShowOption{}, stdout, stderr)
+
+		expected := `tree master:
+
+f.txt
+lib/
+
+tree master~2:lib
+
+g.txt
+x/
+`
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("shows the contents of a file at a revision", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		show(tmpDir, []string{"topic:f.txt", "master^:lib/x/h.txt"}, ShowOption{}, stdout, stderr)
+
+		expected := "1\n2\n3\n4\n5\ntopic\nh\n"
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("shows an annotated tag and the commit it points at", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		os.Setenv("GIT_AUTHOR_NAME", "A. U. Thor")
+		os.Setenv("GIT_AUTHOR_EMAIL", "author@example.com")
+		defer os.Unsetenv("GIT_AUTHOR_NAME")
+		defer os.Unsetenv("GIT_AUTHOR_EMAIL")
+
+		options := TagOption{Annotate: true, ReadOption: write_commit.ReadOption{Message: "first release"}}
+		tag, _ := NewTag(tmpDir, []string{"v1.0", "topic"}, options, new(bytes.Buffer), new(bytes.Buffer))
+		tag.Run(time.Now())
+
+		show(tmpDir, []string{"v1.0"}, ShowOption{Format: "oneline"}, stdout, stderr)
+
+		topic, _ := resolveRevision(t, tmpDir, "topic")
+		expected := fmt.Sprintf(`tag v1.0
+
+first release
+%s B
+`, topic)
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("shows a tag without a tagger", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		topic, _ := resolveRevision(t, tmpDir, "topic")
+		r := repo(t, tmpDir)
+		tag := database.NewTag(topic, "commit", "v0.1", nil, "no tagger\n")
+		r.Database.Store(tag)
+		r.Refs.UpdateRef("refs/tags/v0.1", tag.Oid(), "")
+
+		show(tmpDir, []string{"v0.1"}, ShowOption{Format: "%s"}, stdout, stderr)
+
+		expected := "tag v0.1\n\nno tagger\nB\n"
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("fails for a path that is not in the revision", func(t *testing.T) {
+		tmpDir, stdout, stderr := setUp(t)
+		defer os.RemoveAll(tmpDir)
+
+		status := show(tmpDir, []string{"topic:lib/nope.txt"}, ShowOption{}, stdout, stderr)
+
+		if status != 128 {
+			t.Errorf("want %d, but got %d", 128, status)
+		}
+		expected := `error: path 'lib/nope.txt' does not exist in 'topic'
+fatal: Not a valid object name: 'topic:lib/nope.txt'.
+`
+		if got := stderr.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+}
@@ -0,0 +1,301 @@
+package command
+
+import (
+	"building-git/lib/command/print_diff"
+	"building-git/lib/command/write_commit"
+	"building-git/lib/database"
+	"building-git/lib/merge"
+	"building-git/lib/repository"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+type StashOption struct {
+	Untracked bool
+	Message   string
+	Patch     bool
+	Index     bool
+}
+
+type Stash struct {
+	rootPath  string
+	args      []string
+	options   StashOption
+	repo      *repository.Repository
+	stash     *repository.Stash
+	printDiff *print_diff.PrintDiff
+	stdout    io.Writer
+	stderr    io.Writer
+}
+
+type stashConflictError struct{}
+
+func (e *stashConflictError) Error() string {
+	return "conflicts in stash"
+}
+
+func NewStash(dir string, args []string, options StashOption, stdout, stderr io.Writer) (*Stash, error) {
+	rootPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(rootPath)
+	printDiff, _ := print_diff.NewPrintDiff(dir, stdout, stderr)
+
+	return &Stash{
+		rootPath:  rootPath,
+		args:      args,
+		options:   options,
+		repo:      repo,
+		stash:     repository.NewStash(repo),
+		printDiff: printDiff,
+		stdout:    stdout,
+		stderr:    stderr,
+	}, nil
+}
+
+func (s *Stash) Run(now time.Time) int {
+	subcommand := "push"
+	if len(s.args) > 0 {
+		switch s.args[0] {
+		case "push", "list", "show", "apply", "pop", "drop":
+			subcommand, s.args = s.args[0], s.args[1:]
+		}
+	}
+
+	var err error
+	switch subcommand {
+	case "push":
+		err = s.pushStash(now)
+	case "list":
+		err = s.listStashes()
+	case "show":
+		err = s.showStash()
+	case "apply":
+		err = s.applyStash(s.stashArg())
+	case "pop":
+		err = s.popStash()
+	case "drop":
+		err = s.dropStash(s.stashArg())
+	}
+
+	if _, ok := err.(*stashConflictError); ok {
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(s.stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func (s *Stash) stashArg() string {
+	if len(s.args) > 0 {
+		return s.args[0]
+	}
+	return ""
+}
+
+func (s *Stash) pushStash(now time.Time) error {
+	if err := s.repo.Index.LoadForUpdate(); err != nil {
+		return err
+	}
+
+	author := write_commit.NewWriteCommit(s.repo, nil).CurrentAuthor(now)
+	options := repository.StashOption{
+		Paths:     s.args,
+		Untracked: s.options.Untracked,
+		Message:   s.options.Message,
+	}
+	commit, err := s.stash.Push(options, author)
+	if err != nil || commit == nil {
+		s.repo.Index.ReleaseLock()
+		if err == nil {
+			fmt.Fprintln(s.stdout, "No local changes to save")
+		}
+		return err
+	}
+
+	s.repo.Index.WriteUpdates()
+	fmt.Fprintf(s.stdout, "Saved working directory and index state %s\n", commit.TitleLine())
+	return nil
+}
+
+func (s *Stash) listStashes() error {
+	entries, err := s.stash.List()
+	if err != nil {
+		return err
+	}
+	for i, entry := range entries {
+		fmt.Fprintf(s.stdout, "%s: %s\n", repository.StashName(i), entry.Message)
+	}
+	return nil
+}
+
+func (s *Stash) showStash() error {
+	commit, err := s.loadStash(s.stashArg())
+	if err != nil {
+		return err
+	}
+
+	if s.options.Patch {
+		s.printDiff.PrintCommitDiff(commit.Parent(), commit.Oid(), nil)
+	} else {
+		s.printDiff.PrintCommitStat(commit.Parent(), commit.Oid(), nil)
+	}
+	return nil
+}
+
+func (s *Stash) popStash() error {
+	name := s.stashArg()
+	if err := s.applyStash(name); err != nil {
+		if _, ok := err.(*stashConflictError); ok {
+			fmt.Fprintln(s.stdout, "The stash entry is kept in case you need it again.")
+		}
+		return err
+	}
+	return s.dropStash(name)
+}
+
+func (s *Stash) dropStash(name string) error {
+	n, err := s.stashIndex(name)
+	if err != nil {
+		return err
+	}
+
+	entry, err := s.stash.Drop(n)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = repository.STASH_REF + "@{0}"
+	}
+	fmt.Fprintf(s.stdout, "Dropped %s (%s)\n", name, entry.NewOid)
+	return nil
+}
+
+func (s *Stash) applyStash(name string) error {
+	commit, err := s.loadStash(name)
+	if err != nil {
+		return err
+	}
+	if len(commit.Parents) < 2 {
+		return fmt.Errorf("'%s' is not a stash-like commit", name)
+	}
+	base, indexOid := commit.Parents[0], commit.Parents[1]
+
+	if err := s.repo.Index.LoadForUpdate(); err != nil {
+		return err
+	}
+	if s.repo.Index.IsConflict() {
+		s.repo.Index.ReleaseLock()
+		return fmt.Errorf("Cannot apply a stash in the middle of a merge")
+	}
+
+	untracked := map[string]*database.Entry{}
+	if len(commit.Parents) > 2 {
+		untracked = s.repo.Database.LoadTreeList(commit.Parents[2], "")
+	}
+	for path := range untracked {
+		if stat, _ := s.repo.Workspace.StatFile(path); stat != nil {
+			s.repo.Index.ReleaseLock()
+			return fmt.Errorf("%s already exists, no checkout\nerror: could not restore untracked files from stash", path)
+		}
+	}
+
+	headOid, _ := s.repo.Refs.ReadHead()
+	inputs := merge.NewCherryPick(s.repo, "Updated upstream", "Stashed changes", headOid, commit.Oid(), []string{base})
+	resolve := merge.NewResolve(s.repo, inputs, func(fn func() string) {
+		fmt.Fprintln(s.stdout, fn())
+	})
+	if err := resolve.Execute(); err != nil {
+		s.repo.Index.ReleaseLock()
+		return err
+	}
+
+	for path, entry := range untracked {
+		blob, err := s.repo.Database.Load(entry.Oid())
+		if err != nil {
+			s.repo.Index.ReleaseLock()
+			return err
+		}
+		s.repo.Workspace.WriteFile(path, []byte(blob.String()), entry.Mode(), true)
+	}
+
+	if s.repo.Index.IsConflict() {
+		s.repo.Index.WriteUpdates()
+		return &stashConflictError{}
+	}
+
+	s.restoreIndex(headOid, base, commit.Oid(), indexOid)
+	s.repo.Index.WriteUpdates()
+	return nil
+}
+
+func (s *Stash) restoreIndex(headOid, base, workOid, indexOid string) {
+	headTree := s.repo.Database.LoadTreeList(headOid, "")
+	changes := s.repo.Database.TreeDiff(base, workOid, nil)
+	indexChanges := map[string][2]database.TreeObject{}
+	if s.options.Index {
+		indexChanges = s.repo.Database.TreeDiff(base, indexOid, nil)
+	}
+
+	for path, images := range indexChanges {
+		changes[path] = images
+		if images[1] == nil || images[1].IsNil() {
+			s.repo.Index.Remove(path)
+		} else {
+			s.repo.Index.AddFromDb(path, database.NewEntry(images[1].Oid(), images[1].Mode()))
+		}
+	}
+
+	for path := range changes {
+		if _, ok := indexChanges[path]; ok {
+			continue
+		}
+		if entry, ok := headTree[path]; ok {
+			s.repo.Index.AddFromDb(path, entry)
+		}
+	}
+}
+
+func (s *Stash) loadStash(name string) (*database.Commit, error) {
+	if name == "" {
+		name = repository.StashName(0)
+	}
+	if _, err := s.repo.Refs.ReadRef(repository.STASH_REF); err != nil {
+		return nil, fmt.Errorf("No stash entries found.")
+	}
+
+	if _, err := strconv.Atoi(name); err == nil {
+		name = fmt.Sprintf("stash@{%s}", name)
+	}
+	oid, err := repository.NewRevision(s.repo, name).Resolve(repository.COMMIT)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid reference", name)
+	}
+	object, err := s.repo.Database.Load(oid)
+	if err != nil {
+		return nil, err
+	}
+	return object.(*database.Commit), nil
+}
+
+func (s *Stash) stashIndex(name string) (int, error) {
+	if name == "" {
+		return 0, nil
+	}
+	if n, err := strconv.Atoi(name); err == nil {
+		return n, nil
+	}
+
+	match := repository.REFLOG.FindStringSubmatch(name)
+	if match == nil || (match[1] != "stash" && match[1] != repository.STASH_REF) {
+		return 0, fmt.Errorf("'%s' is not a stash reference", name)
+	}
+	n, _ := strconv.Atoi(match[2])
+	return n, nil
+}
@@ -0,0 +1,263 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStash(t *testing.T) {
+	setup := func() (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+		commitTree(t, tmpDir, "first", map[string]string{
+			"a.txt":     "1\n",
+			"lib/b.txt": "2\n",
+		}, time.Now())
+		return
+	}
+
+	stash := func(t *testing.T, tmpDir string, args []string, options StashOption) (string, string, int) {
+		t.Helper()
+
+		os.Setenv("GIT_AUTHOR_NAME", "A. U. Thor")
+		os.Setenv("GIT_AUTHOR_EMAIL", "author@example.com")
+		defer os.Unsetenv("GIT_AUTHOR_NAME")
+		defer os.Unsetenv("GIT_AUTHOR_EMAIL")
+
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		cmd, _ := NewStash(tmpDir, args, options, stdout, stderr)
+		status := cmd.Run(time.Now())
+		return stdout.String(), stderr.String(), status
+	}
+
+	short := func(tmpDir, expression string) string {
+		oid, _ := resolveRevision(t, tmpDir, expression)
+		return repo(t, tmpDir).Database.ShortOid(oid)
+	}
+
+	t.Run("saves changes and cleans the working tree", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "a.txt", "changed\n")
+		Add(tmpDir, []string{"a.txt"}, new(bytes.Buffer), new(bytes.Buffer))
+		writeFile(t, tmpDir, "lib/b.txt", "also changed\n")
+
+		out, _, status := stash(t, tmpDir, []string{}, StashOption{})
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected := fmt.Sprintf("Saved working directory and index state WIP on master: %s first\n", short(tmpDir, "@"))
+		if out != expected {
+			t.Errorf("want %q, but got %q", expected, out)
+		}
+		assertWorkspace(t, tmpDir, map[string]string{"a.txt": "1\n", "lib/b.txt": "2\n"})
+		assertGitStatus(t, tmpDir, stdout, stderr, "")
+	})
+
+	t.Run("reports when there is nothing to stash", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		out, _, status := stash(t, tmpDir, []string{"push"}, StashOption{})
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		if expected := "No local changes to save\n"; out != expected {
+			t.Errorf("want %q, but got %q", expected, out)
+		}
+	})
+
+	t.Run("lists stashes newest first", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "a.txt", "one\n")
+		stash(t, tmpDir, []string{}, StashOption{})
+		writeFile(t, tmpDir, "a.txt", "two\n")
+		stash(t, tmpDir, []string{"push"}, StashOption{Message: "second try"})
+
+		out, _, _ := stash(t, tmpDir, []string{"list"}, StashOption{})
+
+		expected := fmt.Sprintf("stash@{0}: On master: second try\nstash@{1}: WIP on master: %s first\n", short(tmpDir, "@"))
+		if out != expected {
+			t.Errorf("want %q, but got %q", expected, out)
+		}
+	})
+
+	t.Run("pops the latest stash and drops it", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "a.txt", "changed\n")
+		Add(tmpDir, []string{"a.txt"}, new(bytes.Buffer), new(bytes.Buffer))
+		delete(t, tmpDir, "lib/b.txt")
+		stash(t, tmpDir, []string{}, StashOption{})
+		oid, _ := resolveRevision(t, tmpDir, "stash@{0}")
+
+		out, _, status := stash(t, tmpDir, []string{"pop"}, StashOption{})
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected := fmt.Sprintf("Dropped refs/stash@{0} (%s)\n", oid)
+		if !strings.HasSuffix(out, expected) {
+			t.Errorf("want suffix %q, but got %q", expected, out)
+		}
+		assertWorkspace(t, tmpDir, map[string]string{"a.txt": "changed\n"})
+		assertGitStatus(t, tmpDir, stdout, stderr, " M a.txt\n D lib/b.txt\n")
+
+		list, _, _ := stash(t, tmpDir, []string{"list"}, StashOption{})
+		if list != "" {
+			t.Errorf("want %q, but got %q", "", list)
+		}
+	})
+
+	t.Run("restores the index with --index", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "a.txt", "changed\n")
+		writeFile(t, tmpDir, "c.txt", "new\n")
+		Add(tmpDir, []string{"a.txt", "c.txt"}, new(bytes.Buffer), new(bytes.Buffer))
+		writeFile(t, tmpDir, "lib/b.txt", "unstaged\n")
+		stash(t, tmpDir, []string{}, StashOption{})
+
+		_, _, status := stash(t, tmpDir, []string{"apply"}, StashOption{Index: true})
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		assertGitStatus(t, tmpDir, stdout, stderr, "M  a.txt\nA  c.txt\n M lib/b.txt\n")
+
+		list, _, _ := stash(t, tmpDir, []string{"list"}, StashOption{})
+		if !strings.HasPrefix(list, "stash@{0}: ") {
+			t.Errorf("want the stash to be kept, but got %q", list)
+		}
+	})
+
+	t.Run("stashes untracked files with -u", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "new/file.txt", "untracked\n")
+		stash(t, tmpDir, []string{}, StashOption{Untracked: true})
+		assertWorkspace(t, tmpDir, map[string]string{"a.txt": "1\n", "lib/b.txt": "2\n"})
+
+		_, _, status := stash(t, tmpDir, []string{"pop"}, StashOption{})
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		assertGitStatus(t, tmpDir, stdout, stderr, "?? new/\n")
+	})
+
+	t.Run("only stashes the given paths", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "a.txt", "changed\n")
+		writeFile(t, tmpDir, "lib/b.txt", "changed\n")
+
+		stash(t, tmpDir, []string{"push", "lib"}, StashOption{})
+
+		assertGitStatus(t, tmpDir, stdout, stderr, " M a.txt\n")
+		out, _, _ := stash(t, tmpDir, []string{"show"}, StashOption{})
+		expected := " lib/b.txt | 2 +-\n 1 file changed, 1 insertion(+), 1 deletion(-)\n"
+		if out != expected {
+			t.Errorf("want %q, but got %q", expected, out)
+		}
+	})
+
+	t.Run("shows a stash as a patch", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "a.txt", "changed\n")
+		stash(t, tmpDir, []string{}, StashOption{})
+
+		out, _, _ := stash(t, tmpDir, []string{"show", "stash@{0}"}, StashOption{Patch: true})
+
+		for _, line := range []string{"--- a/a.txt\n", "+++ b/a.txt\n", "-1\n", "+changed\n"} {
+			if !strings.Contains(out, line) {
+				t.Errorf("want %q in %q", line, out)
+			}
+		}
+	})
+
+	t.Run("drops a specific stash", func(t *testing.T) {
+		tmpDir, _, _ := setup()
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "a.txt", "one\n")
+		stash(t, tmpDir, []string{}, StashOption{Message: "one"})
+		writeFile(t, tmpDir, "a.txt", "two\n")
+		stash(t, tmpDir, []string{}, StashOption{Message: "two"})
+		oid, _ := resolveRevision(t, tmpDir, "stash@{1}")
+
+		out, _, _ := stash(t, tmpDir, []string{"drop", "stash@{1}"}, StashOption{})
+
+		if expected := fmt.Sprintf("Dropped stash@{1} (%s)\n", oid); out != expected {
+			t.Errorf("want %q, but got %q", expected, out)
+		}
+		list, _, _ := stash(t, tmpDir, []string{"list"}, StashOption{})
+		if expected := "stash@{0}: On master: two\n"; list != expected {
+			t.Errorf("want %q, but got %q", expected, list)
+		}
+
+		_, errOut, status := stash(t, tmpDir, []string{"drop", "stash@{3}"}, StashOption{})
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		if expected := "error: stash@{3} is not a valid reference\n"; errOut != expected {
+			t.Errorf("want %q, but got %q", expected, errOut)
+		}
+	})
+
+	t.Run("applies onto a HEAD that has moved on", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "a.txt", "changed\n")
+		stash(t, tmpDir, []string{}, StashOption{})
+		commitTree(t, tmpDir, "second", map[string]string{"lib/b.txt": "upstream\n"}, time.Now())
+
+		_, _, status := stash(t, tmpDir, []string{"pop"}, StashOption{})
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		assertWorkspace(t, tmpDir, map[string]string{"a.txt": "changed\n", "lib/b.txt": "upstream\n"})
+		assertGitStatus(t, tmpDir, stdout, stderr, " M a.txt\n")
+	})
+
+	t.Run("keeps the stash when applying conflicts", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, "a.txt", "stashed\n")
+		stash(t, tmpDir, []string{}, StashOption{})
+		commitTree(t, tmpDir, "second", map[string]string{"a.txt": "upstream\n"}, time.Now())
+
+		out, _, status := stash(t, tmpDir, []string{"pop"}, StashOption{})
+
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		expected := "CONFLICT (content): Merge conflict in a.txt\nThe stash entry is kept in case you need it again.\n"
+		if !strings.HasSuffix(out, expected) {
+			t.Errorf("want suffix %q, but got %q", expected, out)
+		}
+		assertGitStatus(t, tmpDir, stdout, stderr, "UU a.txt\n")
+
+		list, _, _ := stash(t, tmpDir, []string{"list"}, StashOption{})
+		if !strings.HasPrefix(list, "stash@{0}: ") {
+			t.Errorf("want the stash to be kept, but got %q", list)
+		}
+	})
+}
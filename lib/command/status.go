@@ -1,6 +1,7 @@
 package command
 
 import (
+	"building-git/lib/database"
 	"building-git/lib/repository"
 	"building-git/lib/sortedmap"
 	"fmt"
@@ -19,15 +20,18 @@ var SHORT_STATUS = map[repository.ChangeType]string{
 	repository.Added:    "A",
 	repository.Deleted:  "D",
 	repository.Modified: "M",
+	repository.Renamed:  "R",
 }
 
 var LONG_STATUS = map[any]string{
 	repository.Added:    "new file:   ",
 	repository.Deleted:  "deleted:    ",
 	repository.Modified: "modified:   ",
+	repository.Renamed:  "renamed:    ",
 }
 
 var CONFLICT_LONG_STATUS = map[any]string{
+	"1":   "both deleted:",
 	"123": "both modified:",
 	"12":  "deleted by them:",
 	"13":  "deleted by us:",
@@ -37,6 +41,7 @@ var CONFLICT_LONG_STATUS = map[any]string{
 }
 
 var CONFLICT_SHORT_STATUS = map[any]string{
+	"1":   "DD",
 	"123": "UU",
 	"12":  "UD",
 	"13":  "DU",
@@ -57,16 +62,19 @@ var UI_WIDTHS = map[string]int{
 
 type StatusOption struct {
 	Porcelain bool
+	Ignored   bool
 }
 
 type Status struct {
-	rootPath string
-	args     []string
-	options  StatusOption
-	repo     *repository.Repository
-	status   *repository.Status
-	stdout   io.Writer
-	stderr   io.Writer
+	rootPath     string
+	args         []string
+	options      StatusOption
+	repo         *repository.Repository
+	status       *repository.Status
+	indexChanges *sortedmap.SortedMap[repository.ChangeType]
+	renames      map[string]*database.Rename
+	stdout       io.Writer
+	stderr       io.Writer
 }
 
 func NewStatus(dir string, args []string, options StatusOption, stdout, stderr io.Writer) (*Status, error) {
@@ -97,11 +105,40 @@ func (s *Status) Run() int {
 	}
 
 	s.repo.Index.WriteUpdates()
+	s.detectRenames()
 	s.printResults()
 
 	return 0
 }
 
+func (s *Status) detectRenames() {
+	_, s.renames = s.repo.Database.DetectRenames(s.status.IndexDiff(), database.RenameOption{})
+
+	sources := map[string]bool{}
+	for _, rename := range s.renames {
+		sources[rename.OldPath] = true
+	}
+
+	s.indexChanges = sortedmap.NewSortedMap[repository.ChangeType]()
+	s.status.IndexChanges.Iterate(func(path string, ctype repository.ChangeType) {
+		switch {
+		case sources[path]:
+			return
+		case s.renames[path] != nil:
+			s.indexChanges.Set(path, repository.Renamed)
+		default:
+			s.indexChanges.Set(path, ctype)
+		}
+	})
+}
+
+func (s *Status) displayPath(path string) string {
+	if ctype, _ := s.indexChanges.Get(path); ctype == repository.Renamed {
+		return fmt.Sprintf("%s -> %s", s.renames[path].OldPath, path)
+	}
+	return path
+}
+
 func (s *Status) printResults() {
 	if s.options.Porcelain {
 		s.printPorcelainFormat()
@@ -113,10 +150,13 @@ func (s *Status) printResults() {
 func (s *Status) printLongFormat() {
 	s.printBranchStatus()
 
-	s.printChanges("Changes to be committed", *s.status.IndexChanges, color.New(color.FgGreen), "normal")
+	s.printChanges("Changes to be committed", *s.indexChanges, color.New(color.FgGreen), "normal")
 	s.printChanges("Unmerged paths", *s.status.Conflicts, color.New(color.FgRed), "conflict")
 	s.printChanges("Changes not staged for commit", *s.status.WorkspaceChanges, color.New(color.FgRed), "normal")
 	s.printChanges("Untracked files", *s.status.Untracked, color.New(color.FgRed), "normal")
+	if s.options.Ignored {
+		s.printChanges("Ignored files", *s.status.Ignored, color.New(color.FgRed), "normal")
+	}
 	s.printCommitStatus()
 }
 
@@ -132,35 +172,44 @@ func (s *Status) printBranchStatus() {
 }
 
 func (s *Status) printChanges(message string, changeset interface{}, color *color.Color, labelSet string) {
-	cset, ok := changeset.(sortedmap.SortedMap[any])
-	if !ok {
-		panic("")
-	}
-	if cset.Len() == 0 {
-		return
-	}
-
 	labels := UI_LABELS[labelSet]
 	width := UI_WIDTHS[labelSet]
 
-	fmt.Fprintln(s.stdout, message)
-	fmt.Fprintln(s.stdout)
-
-	cset.Iterate(func(path string, change interface{}) {
-		status := ""
-		if ctype, ok := change.(repository.ChangeType); ok {
-			status = labels[ctype]
-		} else if statuses, ok := change.([]string); ok {
-			sort.Strings(statuses)
-			status = labels[strings.Join(statuses, "")]
-		}
-
+	lines := []string{}
+	addLine := func(status, path string) {
 		if len(status) < width {
 			status += strings.Repeat(" ", width-len(status))
 		}
-		color.Fprintf(s.stdout, "\t%s%s\n", status, path)
-	})
+		lines = append(lines, status+path)
+	}
+
+	switch cset := changeset.(type) {
+	case sortedmap.SortedMap[repository.ChangeType]:
+		cset.Iterate(func(path string, ctype repository.ChangeType) {
+			if ctype == repository.Renamed {
+				path = s.displayPath(path)
+			}
+			addLine(labels[ctype], path)
+		})
+	case sortedmap.SortedMap[[]string]:
+		cset.Iterate(func(path string, statuses []string) {
+			sort.Strings(statuses)
+			addLine(labels[strings.Join(statuses, "")], path)
+		})
+	case sortedmap.SortedMap[struct{}]:
+		cset.Iterate(func(path string, _ struct{}) {
+			addLine("", path)
+		})
+	}
+	if len(lines) == 0 {
+		return
+	}
 
+	fmt.Fprintln(s.stdout, message)
+	fmt.Fprintln(s.stdout)
+	for _, line := range lines {
+		color.Fprintf(s.stdout, "\t%s\n", line)
+	}
 	fmt.Fprintln(s.stdout)
 }
 
@@ -181,12 +230,22 @@ func (s *Status) printCommitStatus() {
 func (s *Status) printPorcelainFormat() {
 	s.status.Changed.Iterate(func(filename string, _ struct{}) {
 		status := s.statusFor(filename)
-		fmt.Fprintf(s.stdout, "%s %s\n", status, filename)
+		if status == "  " {
+			return
+		}
+		fmt.Fprintf(s.stdout, "%s %s\n", status, s.displayPath(filename))
 	})
 
 	s.status.Untracked.Iterate(func(filename string, _ struct{}) {
 		fmt.Fprintf(s.stdout, "?? %s\n", filename)
 	})
+
+	if !s.options.Ignored {
+		return
+	}
+	s.status.Ignored.Iterate(func(filename string, _ struct{}) {
+		fmt.Fprintf(s.stdout, "!! %s\n", filename)
+	})
 }
 
 func (s *Status) statusFor(path string) string {
@@ -196,7 +255,7 @@ func (s *Status) statusFor(path string) string {
 	}
 
 	left := " "
-	if ctype, exists := s.status.IndexChanges.Get(path); exists {
+	if ctype, exists := s.indexChanges.Get(path); exists {
 		if status, exists := SHORT_STATUS[ctype]; exists {
 			left = status
 		}
@@ -300,4 +300,70 @@ D  a/b/3.txt
 `
 		assertGitStatus(t, tmpDir, stdout, stderr, expected)
 	})
+
+	t.Run("reports renamed files", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+		delete(t, tmpDir, "a/b/3.txt")
+		writeFile(t, tmpDir, "c/3.txt", "three")
+		delete(t, tmpDir, ".git/index")
+		Add(tmpDir, []string{"."}, new(bytes.Buffer), new(bytes.Buffer))
+
+		expected := `R  a/b/3.txt -> c/3.txt
+`
+		assertGitStatus(t, tmpDir, stdout, stderr, expected)
+	})
+}
+
+func TestStatusIgnoredFiles(t *testing.T) {
+	setup := func() (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+		writeFile(t, tmpDir, ".gitignore", "*.o\nbuild/\n")
+		commitTree(t, tmpDir, "first", map[string]string{"lib/main.c": ""}, time.Now())
+
+		writeFile(t, tmpDir, "top.o", "")
+		writeFile(t, tmpDir, "build/out.bin", "")
+		writeFile(t, tmpDir, "lib/main.o", "")
+		writeFile(t, tmpDir, "objs/a.o", "")
+		writeFile(t, tmpDir, "src/new.c", "")
+		writeFile(t, tmpDir, "src/new.o", "")
+		return
+	}
+
+	t.Run("does not list ignored files as untracked", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		expected := "?? src/\n"
+		assertGitStatus(t, tmpDir, stdout, stderr, expected)
+	})
+
+	t.Run("lists ignored files with --ignored", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		statusCmd, _ := NewStatus(tmpDir, []string{}, StatusOption{Porcelain: true, Ignored: true}, stdout, stderr)
+		statusCmd.Run()
+
+		expected := `?? src/
+!! build/
+!! lib/main.o
+!! objs/
+!! src/new.o
+!! top.o
+`
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("reads patterns from .git/info/exclude", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		writeFile(t, tmpDir, ".git/info/exclude", "src/\n")
+
+		expected := ""
+		assertGitStatus(t, tmpDir, stdout, stderr, expected)
+	})
 }
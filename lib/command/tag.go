@@ -0,0 +1,150 @@
+package command
+
+import (
+	"building-git/lib/command/write_commit"
+	"building-git/lib/database"
+	"building-git/lib/editor"
+	"building-git/lib/repository"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const TAG_NOTES = `Write a message for tag:
+  %s
+Lines starting with '#' will be ignored.`
+
+type TagOption struct {
+	write_commit.ReadOption
+	Annotate  bool
+	Delete    bool
+	Force     bool
+	IsTTY     bool
+	EditorCmd func(path string) editor.Executable
+}
+
+type Tag struct {
+	rootPath string
+	args     []string
+	options  TagOption
+	repo     *repository.Repository
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+func NewTag(dir string, args []string, options TagOption, stdout, stderr io.Writer) (*Tag, error) {
+	rootPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := repository.NewRepository(rootPath)
+
+	return &Tag{
+		rootPath: rootPath,
+		args:     args,
+		options:  options,
+		repo:     repo,
+		stdout:   stdout,
+		stderr:   stderr,
+	}, nil
+}
+
+func (t *Tag) Run(now time.Time) int {
+	if t.options.Delete {
+		return t.deleteTags()
+	}
+	if len(t.args) == 0 {
+		t.listTags()
+		return 0
+	}
+
+	if err := t.createTag(now); err != nil {
+		fmt.Fprintf(t.stderr, "fatal: %v\n", err)
+		return 128
+	}
+	return 0
+}
+
+func (t *Tag) listTags() {
+	tags, _ := t.repo.Refs.ListTags()
+	names := []string{}
+	for _, tag := range tags {
+		name, _ := tag.ShortName()
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(t.stdout, "%s\n", name)
+	}
+}
+
+func (t *Tag) createTag(now time.Time) error {
+	tagName := t.args[0]
+	target := repository.HEAD
+	if len(t.args) > 1 {
+		target = t.args[1]
+	}
+
+	oid, err := repository.NewRevision(t.repo, target).Resolve("")
+	if err != nil {
+		return fmt.Errorf("Failed to resolve '%s' as a valid ref.", target)
+	}
+
+	if t.options.Annotate || t.options.Message != "" || t.options.File != "" {
+		oid, err = t.writeTagObject(tagName, oid, now)
+		if err != nil {
+			return err
+		}
+	}
+	return t.repo.Refs.CreateTag(tagName, oid, t.options.Force)
+}
+
+func (t *Tag) writeTagObject(tagName, oid string, now time.Time) (string, error) {
+	writeCommit := write_commit.NewWriteCommit(t.repo, t.options.EditorCmd)
+	message, err := writeCommit.ReadMessage(t.options.ReadOption)
+	if err != nil {
+		message = t.composeMessage(tagName)
+	}
+	if strings.TrimSpace(message) == "" {
+		return "", fmt.Errorf("no tag message given")
+	}
+
+	object, err := t.repo.Database.Load(oid)
+	if err != nil {
+		return "", err
+	}
+
+	tagger := writeCommit.CurrentAuthor(now)
+	tag := database.NewTag(oid, object.Type(), tagName, tagger, message)
+	if err := t.repo.Database.Store(tag); err != nil {
+		return "", err
+	}
+	return tag.Oid(), nil
+}
+
+func (t *Tag) composeMessage(tagName string) string {
+	path := filepath.Join(t.repo.GitPath, "TAG_EDITMSG")
+	return editor.EditFile(path, t.options.EditorCmd(path), t.options.IsTTY, func(e *editor.Editor) {
+		e.Puts("")
+		e.Note(fmt.Sprintf(TAG_NOTES, tagName))
+	})
+}
+
+func (t *Tag) deleteTags() int {
+	status := 0
+	for _, tagName := range t.args {
+		oid, err := t.repo.Refs.DeleteTag(tagName)
+		if err != nil {
+			fmt.Fprintf(t.stderr, "error: tag '%s' not found.\n", tagName)
+			status = 1
+			continue
+		}
+		short := t.repo.Database.ShortOid(oid)
+		fmt.Fprintf(t.stdout, "Deleted tag '%s' (was %s)\n", tagName, short)
+	}
+	return status
+}
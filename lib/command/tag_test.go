@@ -0,0 +1,244 @@
+package command
+
+import (
+	"building-git/lib/command/write_commit"
+	"building-git/lib/database"
+	"building-git/lib/editor"
+	"building-git/lib/repository"
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTag(t *testing.T) {
+	setup := func() (tmpDir string, stdout, stderr *bytes.Buffer) {
+		tmpDir, stdout, stderr = setupTestEnvironment(t)
+		for _, message := range []string{"first", "second"} {
+			commitTree(t, tmpDir, message, map[string]string{"file.txt": message}, time.Now())
+		}
+		return
+	}
+
+	tag := func(tmpDir string, args []string, options TagOption, stdout, stderr *bytes.Buffer) int {
+		os.Setenv("GIT_AUTHOR_NAME", "A. U. Thor")
+		os.Setenv("GIT_AUTHOR_EMAIL", "author@example.com")
+		defer os.Unsetenv("GIT_AUTHOR_NAME")
+		defer os.Unsetenv("GIT_AUTHOR_EMAIL")
+
+		if options.EditorCmd == nil {
+			options.EditorCmd = func(path string) editor.Executable {
+				return &MockEditor{path: path}
+			}
+		}
+
+		cmd, _ := NewTag(tmpDir, args, options, stdout, stderr)
+		return cmd.Run(time.Now())
+	}
+
+	t.Run("creates a lightweight tag pointing at HEAD", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		status := tag(tmpDir, []string{"v1.0"}, TagOption{}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected, _ := repo(t, tmpDir).Refs.ReadHead()
+		if got, _ := repo(t, tmpDir).Refs.ReadRef("refs/tags/v1.0"); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("creates an annotated tag object", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		options := TagOption{ReadOption: write_commit.ReadOption{Message: "Release 1.0"}}
+		tag(tmpDir, []string{"v1.0", "@^"}, options, stdout, stderr)
+
+		r := repo(t, tmpDir)
+		oid, _ := r.Refs.ReadRef("refs/tags/v1.0")
+		object, err := r.Database.Load(oid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tagObject, ok := object.(*database.Tag)
+		if !ok {
+			t.Fatalf("want a tag object, but got a %s", object.Type())
+		}
+
+		target, _ := resolveRevision(t, tmpDir, "@^")
+		if tagObject.Object() != target {
+			t.Errorf("want %q, but got %q", target, tagObject.Object())
+		}
+		if tagObject.ObjectType() != "commit" {
+			t.Errorf("want %q, but got %q", "commit", tagObject.ObjectType())
+		}
+		if tagObject.Name() != "v1.0" {
+			t.Errorf("want %q, but got %q", "v1.0", tagObject.Name())
+		}
+		if tagObject.Message() != "Release 1.0\n" {
+			t.Errorf("want %q, but got %q", "Release 1.0\n", tagObject.Message())
+		}
+		if tagObject.Tagger().Name != "A. U. Thor" {
+			t.Errorf("want %q, but got %q", "A. U. Thor", tagObject.Tagger().Name)
+		}
+	})
+
+	t.Run("peels tags when resolving revisions", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		options := TagOption{ReadOption: write_commit.ReadOption{Message: "Release 1.0"}}
+		tag(tmpDir, []string{"v1.0"}, options, stdout, stderr)
+
+		head, _ := repo(t, tmpDir).Refs.ReadHead()
+		parent, _ := resolveRevision(t, tmpDir, "@^")
+		for expr, expected := range map[string]string{"v1.0": head, "v1.0^": parent, "tags/v1.0~1": parent} {
+			got, err := repository.NewRevision(repo(t, tmpDir), expr).Resolve(repository.COMMIT)
+			if err != nil || got != expected {
+				t.Errorf("want %s to resolve to %q, but got %q (%v)", expr, expected, got, err)
+			}
+		}
+	})
+
+	t.Run("lists tags in name order", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		tag(tmpDir, []string{"v2.0"}, TagOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		tag(tmpDir, []string{"v1.0", "@^"}, TagOption{Annotate: true, ReadOption: write_commit.ReadOption{Message: "one"}}, new(bytes.Buffer), new(bytes.Buffer))
+		tag(tmpDir, []string{}, TagOption{}, stdout, stderr)
+
+		if got := stdout.String(); got != "v1.0\nv2.0\n" {
+			t.Errorf("want %q, but got %q", "v1.0\nv2.0\n", got)
+		}
+	})
+
+	t.Run("fails for an existing tag", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		tag(tmpDir, []string{"v1.0"}, TagOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		status := tag(tmpDir, []string{"v1.0", "@^"}, TagOption{}, stdout, stderr)
+
+		if status != 128 {
+			t.Errorf("want %d, but got %d", 128, status)
+		}
+		if got := stderr.String(); got != "fatal: tag 'v1.0' already exists\n" {
+			t.Errorf("want %q, but got %q", "fatal: tag 'v1.0' already exists\n", got)
+		}
+	})
+
+	t.Run("replaces an existing tag with force", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		tag(tmpDir, []string{"v1.0"}, TagOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		status := tag(tmpDir, []string{"v1.0", "@^"}, TagOption{Force: true}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		expected, _ := resolveRevision(t, tmpDir, "@^")
+		if got, _ := repo(t, tmpDir).Refs.ReadRef("refs/tags/v1.0"); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+
+	t.Run("opens the editor for an annotated tag without a message", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		options := TagOption{
+			Annotate: true,
+			IsTTY:    true,
+			EditorCmd: func(path string) editor.Executable {
+				return NewMockEditor(path, "Release 1.0\n\n# Write a message for tag:\n")
+			},
+		}
+		status := tag(tmpDir, []string{"v1.0"}, options, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		r := repo(t, tmpDir)
+		oid, _ := r.Refs.ReadRef("refs/tags/v1.0")
+		object, err := r.Database.Load(oid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := object.(*database.Tag).Message(); got != "Release 1.0\n" {
+			t.Errorf("want %q, but got %q", "Release 1.0\n", got)
+		}
+	})
+
+	t.Run("fails for an annotated tag without a message", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		status := tag(tmpDir, []string{"v1.0"}, TagOption{Annotate: true}, stdout, stderr)
+
+		if status != 128 {
+			t.Errorf("want %d, but got %d", 128, status)
+		}
+		if got := stderr.String(); got != "fatal: no tag message given\n" {
+			t.Errorf("want %q, but got %q", "fatal: no tag message given\n", got)
+		}
+	})
+
+	t.Run("deletes a tag", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		tag(tmpDir, []string{"v1.0"}, TagOption{}, new(bytes.Buffer), new(bytes.Buffer))
+		status := tag(tmpDir, []string{"v1.0"}, TagOption{Delete: true}, stdout, stderr)
+
+		if status != 0 {
+			t.Errorf("want %d, but got %d", 0, status)
+		}
+		head, _ := repo(t, tmpDir).Refs.ReadHead()
+		expected := fmt.Sprintf("Deleted tag 'v1.0' (was %s)\n", head[:7])
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+		if got, _ := repo(t, tmpDir).Refs.ReadRef("refs/tags/v1.0"); got != "" {
+			t.Errorf("want %q, but got %q", "", got)
+		}
+	})
+
+	t.Run("fails to delete a missing tag", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		status := tag(tmpDir, []string{"v1.0"}, TagOption{Delete: true}, stdout, stderr)
+
+		if status != 1 {
+			t.Errorf("want %d, but got %d", 1, status)
+		}
+		if got := stderr.String(); got != "error: tag 'v1.0' not found.\n" {
+			t.Errorf("want %q, but got %q", "error: tag 'v1.0' not found.\n", got)
+		}
+	})
+
+	t.Run("decorates log output with peeled tags", func(t *testing.T) {
+		tmpDir, stdout, stderr := setup()
+		defer os.RemoveAll(tmpDir)
+
+		tag(tmpDir, []string{"v1.0", "@^"}, TagOption{ReadOption: write_commit.ReadOption{Message: "one"}}, new(bytes.Buffer), new(bytes.Buffer))
+		tag(tmpDir, []string{"v2.0"}, TagOption{}, new(bytes.Buffer), new(bytes.Buffer))
+
+		log, _ := NewLog(tmpDir, []string{}, LogOption{Format: "oneline", IsTty: false, Decorate: "short"}, stdout, stderr)
+		log.Run()
+
+		head, _ := resolveRevision(t, tmpDir, "@")
+		parent, _ := resolveRevision(t, tmpDir, "@^")
+		expected := fmt.Sprintf("%s (HEAD -> master, tag: v2.0) second\n%s (tag: v1.0) first\n", head, parent)
+		if got := stdout.String(); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	})
+}
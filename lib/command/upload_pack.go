@@ -0,0 +1,94 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+)
+
+var UPLOAD_PACK_CAPABILITIES = []string{"ofs-delta"}
+
+type UploadPack struct {
+	args      []string
+	agent     *remoteAgent
+	stdin     io.Reader
+	stdout    io.Writer
+	stderr    io.Writer
+	wanted    []string
+	remoteHas []string
+}
+
+func NewUploadPack(args []string, stdin io.Reader, stdout, stderr io.Writer) (*UploadPack, error) {
+	agent, err := newRemoteAgent(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return &UploadPack{
+		args:   args,
+		agent:  agent,
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}, nil
+}
+
+func (u *UploadPack) Run() int {
+	u.agent.acceptClient("upload-pack", u.stdin, u.stdout, UPLOAD_PACK_CAPABILITIES)
+
+	if err := u.uploadPack(); err != nil {
+		fmt.Fprintf(u.stderr, "fatal: %v\n", err)
+		return 128
+	}
+	return 0
+}
+
+func (u *UploadPack) uploadPack() error {
+	if err := u.agent.sendReferences(); err != nil {
+		return err
+	}
+
+	wanted, err := u.recvOids("want", "")
+	if err != nil || len(wanted) == 0 {
+		return err
+	}
+	u.wanted = wanted
+
+	remoteHas, err := u.recvOids("have", "done")
+	if err != nil {
+		return err
+	}
+	u.remoteHas = remoteHas
+	if err := u.agent.conn.SendPacket("NAK"); err != nil {
+		return err
+	}
+
+	return u.sendObjects()
+}
+
+func (u *UploadPack) recvOids(prefix, terminator string) ([]string, error) {
+	pattern := regexp.MustCompile("^" + prefix + " ([0-9a-f]+)$")
+	seen := map[string]bool{}
+	result := []string{}
+
+	err := u.agent.conn.RecvUntil(terminator, func(line string) error {
+		match := pattern.FindStringSubmatch(line)
+		if match == nil {
+			return fmt.Errorf("protocol error: expected '%s', got '%s'", prefix, line)
+		}
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			result = append(result, match[1])
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (u *UploadPack) sendObjects() error {
+	revs := append([]string{}, u.wanted...)
+	for _, oid := range u.remoteHas {
+		revs = append(revs, "^"+oid)
+	}
+	return sendPackedObjects(u.agent.repo, u.agent.conn, revs)
+}
@@ -39,6 +39,13 @@ If this is not correct, please remove the file
 \t.git/CHERRY_PICK_HEAD
 and try again.`
 
+const REVERT_NOTES = `
+
+It looks like you may be committing a revert.
+If this is not correct, please remove the file
+\t.git/REVERT_HEAD
+and try again.`
+
 type WriteCommit struct {
 	repo      *repository.Repository
 	editorCmd func(path string) editor.Executable
@@ -82,11 +89,21 @@ func (wc *WriteCommit) WriteCommit(parents []string, message string, now time.Ti
 	author := wc.CurrentAuthor(now)
 	commit := database.NewCommit(parents, tree.Oid(), author, author, message)
 	wc.repo.Database.Store(commit)
-	wc.repo.Refs.UpdateHead(commit.Oid())
+	wc.repo.Refs.UpdateHead(commit.Oid(), commitLogMessage(commit))
 
 	return commit, nil
 }
 
+func commitLogMessage(commit *database.Commit) string {
+	prefix := "commit"
+	if len(commit.Parents) == 0 {
+		prefix = "commit (initial)"
+	} else if commit.IsMerge() {
+		prefix = "commit (merge)"
+	}
+	return fmt.Sprintf("%s: %s", prefix, commit.TitleLine())
+}
+
 func (wc *WriteCommit) WriteTree() *database.Tree {
 	root := database.BuildTree(wc.repo.Index.EachEntry())
 	root.Traverse(func(t database.TreeObject) {
@@ -139,6 +156,8 @@ func (wc *WriteCommit) ResumeMerge(mtype repository.MergeType, isTTY bool) error
 		return wc.WriteMergeCommit(isTTY)
 	case repository.CherryPick:
 		return wc.WriteCherryPickCommit(isTTY)
+	case repository.Revert:
+		return wc.WriteRevertCommit(isTTY)
 	}
 	return nil
 }
@@ -185,11 +204,29 @@ func (wc *WriteCommit) WriteCherryPickCommit(isTTY bool) error {
 		message,
 	)
 	wc.repo.Database.Store(picked)
-	wc.repo.Refs.UpdateHead(picked.Oid())
+	wc.repo.Refs.UpdateHead(picked.Oid(), "cherry-pick: "+picked.TitleLine())
 	wc.PendingCommit().Clear(repository.CherryPick)
 	return nil
 }
 
+func (wc *WriteCommit) WriteRevertCommit(isTTY bool) error {
+	err := wc.HandleConflictedIndex()
+	if err != nil {
+		return err
+	}
+
+	head, _ := wc.repo.Refs.ReadHead()
+	parents := []string{head}
+	message := wc.composeMergeMessage(REVERT_NOTES, isTTY)
+
+	author := wc.CurrentAuthor(time.Now())
+	commit := database.NewCommit(parents, wc.WriteTree().Oid(), author, author, message)
+	wc.repo.Database.Store(commit)
+	wc.repo.Refs.UpdateHead(commit.Oid(), "revert: "+commit.TitleLine())
+	wc.PendingCommit().Clear(repository.Revert)
+	return nil
+}
+
 func (wc *WriteCommit) composeMergeMessage(notes string, isTTY bool) string {
 	path := wc.CommitMessagePath()
 	return editor.EditFile(path, wc.editorCmd(path), isTTY, func(e *editor.Editor) {
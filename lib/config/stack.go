@@ -45,6 +45,9 @@ func (s *Stack) Get(key []string) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(all) == 0 {
+		return nil, nil
+	}
 	return all[len(all)-1], nil
 }
 
@@ -2,6 +2,7 @@ package database
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -31,7 +32,7 @@ func ParseAuthor(s string) (*Author, error) {
 	parts = strings.Split(parts[1], ">")
 	email := strings.TrimSpace(parts[0])
 
-	t, err := time.Parse(timeFormat, strings.TrimSpace(parts[1]))
+	t, err := parseTime(strings.TrimSpace(parts[1]))
 	if err != nil {
 		return nil, err
 	}
@@ -39,6 +40,25 @@ func ParseAuthor(s string) (*Author, error) {
 	return &Author{name, email, t}, nil
 }
 
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(timeFormat, s)
+	if err == nil {
+		return t, nil
+	}
+
+	// Objects written by git store the time as "<unix seconds> <zone offset>"
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return t, err
+	}
+	seconds, serr := strconv.ParseInt(fields[0], 10, 64)
+	zone, zerr := time.Parse("-0700", fields[1])
+	if serr != nil || zerr != nil {
+		return t, err
+	}
+	return time.Unix(seconds, 0).In(zone.Location()), nil
+}
+
 func (a *Author) ShortDate() string {
 	return a.time.Format("2006-01-02")
 }
@@ -47,6 +67,95 @@ func (a *Author) ReadableTime() string {
 	return a.time.Format("Mon Jan 2 15:04:05 2006 -0700")
 }
 
+// DATE_MODES lists the styles --date accepts, mapped to the layout each one
+// formats with. Styles without a layout are computed rather than formatted.
+var DATE_MODES = map[string]string{
+	"default":        "Mon Jan 2 15:04:05 2006 -0700",
+	"iso":            "2006-01-02 15:04:05 -0700",
+	"iso8601":        "2006-01-02 15:04:05 -0700",
+	"iso-strict":     "2006-01-02T15:04:05-07:00",
+	"iso8601-strict": "2006-01-02T15:04:05-07:00",
+	"rfc":            "Mon, 2 Jan 2006 15:04:05 -0700",
+	"rfc2822":        "Mon, 2 Jan 2006 15:04:05 -0700",
+	"short":          "2006-01-02",
+	"raw":            "",
+	"unix":           "",
+	"relative":       "",
+}
+
+// FormatTime renders the time in one of the DATE_MODES, falling back to the
+// same style as ReadableTime.
+func (a *Author) FormatTime(mode string) string {
+	switch mode {
+	case "raw":
+		return fmt.Sprintf("%d %s", a.time.Unix(), a.time.Format("-0700"))
+	case "unix":
+		return strconv.FormatInt(a.time.Unix(), 10)
+	case "relative":
+		return RelativeTime(a.time, time.Now())
+	}
+
+	if layout := DATE_MODES[mode]; layout != "" {
+		return a.time.Format(layout)
+	}
+	return a.ReadableTime()
+}
+
+// RelativeTime describes how long before now t was, rounding the same way
+// git does as the unit grows from seconds to years.
+func RelativeTime(t, now time.Time) string {
+	diff := int64(now.Sub(t) / time.Second)
+	if diff < 0 {
+		return "in the future"
+	}
+
+	if diff < 90 {
+		return timeAgo(diff, "second")
+	}
+	diff = (diff + 30) / 60
+	if diff < 90 {
+		return timeAgo(diff, "minute")
+	}
+	diff = (diff + 30) / 60
+	if diff < 36 {
+		return timeAgo(diff, "hour")
+	}
+	diff = (diff + 12) / 24
+	if diff < 14 {
+		return timeAgo(diff, "day")
+	}
+	if diff < 70 {
+		return timeAgo((diff+3)/7, "week")
+	}
+	if diff < 365 {
+		return timeAgo((diff+15)/30, "month")
+	}
+	if diff < 1825 {
+		total := (diff*12*2 + 365) / (365 * 2)
+		years, months := total/12, total%12
+		if months == 0 {
+			return timeAgo(years, "year")
+		}
+		return fmt.Sprintf("%s, %s", unitCount(years, "year"), timeAgo(months, "month"))
+	}
+	return timeAgo((diff+183)/365, "year")
+}
+
+func timeAgo(n int64, unit string) string {
+	return unitCount(n, unit) + " ago"
+}
+
+func unitCount(n int64, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
 func (a *Author) String() string {
 	return fmt.Sprintf("%s <%s> %s", a.Name, a.Email, a.time.Format(timeFormat))
 }
+
+func (a *Author) Time() time.Time {
+	return a.time
+}
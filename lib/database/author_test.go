@@ -0,0 +1,54 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	examples := []struct {
+		ago      time.Duration
+		expected string
+	}{
+		{time.Second, "1 second ago"},
+		{89 * time.Second, "89 seconds ago"},
+		{90 * time.Second, "2 minutes ago"},
+		{3 * time.Hour, "3 hours ago"},
+		{35 * time.Hour, "35 hours ago"},
+		{36 * time.Hour, "2 days ago"},
+		{20 * 24 * time.Hour, "3 weeks ago"},
+		{100 * 24 * time.Hour, "3 months ago"},
+		{400 * 24 * time.Hour, "1 year, 1 month ago"},
+		{730 * 24 * time.Hour, "2 years ago"},
+		{3000 * 24 * time.Hour, "8 years ago"},
+		{-time.Hour, "in the future"},
+	}
+
+	for _, example := range examples {
+		if got := RelativeTime(now.Add(-example.ago), now); got != example.expected {
+			t.Errorf("want %q, but got %q", example.expected, got)
+		}
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	author := NewAuthor("A. U. Thor", "author@example.com", time.Date(2023, 4, 1, 12, 0, 0, 0, time.FixedZone("", -5*60*60)))
+
+	examples := map[string]string{
+		"":           "Sat Apr 1 12:00:00 2023 -0500",
+		"iso":        "2023-04-01 12:00:00 -0500",
+		"iso-strict": "2023-04-01T12:00:00-05:00",
+		"rfc":        "Sat, 1 Apr 2023 12:00:00 -0500",
+		"short":      "2023-04-01",
+		"raw":        "1680368400 -0500",
+		"unix":       "1680368400",
+	}
+
+	for mode, expected := range examples {
+		if got := author.FormatTime(mode); got != expected {
+			t.Errorf("want %q, but got %q", expected, got)
+		}
+	}
+}
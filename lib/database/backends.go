@@ -0,0 +1,200 @@
+package database
+
+import (
+	"building-git/lib/pack"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type backend interface {
+	Has(oid string) bool
+	LoadRaw(oid string) (*pack.Record, error)
+	PrefixMatch(name string) ([]string, error)
+}
+
+type Backends struct {
+	pathname string
+	loose    *Loose
+	packed   []*Packed
+	loaded   bool
+}
+
+func NewBackends(pathname string) *Backends {
+	return &Backends{
+		pathname: pathname,
+		loose:    NewLoose(pathname),
+	}
+}
+
+func (b *Backends) PackPath() string {
+	return filepath.Join(b.pathname, "pack")
+}
+
+func (b *Backends) Has(oid string) bool {
+	for _, store := range b.stores() {
+		if store.Has(oid) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Backends) LoadRaw(oid string) (*pack.Record, error) {
+	if record := b.loadRaw(oid); record != nil {
+		return record, nil
+	}
+
+	b.Reload()
+	if record := b.loadRaw(oid); record != nil {
+		return record, nil
+	}
+	return nil, fmt.Errorf("object %s not found", oid)
+}
+
+func (b *Backends) PrefixMatch(name string) ([]string, error) {
+	seen := map[string]bool{}
+	oids := []string{}
+
+	for _, store := range b.stores() {
+		matches, err := store.PrefixMatch(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, oid := range matches {
+			if !seen[oid] {
+				seen[oid] = true
+				oids = append(oids, oid)
+			}
+		}
+	}
+	return oids, nil
+}
+
+func (b *Backends) WriteObject(oid string, content []byte) error {
+	if b.Has(oid) {
+		return nil
+	}
+	return b.loose.WriteObject(oid, content)
+}
+
+func (b *Backends) Reload() {
+	b.packed = nil
+	b.loaded = false
+}
+
+func (b *Backends) PrunePacked() (int, error) {
+	b.Reload()
+
+	oids, err := b.loose.Oids()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, oid := range oids {
+		if !b.isPacked(oid) {
+			continue
+		}
+		if err := b.loose.Remove(oid); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (b *Backends) RemoveRedundantPacks(keep string) error {
+	b.Reload()
+
+	var kept *Packed
+	for _, packed := range b.packs() {
+		if packed.packPath == keep {
+			kept = packed
+		}
+	}
+	if kept == nil {
+		return fmt.Errorf("pack %s not found", keep)
+	}
+
+	for _, packed := range b.packed {
+		if packed == kept || !packed.isContainedIn(kept) {
+			continue
+		}
+		if err := packed.remove(); err != nil {
+			return err
+		}
+	}
+
+	b.Reload()
+	return nil
+}
+
+func (b *Backends) isPacked(oid string) bool {
+	for _, packed := range b.packs() {
+		if packed.Has(oid) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Backends) loadRaw(oid string) *pack.Record {
+	for _, store := range b.stores() {
+		if !store.Has(oid) {
+			continue
+		}
+		record, err := store.LoadRaw(oid)
+		if err == nil {
+			return record
+		}
+	}
+	return nil
+}
+
+func (b *Backends) stores() []backend {
+	stores := []backend{b.loose}
+	for _, packed := range b.packs() {
+		stores = append(stores, packed)
+	}
+	return stores
+}
+
+func (b *Backends) packs() []*Packed {
+	if !b.loaded {
+		b.packed = b.loadPacks()
+		b.loaded = true
+	}
+	return b.packed
+}
+
+func (b *Backends) loadPacks() []*Packed {
+	paths, _ := filepath.Glob(filepath.Join(b.PackPath(), "*.pack"))
+
+	type packFile struct {
+		path  string
+		mtime int64
+	}
+	files := []packFile{}
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		files = append(files, packFile{path, stat.ModTime().UnixNano()})
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].mtime > files[j].mtime
+	})
+
+	packed := []*Packed{}
+	for _, file := range files {
+		p, err := NewPacked(file.path)
+		if err != nil {
+			continue
+		}
+		packed = append(packed, p)
+	}
+	return packed
+}
@@ -137,6 +137,10 @@ func (c *Commit) Author() *Author {
 	return c.author
 }
 
+func (c *Commit) Committer() *Author {
+	return c.committer
+}
+
 func (c *Commit) Message() string {
 	return c.message
 }
@@ -2,13 +2,11 @@ package database
 
 import (
 	"bufio"
+	"building-git/lib/pack"
 	"bytes"
-	"compress/zlib"
 	"crypto/sha1"
 	"fmt"
-	"io/ioutil"
 	"math/rand"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -17,6 +15,7 @@ import (
 type Database struct {
 	pathname string
 	objects  map[string]GitObject
+	backend  *Backends
 }
 
 type GitObject interface {
@@ -30,6 +29,7 @@ func NewDatabase(pathname string) *Database {
 	return &Database{
 		pathname: pathname,
 		objects:  map[string]GitObject{},
+		backend:  NewBackends(pathname),
 	}
 }
 
@@ -41,7 +41,7 @@ func (d *Database) Store(object GitObject) error {
 	}
 
 	object.SetOid(oid)
-	d.writeObject(object.Oid(), cont)
+	d.backend.WriteObject(object.Oid(), cont)
 	return nil
 }
 
@@ -58,6 +58,53 @@ func (d *Database) Load(oid string) (GitObject, error) {
 	return obj, nil
 }
 
+func (d *Database) Peel(oid string) (GitObject, error) {
+	object, err := d.Load(oid)
+	for err == nil {
+		tag, ok := object.(*Tag)
+		if !ok {
+			break
+		}
+		object, err = d.Load(tag.Object())
+	}
+	return object, err
+}
+
+func (d *Database) Has(oid string) bool {
+	return d.backend.Has(oid)
+}
+
+func (d *Database) StoreRaw(record *pack.Record) error {
+	content := record.Content()
+	oid, err := d.hashContent(content)
+	if err != nil {
+		return err
+	}
+
+	record.SetOid(oid)
+	return d.backend.WriteObject(oid, content)
+}
+
+func (d *Database) LoadRaw(oid string) (*pack.Record, error) {
+	return d.backend.LoadRaw(oid)
+}
+
+func (d *Database) PackPath() string {
+	return d.backend.PackPath()
+}
+
+func (d *Database) Reload() {
+	d.backend.Reload()
+}
+
+func (d *Database) PrunePacked() (int, error) {
+	return d.backend.PrunePacked()
+}
+
+func (d *Database) RemoveRedundantPacks(keep string) error {
+	return d.backend.RemoveRedundantPacks(keep)
+}
+
 func (d *Database) HashObject(object GitObject) (string, error) {
 	return d.hashContent(d.serializeObject(object))
 }
@@ -122,24 +169,7 @@ func (d *Database) ShortOid(oid string) string {
 }
 
 func (d *Database) PrefixMatch(name string) ([]string, error) {
-	dirname := filepath.Dir(d.objectPath(name))
-	files, err := ioutil.ReadDir(dirname)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return []string{}, nil
-		}
-		return nil, err
-	}
-
-	var oids []string
-	for _, file := range files {
-		oid := filepath.Base(dirname) + file.Name()
-		if strings.HasPrefix(oid, name) {
-			oids = append(oids, oid)
-		}
-	}
-
-	return oids, nil
+	return d.backend.PrefixMatch(name)
 }
 
 func (d *Database) TreeDiff(a, b string, filter *PathFilter) map[string][2]TreeObject {
@@ -168,85 +198,26 @@ func (d *Database) hashContent(content []byte) (string, error) {
 	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
 
-func (d *Database) objectPath(oid string) string {
-	return filepath.Join(d.pathname, oid[:2], oid[2:])
-}
-
-func (d *Database) writeObject(oid string, content []byte) error {
-	objectPath := d.objectPath(oid)
-	dirname := filepath.Dir(objectPath)
-	tempPath := filepath.Join(dirname, generateTempName())
-
-	if _, err := os.Stat(objectPath); err == nil {
-		return nil
-	}
-
-	if _, err := os.Stat(dirname); os.IsNotExist(err) {
-		if err := os.MkdirAll(dirname, os.ModePerm); err != nil {
-			return err
-		}
-	}
-
-	// Create a new file with os.O_RDWR|os.O_CREATE|os.O_EXCL flags
-	file, err := os.OpenFile(tempPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	compressor, err := zlib.NewWriterLevel(file, zlib.BestSpeed)
-	if err != nil {
-		return err
-	}
-	if _, err := compressor.Write(content); err != nil {
-		return err
-	}
-	if err := compressor.Close(); err != nil {
-		return err
-	}
-	if err := file.Close(); err != nil {
-		return err
-	}
-
-	if err := os.Rename(file.Name(), objectPath); err != nil {
-		return err
-	}
-	return nil
-}
-
 func (d *Database) readObject(oid string) (GitObject, error) {
-	data, err := ioutil.ReadFile(d.objectPath(oid))
-	if err != nil {
-		return nil, err
-	}
-
-	reader := bytes.NewReader(data)
-	zr, err := zlib.NewReader(reader)
-	if err != nil {
-		return nil, err
-	}
-	defer zr.Close()
-
-	bufReader := bufio.NewReader(zr)
-	line, err := bufReader.ReadString(' ')
+	record, err := d.backend.LoadRaw(oid)
 	if err != nil {
 		return nil, err
 	}
 
-	objectType := strings.TrimSpace(string(line))
-	size, err := bufReader.ReadString(0)
-	size = strings.TrimRight(size, "\x00")
+	bufReader := bufio.NewReader(bytes.NewReader(record.Data))
 
 	var object GitObject
-	switch objectType {
+	switch record.Type {
 	case "blob":
 		object = ParseBlob(bufReader)
 	case "tree":
 		object, err = ParseTree(bufReader)
 	case "commit":
 		object, err = ParseCommit(bufReader)
+	case "tag":
+		object, err = ParseTag(bufReader)
 	default:
-		return nil, fmt.Errorf("unrecognized object type: %s", objectType)
+		return nil, fmt.Errorf("unrecognized object type: %s", record.Type)
 	}
 
 	if err != nil {
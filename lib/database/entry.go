@@ -27,3 +27,10 @@ func (e *Entry) IsTree() bool {
 func (e *Entry) IsNil() bool {
 	return e == nil
 }
+
+func (e *Entry) Type() string {
+	if e.IsTree() {
+		return "tree"
+	}
+	return "blob"
+}
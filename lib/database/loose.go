@@ -0,0 +1,169 @@
+package database
+
+import (
+	"bufio"
+	"building-git/lib/pack"
+	"bytes"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type Loose struct {
+	pathname string
+}
+
+func NewLoose(pathname string) *Loose {
+	return &Loose{
+		pathname: pathname,
+	}
+}
+
+func (l *Loose) Has(oid string) bool {
+	_, err := os.Stat(l.objectPath(oid))
+	return err == nil
+}
+
+func (l *Loose) LoadRaw(oid string) (*pack.Record, error) {
+	data, err := ioutil.ReadFile(l.objectPath(oid))
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	bufReader := bufio.NewReader(zr)
+	objectType, err := bufReader.ReadString(' ')
+	if err != nil {
+		return nil, err
+	}
+	size, err := bufReader.ReadString(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := strconv.Atoi(strings.TrimRight(size, "\x00")); err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(bufReader)
+	if err != nil {
+		return nil, err
+	}
+
+	record := pack.NewRecord(strings.TrimSpace(objectType), content)
+	record.SetOid(oid)
+	return record, nil
+}
+
+func (l *Loose) PrefixMatch(name string) ([]string, error) {
+	dirname := filepath.Dir(l.objectPath(name))
+	files, err := ioutil.ReadDir(dirname)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	var oids []string
+	for _, file := range files {
+		oid := filepath.Base(dirname) + file.Name()
+		if strings.HasPrefix(oid, name) {
+			oids = append(oids, oid)
+		}
+	}
+
+	return oids, nil
+}
+
+func (l *Loose) WriteObject(oid string, content []byte) error {
+	objectPath := l.objectPath(oid)
+	dirname := filepath.Dir(objectPath)
+	tempPath := filepath.Join(dirname, generateTempName())
+
+	if _, err := os.Stat(objectPath); err == nil {
+		return nil
+	}
+
+	if _, err := os.Stat(dirname); os.IsNotExist(err) {
+		if err := os.MkdirAll(dirname, os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	// Create a new file with os.O_RDWR|os.O_CREATE|os.O_EXCL flags
+	file, err := os.OpenFile(tempPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	compressor, err := zlib.NewWriterLevel(file, zlib.BestSpeed)
+	if err != nil {
+		return err
+	}
+	if _, err := compressor.Write(content); err != nil {
+		return err
+	}
+	if err := compressor.Close(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(file.Name(), objectPath); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Loose) objectPath(oid string) string {
+	return filepath.Join(l.pathname, oid[:2], oid[2:])
+}
+
+func (l *Loose) Oids() ([]string, error) {
+	dirs, err := ioutil.ReadDir(l.pathname)
+	if err != nil {
+		return nil, err
+	}
+
+	oids := []string{}
+	for _, dir := range dirs {
+		if !dir.IsDir() || !pack.HEX_PREFIX.MatchString(dir.Name()) || len(dir.Name()) != 2 {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(l.pathname, dir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			oid := dir.Name() + file.Name()
+			if len(oid) == 40 && pack.HEX_PREFIX.MatchString(oid) {
+				oids = append(oids, oid)
+			}
+		}
+	}
+	return oids, nil
+}
+
+func (l *Loose) Remove(oid string) error {
+	objectPath := l.objectPath(oid)
+	if err := os.Remove(objectPath); err != nil {
+		return err
+	}
+
+	dirname := filepath.Dir(objectPath)
+	if files, err := ioutil.ReadDir(dirname); err == nil && len(files) == 0 {
+		os.Remove(dirname)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"building-git/lib/pack"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type Packed struct {
+	packPath string
+	index    *pack.Index
+}
+
+func NewPacked(packPath string) (*Packed, error) {
+	data, err := os.ReadFile(strings.TrimSuffix(packPath, ".pack") + ".idx")
+	if err != nil {
+		return nil, err
+	}
+	index, err := pack.NewIndex(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Packed{
+		packPath: packPath,
+		index:    index,
+	}, nil
+}
+
+func (p *Packed) Has(oid string) bool {
+	_, found := p.index.OidOffset(oid)
+	return found
+}
+
+func (p *Packed) LoadRaw(oid string) (*pack.Record, error) {
+	file, err := os.Open(p.packPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	record, err := p.loadRawByOid(file, oid)
+	if err != nil {
+		return nil, err
+	}
+	record.SetOid(oid)
+	return record, nil
+}
+
+func (p *Packed) PrefixMatch(name string) ([]string, error) {
+	return p.index.PrefixMatch(name), nil
+}
+
+func (p *Packed) loadRawByOid(file io.ReadSeeker, oid string) (*pack.Record, error) {
+	offset, found := p.index.OidOffset(oid)
+	if !found {
+		return nil, fmt.Errorf("object %s not found in %s", oid, p.packPath)
+	}
+	return p.loadRawAtOffset(file, offset)
+}
+
+func (p *Packed) loadRawAtOffset(file io.ReadSeeker, offset int64) (*pack.Record, error) {
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	reader := pack.NewReader(pack.NewStream(file, nil))
+	record, err := reader.ReadRecord()
+	if err != nil {
+		return nil, err
+	}
+
+	var base *pack.Record
+	var delta []byte
+
+	switch v := record.(type) {
+	case *pack.Record:
+		return v, nil
+	case *pack.OfsDelta:
+		base, err = p.loadRawAtOffset(file, offset-v.BaseOfs)
+		delta = v.DeltaData
+	case *pack.RefDelta:
+		base, err = p.loadRawByOid(file, v.BaseOid)
+		delta = v.DeltaData
+	default:
+		return nil, fmt.Errorf("unknown record type %T at offset %d in %s", record, offset, p.packPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := pack.Expand(base.Data, delta)
+	if err != nil {
+		return nil, err
+	}
+	return pack.NewRecord(base.Type, data), nil
+}
+
+func (p *Packed) isContainedIn(other *Packed) bool {
+	for _, oid := range p.index.Oids() {
+		if !other.Has(oid) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Packed) remove() error {
+	if err := os.Remove(strings.TrimSuffix(p.packPath, ".pack") + ".idx"); err != nil {
+		return err
+	}
+	return os.Remove(p.packPath)
+}
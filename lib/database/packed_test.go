@@ -0,0 +1,124 @@
+package database
+
+import (
+	"building-git/lib/pack"
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePack(t *testing.T, db *Database, oids []string, options pack.WriterOption) string {
+	t.Helper()
+
+	var packData, indexData bytes.Buffer
+	writer := pack.NewWriter(&packData, db, options)
+	for _, oid := range oids {
+		if err := writer.Add(oid, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.WriteObjects(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteIndex(&indexData); err != nil {
+		t.Fatal(err)
+	}
+
+	os.MkdirAll(db.PackPath(), 0755)
+	basename := filepath.Join(db.PackPath(), "pack-"+hex.EncodeToString(writer.Checksum()))
+	if err := os.WriteFile(basename+".idx", indexData.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(basename+".pack", packData.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return basename + ".pack"
+}
+
+func TestPacked(t *testing.T) {
+	var setUp = func(t *testing.T, options pack.WriterOption) (objectsDir string, blobs []*Blob, packed *Packed) {
+		objectsDir = t.TempDir()
+		db := NewDatabase(objectsDir)
+
+		lines := []string{}
+		for i := 0; i < 200; i++ {
+			lines = append(lines, strings.Repeat("line of text ", i%7+1))
+		}
+		text := strings.Join(lines, "\n")
+		for _, data := range []string{text, text + "\nmore", "a small file\n"} {
+			blob := NewBlob(data)
+			db.Store(blob)
+			blobs = append(blobs, blob)
+		}
+
+		oids := []string{}
+		for _, blob := range blobs {
+			oids = append(oids, blob.Oid())
+		}
+		packPath := writePack(t, db, oids, options)
+
+		packed, err := NewPacked(packPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	for name, options := range map[string]pack.WriterOption{
+		"offset": {AllowOfs: true},
+		"ref":    {AllowOfs: false},
+	} {
+		t.Run("loads every object written to the pack with "+name+" deltas", func(t *testing.T) {
+			_, blobs, packed := setUp(t, options)
+
+			for _, blob := range blobs {
+				if !packed.Has(blob.Oid()) {
+					t.Errorf("want the pack to have %s", blob.Oid())
+				}
+				record, err := packed.LoadRaw(blob.Oid())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if record.Type != "blob" || string(record.Data) != blob.data {
+					t.Errorf("want %q, but got %s %q", blob.data, record.Type, record.Data)
+				}
+			}
+		})
+	}
+
+	t.Run("loads packed objects through the database", func(t *testing.T) {
+		objectsDir, blobs, _ := setUp(t, pack.WriterOption{AllowOfs: true})
+
+		db := NewDatabase(objectsDir)
+		if _, err := db.PrunePacked(); err != nil {
+			t.Fatal(err)
+		}
+		db.Reload()
+
+		for _, blob := range blobs {
+			object, err := db.Load(blob.Oid())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := object.String(); got != blob.data {
+				t.Errorf("want %q, but got %q", blob.data, got)
+			}
+		}
+	})
+
+	t.Run("does not find malformed object IDs", func(t *testing.T) {
+		_, _, packed := setUp(t, pack.WriterOption{AllowOfs: true})
+
+		for _, oid := range []string{"", "a", "zz", "not-an-object-id", strings.Repeat("g", 40)} {
+			if packed.Has(oid) {
+				t.Errorf("want %q not to be found", oid)
+			}
+			if _, err := packed.LoadRaw(oid); err == nil {
+				t.Errorf("want an error loading %q", oid)
+			}
+		}
+	})
+}
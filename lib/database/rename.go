@@ -0,0 +1,261 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	MAX_RENAME_SCORE     = 100
+	DEFAULT_RENAME_SCORE = 50
+)
+
+type RenameOption struct {
+	Copies    bool
+	Threshold int
+}
+
+type Rename struct {
+	OldPath string
+	NewPath string
+	Old     TreeObject
+	New     TreeObject
+	Score   int
+	Copy    bool
+}
+
+// ParseRenameScore reads the argument of -M/-C the way git does: a trailing
+// "%" gives a percentage, otherwise the digits are a decimal fraction, so
+// "5" means 50% and "05" means 5%.
+func ParseRenameScore(arg string) (int, error) {
+	if arg == "" {
+		return DEFAULT_RENAME_SCORE, nil
+	}
+
+	if strings.HasSuffix(arg, "%") {
+		n, err := strconv.Atoi(strings.TrimSuffix(arg, "%"))
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid rename score '%s'", arg)
+		}
+		if n > MAX_RENAME_SCORE {
+			n = MAX_RENAME_SCORE
+		}
+		return n, nil
+	}
+
+	if _, err := strconv.ParseUint(arg, 10, 64); err != nil {
+		return 0, fmt.Errorf("invalid rename score '%s'", arg)
+	}
+	score, _ := strconv.ParseFloat("0."+arg, 64)
+	return int(score * MAX_RENAME_SCORE), nil
+}
+
+type renameCandidate struct {
+	source string
+	target string
+	score  int
+}
+
+type RenameDetector struct {
+	database *Database
+	options  RenameOption
+	changes  map[string][2]TreeObject
+	sources  []string
+	targets  []string
+	used     map[string]bool
+	renames  map[string]*Rename
+	lines    map[string]map[string]int
+	sizes    map[string]int
+}
+
+func NewRenameDetector(database *Database, changes map[string][2]TreeObject, options RenameOption) *RenameDetector {
+	if options.Threshold <= 0 {
+		options.Threshold = DEFAULT_RENAME_SCORE
+	}
+	return &RenameDetector{
+		database: database,
+		options:  options,
+		changes:  changes,
+		used:     map[string]bool{},
+		renames:  map[string]*Rename{},
+		lines:    map[string]map[string]int{},
+		sizes:    map[string]int{},
+	}
+}
+
+// DetectRenames pairs deleted paths with added ones and returns the changes
+// with each pair collapsed onto its new path, along with the renames keyed
+// by that path. Copies keep their source in the changes.
+func (d *Database) DetectRenames(changes map[string][2]TreeObject, options RenameOption) (map[string][2]TreeObject, map[string]*Rename) {
+	return NewRenameDetector(d, changes, options).Detect()
+}
+
+func (r *RenameDetector) Detect() (map[string][2]TreeObject, map[string]*Rename) {
+	r.collectPaths()
+	r.findExactRenames()
+	r.findInexactRenames()
+
+	result := map[string][2]TreeObject{}
+	for path, images := range r.changes {
+		result[path] = images
+	}
+	for path, rename := range r.renames {
+		if !rename.Copy {
+			delete(result, rename.OldPath)
+		}
+		result[path] = [2]TreeObject{rename.Old, rename.New}
+	}
+	return result, r.renames
+}
+
+func (r *RenameDetector) collectPaths() {
+	for path, images := range r.changes {
+		oldItem, newItem := images[0], images[1]
+		switch {
+		case isMissing(oldItem) && !isMissing(newItem):
+			r.targets = append(r.targets, path)
+		case !isMissing(oldItem) && isMissing(newItem):
+			r.sources = append(r.sources, path)
+		case !isMissing(oldItem) && r.options.Copies:
+			r.sources = append(r.sources, path)
+		}
+	}
+	sort.Strings(r.sources)
+	sort.Strings(r.targets)
+}
+
+func (r *RenameDetector) findExactRenames() {
+	for _, target := range r.targets {
+		newItem := r.changes[target][1]
+		if r.isEmpty(newItem) {
+			continue
+		}
+
+		best := ""
+		for _, source := range r.sources {
+			if r.changes[source][0].Oid() != newItem.Oid() || !r.isAvailable(source) {
+				continue
+			}
+			if best == "" || filepath.Base(source) == filepath.Base(target) && filepath.Base(best) != filepath.Base(target) {
+				best = source
+			}
+		}
+		if best != "" {
+			r.record(best, target, MAX_RENAME_SCORE)
+		}
+	}
+}
+
+func (r *RenameDetector) findInexactRenames() {
+	candidates := []renameCandidate{}
+
+	for _, target := range r.targets {
+		if _, ok := r.renames[target]; ok || r.isEmpty(r.changes[target][1]) {
+			continue
+		}
+		for _, source := range r.sources {
+			if !r.isAvailable(source) || r.isEmpty(r.changes[source][0]) {
+				continue
+			}
+			score := r.similarity(r.changes[source][0].Oid(), r.changes[target][1].Oid())
+			if score >= r.options.Threshold {
+				candidates = append(candidates, renameCandidate{source, target, score})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		return sameBasename(a) && !sameBasename(b)
+	})
+
+	for _, candidate := range candidates {
+		if _, ok := r.renames[candidate.target]; ok || !r.isAvailable(candidate.source) {
+			continue
+		}
+		r.record(candidate.source, candidate.target, candidate.score)
+	}
+}
+
+func (r *RenameDetector) record(source, target string, score int) {
+	deleted := isMissing(r.changes[source][1])
+
+	r.renames[target] = &Rename{
+		OldPath: source,
+		NewPath: target,
+		Old:     r.changes[source][0],
+		New:     r.changes[target][1],
+		Score:   score,
+		Copy:    !deleted || r.used[source],
+	}
+	if deleted {
+		r.used[source] = true
+	}
+}
+
+func (r *RenameDetector) isAvailable(source string) bool {
+	return r.options.Copies || !r.used[source]
+}
+
+func (r *RenameDetector) isEmpty(item TreeObject) bool {
+	r.loadLines(item.Oid())
+	return r.sizes[item.Oid()] == 0
+}
+
+// similarity scores how much of the larger blob is made of lines shared with
+// the other one, as a percentage.
+func (r *RenameDetector) similarity(a, b string) int {
+	if a == b {
+		return MAX_RENAME_SCORE
+	}
+
+	aLines, bLines := r.loadLines(a), r.loadLines(b)
+	common := 0
+	for line, count := range aLines {
+		if other := bLines[line]; other < count {
+			common += len(line) * other
+		} else {
+			common += len(line) * count
+		}
+	}
+
+	size := r.sizes[a]
+	if r.sizes[b] > size {
+		size = r.sizes[b]
+	}
+	return common * MAX_RENAME_SCORE / size
+}
+
+func (r *RenameDetector) loadLines(oid string) map[string]int {
+	if lines, ok := r.lines[oid]; ok {
+		return lines
+	}
+
+	lines := map[string]int{}
+	data := ""
+	if object, err := r.database.Load(oid); err == nil {
+		data = object.String()
+	}
+	for _, line := range strings.SplitAfter(data, "\n") {
+		if line != "" {
+			lines[line]++
+		}
+	}
+	r.lines[oid] = lines
+	r.sizes[oid] = len(data)
+	return lines
+}
+
+func sameBasename(candidate renameCandidate) bool {
+	return filepath.Base(candidate.source) == filepath.Base(candidate.target)
+}
+
+func isMissing(item TreeObject) bool {
+	return item == nil || item.IsNil()
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"os"
+	"testing"
+)
+
+func detectRenames(a, b string, options RenameOption) (map[string][2]TreeObject, map[string]*Rename) {
+	db := NewDatabase(testDir)
+	return db.DetectRenames(treeDiff(a, b), options)
+}
+
+func TestDetectRenames(t *testing.T) {
+	os.MkdirAll(testDir, 0755)
+	defer os.RemoveAll(testDir)
+
+	t.Run("pairs a moved file with its old path", func(t *testing.T) {
+		treeA := storeTree(map[string]string{
+			"lib/alice.txt": "alice\n",
+			"bob.txt":       "bob\n",
+		})
+		treeB := storeTree(map[string]string{
+			"src/alice.txt": "alice\n",
+			"bob.txt":       "bob\n",
+		})
+
+		changes, renames := detectRenames(treeA, treeB, RenameOption{})
+
+		if len(changes) != 1 {
+			t.Errorf("want 1 change, but got %v", changes)
+		}
+		rename := renames["src/alice.txt"]
+		if rename == nil || rename.OldPath != "lib/alice.txt" || rename.Score != 100 || rename.Copy {
+			t.Errorf("want a 100%% rename from lib/alice.txt, but got %+v", rename)
+		}
+	})
+
+	t.Run("pairs files with similar contents", func(t *testing.T) {
+		treeA := storeTree(map[string]string{
+			"a.txt": "1\n2\n3\n4\n5\n",
+		})
+		treeB := storeTree(map[string]string{
+			"b.txt": "1\n2\n3\n4\nfive\n",
+		})
+
+		_, renames := detectRenames(treeA, treeB, RenameOption{})
+
+		rename := renames["b.txt"]
+		if rename == nil || rename.OldPath != "a.txt" || rename.Score != 61 {
+			t.Errorf("want a 61%% rename from a.txt, but got %+v", rename)
+		}
+	})
+
+	t.Run("leaves files below the threshold unpaired", func(t *testing.T) {
+		treeA := storeTree(map[string]string{
+			"a.txt": "1\n2\n3\n4\n5\n",
+		})
+		treeB := storeTree(map[string]string{
+			"b.txt": "1\n2\n3\n4\nfive\n",
+		})
+
+		changes, renames := detectRenames(treeA, treeB, RenameOption{Threshold: 70})
+
+		if len(renames) != 0 || len(changes) != 2 {
+			t.Errorf("want no renames, but got %v", renames)
+		}
+	})
+
+	t.Run("detects copies of modified files", func(t *testing.T) {
+		treeA := storeTree(map[string]string{
+			"a.txt": "1\n2\n3\n",
+		})
+		treeB := storeTree(map[string]string{
+			"a.txt": "1\n2\n3\n4\n",
+			"b.txt": "1\n2\n3\n",
+		})
+
+		_, renames := detectRenames(treeA, treeB, RenameOption{})
+		if len(renames) != 0 {
+			t.Errorf("want no renames without copies, but got %v", renames)
+		}
+
+		changes, renames := detectRenames(treeA, treeB, RenameOption{Copies: true})
+		rename := renames["b.txt"]
+		if rename == nil || rename.OldPath != "a.txt" || !rename.Copy {
+			t.Errorf("want a copy from a.txt, but got %+v", rename)
+		}
+		if _, ok := changes["a.txt"]; !ok {
+			t.Errorf("want the copy source to stay in the changes, but got %v", changes)
+		}
+	})
+}
+
+func TestParseRenameScore(t *testing.T) {
+	tests := map[string]int{
+		"":    50,
+		"90%": 90,
+		"5":   50,
+		"05":  5,
+		"75":  75,
+	}
+
+	for arg, expected := range tests {
+		score, err := ParseRenameScore(arg)
+		if err != nil || score != expected {
+			t.Errorf("%q: want %d, but got %d (%v)", arg, expected, score, err)
+		}
+	}
+
+	if _, err := ParseRenameScore("x"); err == nil {
+		t.Errorf("want an error for an invalid score")
+	}
+}
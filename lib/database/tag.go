@@ -0,0 +1,123 @@
+package database
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+type Tag struct {
+	oid     string
+	object  string
+	otype   string
+	tag     string
+	tagger  *Author
+	message string
+}
+
+func NewTag(object, otype, tag string, tagger *Author, message string) *Tag {
+	return &Tag{
+		object:  object,
+		otype:   otype,
+		tag:     tag,
+		tagger:  tagger,
+		message: message,
+	}
+}
+
+func ParseTag(reader *bufio.Reader) (*Tag, error) {
+	headers := make(map[string]string)
+	message := ""
+
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSuffix(line, "\n")
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		if line == "" {
+			messageBytes, err := reader.ReadBytes('\x00')
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			message = string(messageBytes)
+			break
+		}
+
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) == 1 {
+			parts = append(parts, "")
+		}
+		headers[parts[0]] = parts[1]
+	}
+
+	var tagger *Author
+	if headers["tagger"] != "" {
+		var err error
+		tagger, err = ParseAuthor(headers["tagger"])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewTag(
+		headers["object"],
+		headers["type"],
+		headers["tag"],
+		tagger,
+		message), nil
+}
+
+func (t *Tag) Type() string {
+	return "tag"
+}
+
+func (t Tag) String() string {
+	lines := []string{
+		"object " + t.object,
+		"type " + t.otype,
+		"tag " + t.tag,
+	}
+	if t.tagger != nil {
+		lines = append(lines, "tagger "+t.tagger.String())
+	}
+	lines = append(lines, "", t.message)
+
+	return strings.Join(lines, "\n")
+}
+
+func (t *Tag) Oid() string {
+	return t.oid
+}
+
+func (t *Tag) SetOid(oid string) {
+	t.oid = oid
+}
+
+func (t *Tag) Object() string {
+	return t.object
+}
+
+func (t *Tag) ObjectType() string {
+	return t.otype
+}
+
+func (t *Tag) Name() string {
+	return t.tag
+}
+
+func (t *Tag) Tagger() *Author {
+	return t.tagger
+}
+
+func (t *Tag) Message() string {
+	return t.message
+}
+
+func (t *Tag) TitleLine() string {
+	return strings.Split(t.message, "\n")[0]
+}
@@ -1,6 +1,32 @@
 package diff
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
+
+type Algorithm string
+
+const (
+	MYERS     Algorithm = "myers"
+	MINIMAL   Algorithm = "minimal"
+	PATIENCE  Algorithm = "patience"
+	HISTOGRAM Algorithm = "histogram"
+)
+
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch name {
+	case "default", "myers":
+		return MYERS, nil
+	case "minimal":
+		return MINIMAL, nil
+	case "patience":
+		return PATIENCE, nil
+	case "histogram":
+		return HISTOGRAM, nil
+	}
+	return "", fmt.Errorf("option diff-algorithm accepts \"myers\", \"minimal\", \"patience\" and \"histogram\"")
+}
 
 type Line struct {
 	Number int
@@ -80,25 +106,77 @@ func lines(s string) []*Line {
 }
 
 func Diff(a, b string) []Diffable {
-	m := &Myers{
-		a: lines(a),
-		b: lines(b),
+	return DiffWith(MYERS, a, b)
+}
+
+func DiffWith(algorithm Algorithm, a, b string) []Diffable {
+	return diffLines(algorithm, lines(a), lines(b))
+}
+
+func diffLines(algorithm Algorithm, a, b []*Line) []Diffable {
+	switch algorithm {
+	case PATIENCE:
+		return (&Patience{a: a, b: b}).diff()
+	case HISTOGRAM:
+		return (&Histogram{a: a, b: b}).diff()
+	case MINIMAL:
+		return (&Myers{a: a, b: b, minimal: true}).diff()
 	}
-	return m.diff()
+	return (&Myers{a: a, b: b}).diff()
 }
 
 func DiffHunk(a, b string) []*Hunk {
-	return HunkFilter(Diff(a, b))
+	return DiffHunkWith(MYERS, a, b)
+}
+
+func DiffHunkWith(algorithm Algorithm, a, b string) []*Hunk {
+	return HunkFilter(DiffWith(algorithm, a, b))
 }
 
 func DiffCombined(as []string, b string) []Diffable {
+	return DiffCombinedWith(MYERS, as, b)
+}
+
+func DiffCombinedWith(algorithm Algorithm, as []string, b string) []Diffable {
 	diffs := [][]Diffable{}
 	for _, a := range as {
-		diffs = append(diffs, Diff(a, b))
+		diffs = append(diffs, DiffWith(algorithm, a, b))
 	}
 	return NewCombined(diffs).ToSlice()
 }
 
 func DiffCombinedHunks(as []string, b string) []*Hunk {
-	return HunkFilter(DiffCombined(as, b))
+	return DiffCombinedHunksWith(MYERS, as, b)
+}
+
+func DiffCombinedHunksWith(algorithm Algorithm, as []string, b string) []*Hunk {
+	return HunkFilter(DiffCombinedWith(algorithm, as, b))
+}
+
+// splitCommon peels off the lines a and b start and end with, which every
+// algorithm reports as unchanged, and returns the differing middle.
+func splitCommon(a, b []*Line) (head []Diffable, midA, midB []*Line, tail []Diffable) {
+	start := 0
+	for start < len(a) && start < len(b) && a[start].Text == b[start].Text {
+		head = append(head, NewEdit(EQL, a[start], b[start]))
+		start++
+	}
+
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && a[endA-1].Text == b[endB-1].Text {
+		endA, endB = endA-1, endB-1
+	}
+	for i := 0; endA+i < len(a); i++ {
+		tail = append(tail, NewEdit(EQL, a[endA+i], b[endB+i]))
+	}
+
+	return head, a[start:endA], b[start:endB], tail
+}
+
+// fallback diffs a region the other algorithms cannot split any further.
+func fallback(a, b []*Line) []Diffable {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	return (&Myers{a: a, b: b}).diff()
 }
@@ -3,6 +3,7 @@ package diff
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -145,3 +146,79 @@ func TestDiffHunk(t *testing.T) {
 		}
 	})
 }
+
+func TestDiffAlgorithms(t *testing.T) {
+	diffLines := func(algorithm Algorithm, a, b string) []string {
+		result := []string{}
+		for _, edit := range DiffWith(algorithm, a, b) {
+			result = append(result, edit.String())
+		}
+		return result
+	}
+
+	t.Run("keeps a rewritten block together instead of matching a lone brace", func(t *testing.T) {
+		a := "a {\n  a()\n}\n\nb {\n  b()\n}\n"
+		b := "c {\n  c()\n}\n"
+		expected := []string{"-a {\n", "-  a()\n", "-}\n", "-\n", "-b {\n", "-  b()\n", "+c {\n", "+  c()\n", " }\n"}
+
+		for _, algorithm := range []Algorithm{PATIENCE, HISTOGRAM} {
+			result := diffLines(algorithm, a, b)
+			if !reflect.DeepEqual(result, expected) {
+				t.Errorf("DiffWith(%s) = %v, want %v", algorithm, result, expected)
+			}
+		}
+	})
+
+	t.Run("histogram anchors on the least common lines", func(t *testing.T) {
+		result := diffLines(HISTOGRAM, "A\nB\nC\nA\nB\nB\nA\n", "C\nB\nA\nB\nA\nC\n")
+		expected := []string{"-A\n", "-B\n", " C\n", "-A\n", "-B\n", " B\n", " A\n", "+B\n", "+A\n", "+C\n"}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("want %v, but got %v", expected, result)
+		}
+	})
+
+	t.Run("every algorithm reproduces both inputs", func(t *testing.T) {
+		inputs := [][2]string{
+			{"A\nB\nC\nA\nB\nB\nA\n", "C\nB\nA\nB\nA\nC\n"},
+			{"}\nfoo\n}\nbar\n}\n", "bar\n}\nfoo\n}\n"},
+			{"", "added\n"},
+			{"removed\n", ""},
+			{"", ""},
+		}
+
+		for _, algorithm := range []Algorithm{MYERS, PATIENCE, HISTOGRAM} {
+			for _, input := range inputs {
+				var a, b strings.Builder
+				for _, edit := range DiffWith(algorithm, input[0], input[1]) {
+					if edit.Type() != INS {
+						a.WriteString(edit.ALine().Text)
+					}
+					if edit.Type() != DEL {
+						b.WriteString(edit.BLine().Text)
+					}
+				}
+				if a.String() != input[0] || b.String() != input[1] {
+					t.Errorf("DiffWith(%s, %q, %q) rebuilt %q and %q", algorithm, input[0], input[1], a.String(), b.String())
+				}
+			}
+		}
+	})
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	for name, expected := range map[string]Algorithm{
+		"default":   MYERS,
+		"minimal":   MINIMAL,
+		"myers":     MYERS,
+		"patience":  PATIENCE,
+		"histogram": HISTOGRAM,
+	} {
+		if algorithm, err := ParseAlgorithm(name); err != nil || algorithm != expected {
+			t.Errorf("ParseAlgorithm(%q) = %q, %v, want %q", name, algorithm, err, expected)
+		}
+	}
+
+	if _, err := ParseAlgorithm("fastest"); err == nil {
+		t.Errorf("want an error for an unknown algorithm")
+	}
+}
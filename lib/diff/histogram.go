@@ -0,0 +1,77 @@
+package diff
+
+const MAX_CHAIN_LENGTH = 64
+
+type Histogram struct {
+	a []*Line
+	b []*Line
+}
+
+func (h *Histogram) diff() []Diffable {
+	head, a, b, tail := splitCommon(h.a, h.b)
+	if len(a) == 0 || len(b) == 0 {
+		return append(append(head, fallback(a, b)...), tail...)
+	}
+
+	x, y, n := h.findCommonRegion(a, b)
+	if n == 0 {
+		return append(append(head, fallback(a, b)...), tail...)
+	}
+
+	diff := append(head, (&Histogram{a: a[:x], b: b[:y]}).diff()...)
+	for i := 0; i < n; i++ {
+		diff = append(diff, NewEdit(EQL, a[x+i], b[y+i]))
+	}
+	diff = append(diff, (&Histogram{a: a[x+n:], b: b[y+n:]}).diff()...)
+	return append(diff, tail...)
+}
+
+// findCommonRegion looks for the longest run of lines shared by a and b,
+// preferring runs built from lines that occur least often in a. Lines that
+// repeat more than MAX_CHAIN_LENGTH times are never used as a starting
+// point, and Myers takes over if nothing else matches.
+func (h *Histogram) findCommonRegion(a, b []*Line) (int, int, int) {
+	positions := map[string][]int{}
+	for i, line := range a {
+		positions[line.Text] = append(positions[line.Text], i)
+	}
+
+	bestX, bestY, bestN := 0, 0, 0
+	lowest := MAX_CHAIN_LENGTH + 1
+
+	for y := 0; y < len(b); {
+		next := y + 1
+		for _, x := range positions[b[y].Text] {
+			if len(positions[b[y].Text]) > lowest {
+				break
+			}
+
+			startX, startY := x, y
+			for startX > 0 && startY > 0 && a[startX-1].Text == b[startY-1].Text {
+				startX, startY = startX-1, startY-1
+			}
+			endX, endY := x+1, y+1
+			for endX < len(a) && endY < len(b) && a[endX].Text == b[endY].Text {
+				endX, endY = endX+1, endY+1
+			}
+
+			count := lowest
+			for _, line := range a[startX:endX] {
+				if n := len(positions[line.Text]); n < count {
+					count = n
+				}
+			}
+
+			if count < lowest || (count == lowest && endX-startX > bestN) {
+				bestX, bestY, bestN = startX, startY, endX-startX
+				lowest = count
+			}
+			if endY > next {
+				next = endY
+			}
+		}
+		y = next
+	}
+
+	return bestX, bestY, bestN
+}
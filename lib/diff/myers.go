@@ -1,98 +1,304 @@
 package diff
 
+import "math"
+
+// MIN_COST_LIMIT is the smallest number of edits the middle snake search will
+// explore before settling for the furthest point it has reached. Larger inputs
+// get a limit of roughly the square root of their size.
+const MIN_COST_LIMIT = 256
+
+// MAX_TRACE_SIZE is the largest region, in lines from both sides, that is
+// diffed by keeping every step of the search rather than by splitting it.
+const MAX_TRACE_SIZE = 1024
+
 type Myers struct {
-	a []*Line
-	b []*Line
+	a         []*Line
+	b         []*Line
+	minimal   bool
+	costLimit int
+}
+
+type box struct {
+	left, top, right, bottom int
+}
+
+func (b box) width() int {
+	return b.right - b.left
+}
+
+func (b box) height() int {
+	return b.bottom - b.top
+}
+
+func (b box) size() int {
+	return b.width() + b.height()
+}
+
+func (b box) delta() int {
+	return b.width() - b.height()
+}
+
+type point struct {
+	x, y int
 }
 
 func (m *Myers) diff() []Diffable {
 	diff := []Diffable{}
 
-	m.backtrack(func(prev_x, prev_y, x, y int) {
-		if x == prev_x {
-			bLine := m.b[prev_y]
-			diff = append(diff, NewEdit(INS, nil, bLine))
-		} else if y == prev_y {
-			aLine := m.a[prev_x]
-			diff = append(diff, NewEdit(DEL, aLine, nil))
+	m.costLimit = int(math.Sqrt(float64(len(m.a) + len(m.b))))
+	if m.costLimit < MIN_COST_LIMIT {
+		m.costLimit = MIN_COST_LIMIT
+	}
+	if m.minimal {
+		// No edit script is longer than both inputs together, so the search
+		// never stops short of the shortest one.
+		m.costLimit = len(m.a) + len(m.b)
+	}
+
+	m.diffBox(box{0, 0, len(m.a), len(m.b)}, func(x1, y1, x2, y2 int) {
+		if x1 == x2 {
+			diff = append(diff, NewEdit(INS, nil, m.b[y1]))
+		} else if y1 == y2 {
+			diff = append(diff, NewEdit(DEL, m.a[x1], nil))
 		} else {
-			aLine := m.a[prev_x]
-			bLine := m.b[prev_y]
-			diff = append(diff, NewEdit(EQL, aLine, bLine))
+			diff = append(diff, NewEdit(EQL, m.a[x1], m.b[y1]))
 		}
 	})
 
-	for i := 0; i < len(diff)/2; i++ {
-		diff[i], diff[len(diff)-i-1] = diff[len(diff)-i-1], diff[i]
-	}
 	return diff
 }
 
-func (s *Myers) backtrack(fn func(int, int, int, int)) {
-	x, y := len(s.a), len(s.b)
-	max := len(s.a) + len(s.b)
+// diffBox splits the box at its middle snake and recurses into the regions
+// before and after it. Once a region is small enough, keeping the full trace
+// of the greedy search is cheap, so it is solved directly instead.
+func (m *Myers) diffBox(b box, fn func(int, int, int, int)) {
+	if b.size() <= MAX_TRACE_SIZE {
+		m.backtrack(b, fn)
+		return
+	}
 
-	trace := s.shortestEdit()
+	start, finish, ok := m.midpoint(b)
+	if !ok {
+		return
+	}
+
+	m.diffBox(box{b.left, b.top, start.x, start.y}, fn)
+	m.walkSnake(start, finish, fn)
+	m.diffBox(box{finish.x, finish.y, b.right, b.bottom}, fn)
+}
+
+// walkSnake emits the single insertion or deletion joining start to finish,
+// along with the unchanged lines on either side of it.
+func (m *Myers) walkSnake(start, finish point, fn func(int, int, int, int)) {
+	x1, y1 := m.walkDiagonal(start.x, start.y, finish.x, finish.y, fn)
+
+	switch {
+	case finish.x-x1 < finish.y-y1:
+		fn(x1, y1, x1, y1+1)
+		y1++
+	case finish.x-x1 > finish.y-y1:
+		fn(x1, y1, x1+1, y1)
+		x1++
+	}
+
+	m.walkDiagonal(x1, y1, finish.x, finish.y, fn)
+}
+
+func (m *Myers) walkDiagonal(x1, y1, x2, y2 int, fn func(int, int, int, int)) (int, int) {
+	for x1 < x2 && y1 < y2 && m.a[x1].Text == m.b[y1].Text {
+		fn(x1, y1, x1+1, y1+1)
+		x1, y1 = x1+1, y1+1
+	}
+	return x1, y1
+}
+
+func (m *Myers) backtrack(b box, fn func(int, int, int, int)) {
+	if b.size() == 0 {
+		return
+	}
+
+	moves := [][4]int{}
+	x, y := b.width(), b.height()
+
+	trace := m.shortestEdit(b)
 
 	for d := len(trace) - 1; d >= 0; d-- {
 		v := trace[d]
 		k := x - y
 
 		var prevK int
-		if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
+		if k == -d || (k != d && v[d+k] < v[d+k+2]) {
 			prevK = k + 1
 		} else {
 			prevK = k - 1
 		}
 
-		prevX := v[max+prevK]
+		prevX := v[d+1+prevK]
 		prevY := prevX - prevK
 
 		for x > prevX && y > prevY {
-			fn(x-1, y-1, x, y)
+			moves = append(moves, [4]int{x - 1, y - 1, x, y})
 			x--
 			y--
 		}
 
 		if d > 0 {
-			fn(prevX, prevY, x, y)
+			moves = append(moves, [4]int{prevX, prevY, x, y})
 			x, y = prevX, prevY
 		}
 	}
+
+	for i := len(moves) - 1; i >= 0; i-- {
+		move := moves[i]
+		fn(b.left+move[0], b.top+move[1], b.left+move[2], b.top+move[3])
+	}
 }
 
-func (s *Myers) shortestEdit() [][]int {
-	n, m := len(s.a), len(s.b)
-	max := n + m
-	v := make([]int, 2*max+1)
-	v[max] = 0
+// shortestEdit runs the greedy forward search over the box, recording for
+// each cost d the furthest reaching x on diagonals -(d+1) to d+1.
+func (m *Myers) shortestEdit(b box) [][]int {
+	n, o := b.width(), b.height()
+	max := n + o
+	v := make([]int, 2*max+3)
 	var trace [][]int
 
 	for d := 0; d <= max; d++ {
-		vCopy := make([]int, len(v))
-		copy(vCopy, v)
-		trace = append(trace, vCopy)
+		trace = append(trace, append([]int{}, v[max-d:max+d+3]...))
 
 		for k := -d; k <= d; k += 2 {
 			var x int
-			if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
-				x = v[max+k+1]
+			if k == -d || (k != d && v[max+k] < v[max+k+2]) {
+				x = v[max+k+2]
 			} else {
-				x = v[max+k-1] + 1
+				x = v[max+k] + 1
 			}
 
 			y := x - k
 
-			for x < n && y < m && s.a[x].Text == s.b[y].Text {
+			for x < n && y < o && m.a[b.left+x].Text == m.b[b.top+y].Text {
 				x, y = x+1, y+1
 			}
 
-			v[max+k] = x
+			v[max+k+1] = x
 
-			if x >= n && y >= m {
+			if x >= n && y >= o {
 				return trace
 			}
 		}
 	}
 	return [][]int{}
 }
+
+func (m *Myers) midpoint(b box) (point, point, bool) {
+	if b.size() == 0 {
+		return point{}, point{}, false
+	}
+
+	max := (b.size() + 1) / 2
+	if max > m.costLimit+1 {
+		max = m.costLimit + 1
+	}
+	offset := max + 1
+	vf := make([]int, 2*offset+1)
+	vb := make([]int, 2*offset+1)
+	vf[offset+1] = b.left
+	vb[offset+1] = b.bottom
+
+	for d := 0; d <= max; d++ {
+		if d > m.costLimit {
+			p := m.furthestPoint(b, vf, offset, d-1)
+			return p, p, true
+		}
+		if start, finish, ok := m.forwards(b, vf, vb, offset, d); ok {
+			return start, finish, true
+		}
+		if start, finish, ok := m.backward(b, vf, vb, offset, d); ok {
+			return start, finish, true
+		}
+	}
+
+	return point{}, point{}, false
+}
+
+func (m *Myers) forwards(b box, vf, vb []int, offset, d int) (point, point, bool) {
+	for k := d; k >= -d; k -= 2 {
+		c := k - b.delta()
+
+		var x, px int
+		if k == -d || (k != d && vf[offset+k-1] < vf[offset+k+1]) {
+			x = vf[offset+k+1]
+			px = x
+		} else {
+			px = vf[offset+k-1]
+			x = px + 1
+		}
+
+		y := b.top + (x - b.left) - k
+		py := y
+		if d != 0 && x == px {
+			py = y - 1
+		}
+
+		for x < b.right && y < b.bottom && m.a[x].Text == m.b[y].Text {
+			x, y = x+1, y+1
+		}
+
+		vf[offset+k] = x
+
+		if b.delta()%2 != 0 && c >= -(d-1) && c <= d-1 && y >= vb[offset+c] {
+			return point{px, py}, point{x, y}, true
+		}
+	}
+	return point{}, point{}, false
+}
+
+func (m *Myers) backward(b box, vf, vb []int, offset, d int) (point, point, bool) {
+	for c := d; c >= -d; c -= 2 {
+		k := c + b.delta()
+
+		var y, py int
+		if c == -d || (c != d && vb[offset+c-1] > vb[offset+c+1]) {
+			y = vb[offset+c+1]
+			py = y
+		} else {
+			py = vb[offset+c-1]
+			y = py - 1
+		}
+
+		x := b.left + (y - b.top) + k
+		px := x
+		if d != 0 && y == py {
+			px = x + 1
+		}
+
+		for x > b.left && y > b.top && m.a[x-1].Text == m.b[y-1].Text {
+			x, y = x-1, y-1
+		}
+
+		vb[offset+c] = y
+
+		if b.delta()%2 == 0 && k >= -d && k <= d && x <= vf[offset+k] {
+			return point{x, y}, point{px, py}, true
+		}
+	}
+	return point{}, point{}, false
+}
+
+// furthestPoint is used once the search has gone past the cost limit: rather
+// than keep looking for the true middle snake, it splits the box at the point
+// the forward search has pushed furthest into it.
+func (m *Myers) furthestPoint(b box, vf []int, offset, d int) point {
+	best := point{b.left, b.top}
+
+	for k := d; k >= -d; k -= 2 {
+		x := vf[offset+k]
+		y := b.top + (x - b.left) - k
+		if x > b.right || y > b.bottom || y < b.top {
+			continue
+		}
+		if x+y > best.x+best.y {
+			best = point{x, y}
+		}
+	}
+	return best
+}
@@ -0,0 +1,111 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func generateFile(random *rand.Rand, lines, vocabulary int) []string {
+	file := make([]string, lines)
+	for i := range file {
+		file[i] = fmt.Sprintf("line %d\n", random.Intn(vocabulary))
+	}
+	return file
+}
+
+func editFile(random *rand.Rand, file []string, changes int) []string {
+	edited := []string{}
+	for _, line := range file {
+		if random.Intn(len(file)) < changes {
+			switch random.Intn(3) {
+			case 0:
+				continue
+			case 1:
+				edited = append(edited, fmt.Sprintf("added %d\n", random.Int()))
+			case 2:
+				line = fmt.Sprintf("changed %d\n", random.Int())
+			}
+		}
+		edited = append(edited, line)
+	}
+	return edited
+}
+
+func TestMyersLargeInputs(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	a := generateFile(random, 5000, 100)
+	b := editFile(random, a, 50)
+
+	var oldFile, newFile strings.Builder
+	for _, edit := range Diff(strings.Join(a, ""), strings.Join(b, "")) {
+		if edit.Type() != INS {
+			oldFile.WriteString(edit.ALine().Text)
+		}
+		if edit.Type() != DEL {
+			newFile.WriteString(edit.BLine().Text)
+		}
+	}
+
+	if oldFile.String() != strings.Join(a, "") || newFile.String() != strings.Join(b, "") {
+		t.Errorf("want the edits to rebuild both files")
+	}
+}
+
+func TestMyersMinimal(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	aLines := generateFile(random, 2000, 100)
+	a, b := strings.Join(aLines, ""), strings.Join(editFile(random, aLines, 1000), "")
+
+	changes := map[Algorithm]int{}
+	for _, algorithm := range []Algorithm{MYERS, MINIMAL} {
+		var oldFile, newFile strings.Builder
+		for _, edit := range DiffWith(algorithm, a, b) {
+			if edit.Type() != INS {
+				oldFile.WriteString(edit.ALine().Text)
+			}
+			if edit.Type() != DEL {
+				newFile.WriteString(edit.BLine().Text)
+			}
+			if edit.Type() != EQL {
+				changes[algorithm]++
+			}
+		}
+		if oldFile.String() != a || newFile.String() != b {
+			t.Errorf("want the %s edits to rebuild both files", algorithm)
+		}
+	}
+
+	if changes[MINIMAL] >= changes[MYERS] {
+		t.Errorf("want fewer than %d changes, but got %d", changes[MYERS], changes[MINIMAL])
+	}
+}
+
+func benchmarkDiff(b *testing.B, lines, changes int) {
+	random := rand.New(rand.NewSource(1))
+	old := generateFile(random, lines, lines/10+1)
+	new := editFile(random, old, changes)
+	oldData, newData := strings.Join(old, ""), strings.Join(new, "")
+
+	for _, algorithm := range []Algorithm{MYERS, PATIENCE, HISTOGRAM} {
+		b.Run(string(algorithm), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				DiffWith(algorithm, oldData, newData)
+			}
+		})
+	}
+}
+
+func BenchmarkDiffSmallChangesInLargeFile(b *testing.B) {
+	benchmarkDiff(b, 100000, 100)
+}
+
+func BenchmarkDiffManyChangesInLargeFile(b *testing.B) {
+	benchmarkDiff(b, 20000, 5000)
+}
+
+func BenchmarkDiffRewrittenLargeFile(b *testing.B) {
+	benchmarkDiff(b, 20000, 20000)
+}
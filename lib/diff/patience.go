@@ -0,0 +1,86 @@
+package diff
+
+import "sort"
+
+type Patience struct {
+	a []*Line
+	b []*Line
+}
+
+func (p *Patience) diff() []Diffable {
+	head, a, b, tail := splitCommon(p.a, p.b)
+	if len(a) == 0 || len(b) == 0 {
+		return append(append(head, fallback(a, b)...), tail...)
+	}
+
+	anchors := p.uniqueAnchors(a, b)
+	if len(anchors) == 0 {
+		return append(append(head, fallback(a, b)...), tail...)
+	}
+
+	diff := head
+	x, y := 0, 0
+	for _, anchor := range anchors {
+		diff = append(diff, (&Patience{a: a[x:anchor[0]], b: b[y:anchor[1]]}).diff()...)
+		diff = append(diff, NewEdit(EQL, a[anchor[0]], b[anchor[1]]))
+		x, y = anchor[0]+1, anchor[1]+1
+	}
+	diff = append(diff, (&Patience{a: a[x:], b: b[y:]}).diff()...)
+	return append(diff, tail...)
+}
+
+// uniqueAnchors finds the lines that occur exactly once on each side and
+// returns the longest run of them that appears in the same order in both.
+func (p *Patience) uniqueAnchors(a, b []*Line) [][2]int {
+	counts := map[string][2]int{}
+	positions := map[string][2]int{}
+	for i, line := range a {
+		count := counts[line.Text]
+		counts[line.Text] = [2]int{count[0] + 1, count[1]}
+		positions[line.Text] = [2]int{i, positions[line.Text][1]}
+	}
+	for i, line := range b {
+		count := counts[line.Text]
+		counts[line.Text] = [2]int{count[0], count[1] + 1}
+		positions[line.Text] = [2]int{positions[line.Text][0], i}
+	}
+
+	matches := [][2]int{}
+	for i, line := range a {
+		if count := counts[line.Text]; count[0] == 1 && count[1] == 1 {
+			matches = append(matches, [2]int{i, positions[line.Text][1]})
+		}
+	}
+	return longestIncreasing(matches)
+}
+
+// longestIncreasing picks the longest subsequence of matches, already in
+// order of their position in a, whose positions in b also increase.
+func longestIncreasing(matches [][2]int) [][2]int {
+	piles := []int{}
+	previous := make([]int, len(matches))
+
+	for i, match := range matches {
+		n := sort.Search(len(piles), func(j int) bool {
+			return matches[piles[j]][1] > match[1]
+		})
+		previous[i] = -1
+		if n > 0 {
+			previous[i] = piles[n-1]
+		}
+		if n == len(piles) {
+			piles = append(piles, i)
+		} else {
+			piles[n] = i
+		}
+	}
+
+	if len(piles) == 0 {
+		return nil
+	}
+	result := make([][2]int, len(piles))
+	for i, n := len(piles)-1, piles[len(piles)-1]; n >= 0; i, n = i-1, previous[n] {
+		result[i] = matches[n]
+	}
+	return result
+}
@@ -97,11 +97,20 @@ func (e *Editor) EditFile() string {
 func (e *Editor) removeNotes(s string) string {
 	lines := strings.Split(s, "\n")
 	var result []string
+	blank := false
 	for _, line := range lines {
-		if !strings.HasPrefix(line, "#") &&
-			strings.TrimSpace(line) != "" {
-			result = append(result, line)
+		if strings.HasPrefix(line, "#") {
+			continue
 		}
+		if strings.TrimSpace(line) == "" {
+			blank = len(result) > 0
+			continue
+		}
+		if blank {
+			result = append(result, "")
+			blank = false
+		}
+		result = append(result, line)
 	}
 	return strings.Join(result, "\n") + "\n"
 }
@@ -158,7 +158,7 @@ func (i *Index) ChildPaths(path string) []string {
 }
 
 func (i *Index) IsTrackedFile(path string) bool {
-	for _, stage := range []string{"0", "1", "2"} {
+	for _, stage := range []string{"0", "1", "2", "3"} {
 		_, existsInEntries := i.entries[[2]string{path, stage}]
 		if existsInEntries {
 			return true
@@ -213,7 +213,7 @@ func (i *Index) discardConflicts(entry *Entry) {
 }
 
 func (i *Index) removeEntry(pathname string) {
-	for _, stage := range []string{"0", "1", "2"} {
+	for _, stage := range []string{"0", "1", "2", "3"} {
 		i.removeEntryWithStage(pathname, stage)
 	}
 }
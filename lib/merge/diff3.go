@@ -89,11 +89,16 @@ func (r *Result) String(aName, bName string) string {
 }
 
 func Merge(o, a, b interface{}) *Result {
+	return MergeWith(diff.MYERS, o, a, b)
+}
+
+func MergeWith(algorithm diff.Algorithm, o, a, b interface{}) *Result {
 	oLines := convertToLines(o)
 	aLines := convertToLines(a)
 	bLines := convertToLines(b)
 
 	diff3 := NewDiff3(oLines, aLines, bLines)
+	diff3.Algorithm = algorithm
 	return diff3.Merge()
 }
 
@@ -114,6 +119,7 @@ func convertToLines(input interface{}) []string {
 
 type Diff3 struct {
 	O, A, B             []string
+	Algorithm           diff.Algorithm
 	chunks              []chunk
 	matchA, matchB      map[int]int
 	lineO, lineA, lineB int
@@ -141,7 +147,7 @@ func (d *Diff3) setup() {
 
 func (d *Diff3) matchSet(file []string) map[int]int {
 	matches := make(map[int]int)
-	diffs := diff.Diff(strings.Join(d.O, "\n"), strings.Join(file, "\n"))
+	diffs := diff.DiffWith(d.Algorithm, strings.Join(d.O, "\n"), strings.Join(file, "\n"))
 	for _, edit := range diffs {
 		if edit.Type() == diff.EQL {
 			matches[edit.ALine().Number] = edit.BLine().Number
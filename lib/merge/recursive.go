@@ -0,0 +1,108 @@
+package merge
+
+import (
+	"building-git/lib/database"
+	"building-git/lib/index"
+	"building-git/lib/repository"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+const (
+	RESOLVE   = "resolve"
+	RECURSIVE = "recursive"
+)
+
+var STRATEGIES = []string{RECURSIVE, RESOLVE}
+
+func IsStrategy(name string) bool {
+	for _, strategy := range STRATEGIES {
+		if name == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+type Recursive struct {
+	repo *repository.Repository
+}
+
+func NewRecursive(repo *repository.Repository) *Recursive {
+	return &Recursive{
+		repo: repo,
+	}
+}
+
+func (r *Recursive) MergeBases(oids []string) (string, error) {
+	if len(oids) == 0 {
+		return "", nil
+	}
+
+	base := oids[0]
+	for _, other := range oids[1:] {
+		merged, err := r.mergeVirtual(base, other)
+		if err != nil {
+			return "", err
+		}
+		base = merged
+	}
+	return base, nil
+}
+
+func (r *Recursive) mergeVirtual(left, right string) (string, error) {
+	bases := NewBases(r.repo.Database, left, right).Find()
+	if len(bases) > 1 {
+		virtual, err := r.MergeBases(bases)
+		if err != nil {
+			return "", err
+		}
+		bases = []string{virtual}
+	}
+
+	inputs := NewCherryPick(r.repo, "Temporary merge branch 1", "Temporary merge branch 2", left, right, bases)
+	resolve := NewResolve(r.repo, inputs, func(fn func() string) {})
+	if err := resolve.prepareTreeDiffs(); err != nil {
+		return "", err
+	}
+
+	entries := r.repo.Database.LoadTreeList(left, "")
+	for path, images := range resolve.cleanDiff {
+		if item := images[1]; item == nil || item.IsNil() {
+			delete(entries, path)
+		} else {
+			entries[path] = database.NewEntry(item.Oid(), item.Mode())
+		}
+	}
+
+	tree, err := r.writeTree(entries)
+	if err != nil {
+		return "", err
+	}
+
+	author := database.NewAuthor("jit", "jit@localhost", time.Unix(0, 0))
+	commit := database.NewCommit([]string{left, right}, tree.Oid(), author, author, "merged common ancestors\n")
+	if err := r.repo.Database.Store(commit); err != nil {
+		return "", err
+	}
+	return commit.Oid(), nil
+}
+
+func (r *Recursive) writeTree(entries map[string]*database.Entry) (*database.Tree, error) {
+	scratch := index.NewIndex(filepath.Join(r.repo.GitPath, "index.recursive"))
+	for path, entry := range entries {
+		scratch.AddFromDb(path, entry)
+	}
+
+	var err error
+	root := database.BuildTree(scratch.EachEntry())
+	root.Traverse(func(t database.TreeObject) {
+		if object, ok := t.(database.GitObject); ok && err == nil {
+			if storeErr := r.repo.Database.Store(object); storeErr != nil {
+				err = fmt.Errorf("could not write virtual merge base: %w", storeErr)
+			}
+		}
+	})
+	return root, err
+}
@@ -0,0 +1,138 @@
+package merge
+
+import (
+	"building-git/lib/database"
+	"fmt"
+	"sort"
+)
+
+func (r *Resolve) resolveRenames() {
+	_, leftRenames := r.repo.Database.DetectRenames(r.leftDiff, database.RenameOption{})
+	_, rightRenames := r.repo.Database.DetectRenames(r.rightDiff, database.RenameOption{})
+
+	leftSources := renamesBySource(leftRenames)
+	rightSources := renamesBySource(rightRenames)
+
+	sources := []string{}
+	for source := range leftSources {
+		sources = append(sources, source)
+	}
+	for source := range rightSources {
+		if _, ok := leftSources[source]; !ok {
+			sources = append(sources, source)
+		}
+	}
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		left, right := leftSources[source], rightSources[source]
+		switch {
+		case left != nil && right != nil && left.NewPath == right.NewPath:
+			r.mergeRenamed(left.NewPath, left.Old, left.New, right.New, left.New)
+			delete(r.rightDiff, source)
+			delete(r.rightDiff, right.NewPath)
+		case left != nil && right != nil:
+			r.renameRenameConflict(left, right)
+		case left != nil:
+			r.resolveLeftRename(left)
+		case right != nil:
+			r.resolveRightRename(right)
+		}
+	}
+}
+
+func renamesBySource(renames map[string]*database.Rename) map[string]*database.Rename {
+	sources := map[string]*database.Rename{}
+	for _, rename := range renames {
+		if !rename.Copy {
+			sources[rename.OldPath] = rename
+		}
+	}
+	return sources
+}
+
+// resolveLeftRename carries the right side's change to a file over to the
+// path the left side moved it to. The moved file is already in place, so
+// only its contents need merging.
+func (r *Resolve) resolveLeftRename(rename *database.Rename) {
+	images, changed := r.rightDiff[rename.OldPath]
+	if !changed {
+		return
+	}
+	delete(r.rightDiff, rename.OldPath)
+
+	if right := images[1]; right != nil && !right.IsNil() {
+		r.mergeRenamed(rename.NewPath, rename.Old, rename.New, right, rename.New)
+		return
+	}
+
+	r.conflicts[rename.NewPath] = [3]database.TreeObject{rename.Old, rename.New, nil}
+	r.logRenameDeleteConflict(rename, r.inputs.LeftName(), r.inputs.RightName())
+}
+
+// resolveRightRename moves the left side's version of a file to the path the
+// right side renamed it to, merging in any edits made on both sides.
+func (r *Resolve) resolveRightRename(rename *database.Rename) {
+	images, changed := r.leftDiff[rename.OldPath]
+	if !changed {
+		return
+	}
+	delete(r.rightDiff, rename.OldPath)
+	delete(r.rightDiff, rename.NewPath)
+
+	if left := images[1]; left != nil && !left.IsNil() {
+		r.cleanDiff[rename.OldPath] = [2]database.TreeObject{left, nil}
+		r.mergeRenamed(rename.NewPath, rename.Old, left, rename.New, nil)
+		return
+	}
+
+	r.cleanDiff[rename.NewPath] = [2]database.TreeObject{nil, rename.New}
+	r.conflicts[rename.NewPath] = [3]database.TreeObject{rename.Old, nil, rename.New}
+	r.logRenameDeleteConflict(rename, r.inputs.RightName(), r.inputs.LeftName())
+}
+
+func (r *Resolve) mergeRenamed(path string, base, left, right, current database.TreeObject) {
+	if left.Oid() == right.Oid() && left.Mode() == right.Mode() {
+		if current == nil {
+			r.cleanDiff[path] = [2]database.TreeObject{nil, right}
+		}
+		return
+	}
+
+	r.onProgress(func() string {
+		return fmt.Sprintf("Auto-merging %s", path)
+	})
+
+	oidOk, oid := r.mergeBlobs(base, left, right)
+	modeOk, mode := r.mergedModes(base, left, right)
+
+	r.cleanDiff[path] = [2]database.TreeObject{current, database.NewEntry(oid, mode)}
+	if oidOk && modeOk {
+		return
+	}
+
+	r.conflicts[path] = [3]database.TreeObject{base, left, right}
+	r.logLeftRightConflict(path)
+}
+
+func (r *Resolve) renameRenameConflict(left, right *database.Rename) {
+	delete(r.rightDiff, right.OldPath)
+	delete(r.rightDiff, right.NewPath)
+
+	r.cleanDiff[right.NewPath] = [2]database.TreeObject{nil, right.New}
+	r.conflicts[left.OldPath] = [3]database.TreeObject{left.Old, nil, nil}
+	r.conflicts[left.NewPath] = [3]database.TreeObject{nil, left.New, nil}
+	r.conflicts[right.NewPath] = [3]database.TreeObject{nil, nil, right.New}
+
+	r.onProgress(func() string {
+		return fmt.Sprintf("CONFLICT (rename/rename): %s renamed to %s in %s and to %s in %s.",
+			left.OldPath, left.NewPath, r.inputs.LeftName(), right.NewPath, r.inputs.RightName())
+	})
+}
+
+func (r *Resolve) logRenameDeleteConflict(rename *database.Rename, renamed, deleted string) {
+	r.onProgress(func() string {
+		return fmt.Sprintf("CONFLICT (rename/delete): %s renamed to %s in %s, but deleted in %s.",
+			rename.OldPath, rename.NewPath, renamed, deleted)
+	})
+}
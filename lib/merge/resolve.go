@@ -18,6 +18,7 @@ type Resolve struct {
 	conflicts  map[string][3]database.TreeObject
 	untracked  map[string]database.TreeObject
 	onProgress func(fn func() string)
+	strategy   string
 }
 
 type ResolveInputs interface {
@@ -33,11 +34,18 @@ func NewResolve(repo *repository.Repository, inputs ResolveInputs, onProgress fu
 		repo:       repo,
 		inputs:     inputs,
 		onProgress: onProgress,
+		strategy:   RESOLVE,
 	}
 }
 
+func (r *Resolve) SetStrategy(strategy string) {
+	r.strategy = strategy
+}
+
 func (r *Resolve) Execute() error {
-	r.prepareTreeDiffs()
+	if err := r.prepareTreeDiffs(); err != nil {
+		return err
+	}
 
 	migration := r.repo.Migration(r.cleanDiff)
 	if err := migration.ApplyChanges(); err != nil {
@@ -49,10 +57,10 @@ func (r *Resolve) Execute() error {
 	return nil
 }
 
-func (r *Resolve) prepareTreeDiffs() {
-	baseOid := ""
-	if len(r.inputs.BaseOids()) > 0 {
-		baseOid = r.inputs.BaseOids()[0]
+func (r *Resolve) prepareTreeDiffs() error {
+	baseOid, err := r.baseOid()
+	if err != nil {
+		return err
 	}
 
 	r.leftDiff = r.repo.Database.TreeDiff(baseOid, r.inputs.LeftOid(), nil)
@@ -61,6 +69,8 @@ func (r *Resolve) prepareTreeDiffs() {
 	r.conflicts = map[string][3]database.TreeObject{}
 	r.untracked = map[string]database.TreeObject{}
 
+	r.resolveRenames()
+
 	for path, images := range r.rightDiff {
 		oldItem, newItem := images[0], images[1]
 		if newItem != nil && !newItem.IsNil() {
@@ -74,6 +84,18 @@ func (r *Resolve) prepareTreeDiffs() {
 			r.fileDirConflict(path, r.rightDiff, r.inputs.RightName())
 		}
 	}
+	return nil
+}
+
+func (r *Resolve) baseOid() (string, error) {
+	baseOids := r.inputs.BaseOids()
+	switch {
+	case len(baseOids) == 0:
+		return "", nil
+	case len(baseOids) > 1 && r.strategy == RECURSIVE:
+		return NewRecursive(r.repo).MergeBases(baseOids)
+	}
+	return baseOids[0], nil
 }
 
 func (r *Resolve) samePathConflict(path string, base, right database.TreeObject) {
@@ -134,7 +156,7 @@ func (r *Resolve) mergeBlobs(base, left, right database.TreeObject) (bool, strin
 		blobs = append(blobs, obj.String())
 	}
 
-	merge := Merge(blobs[0], blobs[1], blobs[2])
+	merge := MergeWith(r.repo.DiffAlgorithm(), blobs[0], blobs[1], blobs[2])
 	data := merge.String(r.inputs.LeftName(), r.inputs.RightName())
 	blob := database.NewBlob(data)
 	r.repo.Database.Store(blob)
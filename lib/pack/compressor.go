@@ -0,0 +1,114 @@
+package pack
+
+import "sort"
+
+const (
+	MIN_OBJECT_SIZE = 50
+	MAX_OBJECT_SIZE = 0x20000000
+	MAX_DEPTH       = 50
+	WINDOW_SIZE     = 8
+)
+
+type Compressor struct {
+	database Loader
+	window   *Window
+	objects  []*Entry
+}
+
+func NewCompressor(database Loader) *Compressor {
+	return &Compressor{
+		database: database,
+		window:   NewWindow(WINDOW_SIZE),
+		objects:  []*Entry{},
+	}
+}
+
+func (c *Compressor) Add(entry *Entry) {
+	if entry.Size < MIN_OBJECT_SIZE || entry.Size > MAX_OBJECT_SIZE {
+		return
+	}
+	c.objects = append(c.objects, entry)
+}
+
+func (c *Compressor) BuildDeltas() error {
+	sort.SliceStable(c.objects, func(i, j int) bool {
+		return c.objects[i].less(c.objects[j])
+	})
+
+	for i := len(c.objects) - 1; i >= 0; i-- {
+		if err := c.buildDelta(c.objects[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Compressor) buildDelta(entry *Entry) error {
+	object, err := c.database.LoadRaw(entry.oid)
+	if err != nil {
+		return err
+	}
+	target := c.window.Add(entry, object.Data)
+
+	c.window.Each(func(source *Unpacked) {
+		c.tryDelta(source, target)
+	})
+	return nil
+}
+
+func (c *Compressor) tryDelta(source, target *Unpacked) {
+	if source.entry.Type != target.entry.Type {
+		return
+	}
+	if source.entry.depth >= MAX_DEPTH {
+		return
+	}
+
+	maxSize := c.maxSizeHeuristic(source, target)
+	if !c.compatibleSizes(source, target, maxSize) {
+		return
+	}
+
+	delta := NewDelta(source, target)
+	size := target.entry.PackedSize()
+
+	if delta.Size() > maxSize {
+		return
+	}
+	if delta.Size() == size && delta.base.depth+1 >= target.entry.depth {
+		return
+	}
+	target.entry.AssignDelta(delta)
+}
+
+func (c *Compressor) maxSizeHeuristic(source, target *Unpacked) int {
+	var maxSize, refDepth int
+
+	if target.entry.delta != nil {
+		maxSize = target.entry.delta.Size()
+		refDepth = target.entry.depth
+	} else {
+		maxSize = target.entry.Size/2 - 20
+		refDepth = 1
+	}
+
+	return maxSize * (MAX_DEPTH - source.entry.depth) / (MAX_DEPTH + 1 - refDepth)
+}
+
+func (c *Compressor) compatibleSizes(source, target *Unpacked, maxSize int) bool {
+	sizeDiff := target.entry.Size - source.entry.Size
+	if sizeDiff < 0 {
+		sizeDiff = 0
+	}
+
+	if maxSize <= 0 {
+		return false
+	}
+	if sizeDiff >= maxSize {
+		return false
+	}
+	if target.entry.Size < source.entry.Size/32 {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package pack
+
+import (
+	"bytes"
+	"io"
+)
+
+type DeltaOp interface {
+	Bytes() []byte
+}
+
+type Copy struct {
+	Offset int
+	Size   int
+}
+
+func ParseCopy(input io.ByteReader, header byte) (*Copy, error) {
+	value, err := ReadPackedInt56LE(input, header)
+	if err != nil {
+		return nil, err
+	}
+	offset := value & 0xffffffff
+	size := value >> 32
+	if size == 0 {
+		size = 0x10000
+	}
+	return &Copy{Offset: offset, Size: size}, nil
+}
+
+func (c *Copy) Bytes() []byte {
+	data := WritePackedInt56LE(c.Size<<32 | c.Offset)
+	data[0] |= 0x80
+	return data
+}
+
+type Insert struct {
+	Data []byte
+}
+
+func ParseInsert(input io.Reader, header byte) (*Insert, error) {
+	data := make([]byte, header)
+	if _, err := io.ReadFull(input, data); err != nil {
+		return nil, err
+	}
+	return &Insert{Data: data}, nil
+}
+
+func (i *Insert) Bytes() []byte {
+	return append([]byte{byte(len(i.Data))}, i.Data...)
+}
+
+func ParseDeltaOps(delta []byte) (int, int, []DeltaOp, error) {
+	input := bytes.NewReader(delta)
+
+	_, sourceSize, err := ReadVarIntLE(input, 7)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	_, targetSize, err := ReadVarIntLE(input, 7)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	ops := []DeltaOp{}
+	for input.Len() > 0 {
+		header, _ := input.ReadByte()
+
+		var op DeltaOp
+		if header >= 0x80 {
+			op, err = ParseCopy(input, header)
+		} else if header > 0 {
+			op, err = ParseInsert(input, header)
+		} else {
+			err = invalidPack("invalid delta opcode: 0")
+		}
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		ops = append(ops, op)
+	}
+	return sourceSize, targetSize, ops, nil
+}
+
+type Delta struct {
+	base *Entry
+	data []byte
+}
+
+func NewDelta(source, target *Unpacked) *Delta {
+	data := WriteVarIntLE(source.entry.Size, 7)
+	data = append(data, WriteVarIntLE(target.entry.Size, 7)...)
+
+	if source.deltaIndex == nil {
+		source.deltaIndex = CreateXDeltaIndex(source.data)
+	}
+	for _, op := range source.deltaIndex.Compress(target.data) {
+		data = append(data, op.Bytes()...)
+	}
+
+	return &Delta{
+		base: source.entry,
+		data: data,
+	}
+}
+
+func (d *Delta) Base() *Entry {
+	return d.base
+}
+
+func (d *Delta) Data() []byte {
+	return d.data
+}
+
+func (d *Delta) Size() int {
+	return len(d.data)
+}
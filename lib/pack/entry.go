@@ -0,0 +1,100 @@
+package pack
+
+import (
+	"encoding/hex"
+	"path/filepath"
+)
+
+type Entry struct {
+	oid    string
+	Type   string
+	Size   int
+	path   string
+	delta  *Delta
+	depth  int
+	ofs    bool
+	Offset int64
+	crc    uint32
+}
+
+func NewEntry(oid, etype string, size int, path string, ofs bool) *Entry {
+	return &Entry{
+		oid:  oid,
+		Type: etype,
+		Size: size,
+		path: path,
+		ofs:  ofs,
+	}
+}
+
+func (e *Entry) Oid() string {
+	return e.oid
+}
+
+func (e *Entry) Depth() int {
+	return e.depth
+}
+
+func (e *Entry) Delta() *Delta {
+	return e.delta
+}
+
+func (e *Entry) AssignDelta(delta *Delta) {
+	e.delta = delta
+	e.depth = delta.base.depth + 1
+}
+
+func (e *Entry) PackedType() int {
+	if e.delta == nil {
+		return TYPE_CODES[e.Type]
+	}
+	if e.ofs {
+		return OFS_DELTA
+	}
+	return REF_DELTA
+}
+
+func (e *Entry) PackedSize() int {
+	if e.delta == nil {
+		return e.Size
+	}
+	return e.delta.Size()
+}
+
+func (e *Entry) DeltaPrefix() []byte {
+	if e.delta == nil {
+		return []byte{}
+	}
+	if e.ofs {
+		return WriteVarIntBE(int(e.Offset - e.delta.base.Offset))
+	}
+	oid, _ := hex.DecodeString(e.delta.base.oid)
+	return oid
+}
+
+func (e *Entry) less(other *Entry) bool {
+	if e.PackedType() != other.PackedType() {
+		return e.PackedType() < other.PackedType()
+	}
+	if e.basename() != other.basename() {
+		return e.basename() < other.basename()
+	}
+	if e.dirname() != other.dirname() {
+		return e.dirname() < other.dirname()
+	}
+	return e.Size < other.Size
+}
+
+func (e *Entry) basename() string {
+	if e.path == "" {
+		return ""
+	}
+	return filepath.Base(e.path)
+}
+
+func (e *Entry) dirname() string {
+	if e.path == "" {
+		return ""
+	}
+	return filepath.Dir(e.path)
+}
@@ -0,0 +1,29 @@
+package pack
+
+func Expand(source, delta []byte) ([]byte, error) {
+	sourceSize, targetSize, ops, err := ParseDeltaOps(delta)
+	if err != nil {
+		return nil, err
+	}
+	if sourceSize != len(source) {
+		return nil, invalidPack("source size mismatch: expected %d, got %d", sourceSize, len(source))
+	}
+
+	target := make([]byte, 0, targetSize)
+	for _, op := range ops {
+		switch v := op.(type) {
+		case *Copy:
+			if v.Offset+v.Size > len(source) {
+				return nil, invalidPack("delta copy out of range: %d+%d", v.Offset, v.Size)
+			}
+			target = append(target, source[v.Offset:v.Offset+v.Size]...)
+		case *Insert:
+			target = append(target, v.Data...)
+		}
+	}
+
+	if len(target) != targetSize {
+		return nil, invalidPack("target size mismatch: expected %d, got %d", targetSize, len(target))
+	}
+	return target, nil
+}
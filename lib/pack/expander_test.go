@@ -0,0 +1,55 @@
+package pack
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	source := []byte("the quick brown fox jumps over the lazy dog\n")
+
+	delta := []byte{}
+	delta = append(delta, WriteVarIntLE(len(source), 7)...)
+	delta = append(delta, WriteVarIntLE(39, 7)...)
+	delta = append(delta, (&Copy{Offset: 0, Size: 16}).Bytes()...)
+	delta = append(delta, (&Insert{Data: []byte("cat")}).Bytes()...)
+	delta = append(delta, (&Copy{Offset: 19, Size: 20}).Bytes()...)
+
+	t.Run("applies copy and insert operations", func(t *testing.T) {
+		target, err := Expand(source, delta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "the quick brown cat jumps over the lazy"
+		if string(target) != expected {
+			t.Errorf("want %q, but got %q", expected, target)
+		}
+	})
+
+	t.Run("rejects a source of the wrong size", func(t *testing.T) {
+		_, err := Expand(source[1:], delta)
+		if err == nil {
+			t.Errorf("expected an error for a mismatched source")
+		}
+	})
+}
+
+func TestNumbers(t *testing.T) {
+	t.Run("round-trips little-endian varints", func(t *testing.T) {
+		for _, n := range []int{0, 15, 16, 127, 128, 300, 1 << 20} {
+			_, value, err := ReadVarIntLE(bytes.NewReader(WriteVarIntLE(n, 4)), 4)
+			if err != nil || value != n {
+				t.Errorf("want %d, but got %d (%v)", n, value, err)
+			}
+		}
+	})
+
+	t.Run("round-trips big-endian offsets", func(t *testing.T) {
+		for _, n := range []int{0, 127, 128, 16511, 16512, 1 << 24} {
+			value, err := ReadVarIntBE(bytes.NewReader(WriteVarIntBE(n)))
+			if err != nil || value != n {
+				t.Errorf("want %d, but got %d (%v)", n, value, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package pack
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	IDX_SIGNATURE  = 0xff744f63
+	IDX_VERSION    = 2
+	IDX_MAX_OFFSET = 0x80000000
+
+	FANOUT_SIZE = 1024
+	OID_LAYER   = 2
+	CRC_LAYER   = 3
+	OFS_LAYER   = 4
+	EXT_LAYER   = 5
+)
+
+var HEX_PREFIX = regexp.MustCompile(`^[0-9a-f]{2,40}$`)
+
+type Index struct {
+	input  io.ReaderAt
+	fanout []uint32
+	Size   int
+}
+
+func NewIndex(input io.ReaderAt) (*Index, error) {
+	index := &Index{input: input}
+	if err := index.loadFanoutTable(); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func (i *Index) OidOffset(oid string) (int64, bool) {
+	pos, found := i.oidPosition(oid)
+	if !found {
+		return 0, false
+	}
+
+	offset := i.readUint32(OFS_LAYER, pos)
+	if offset < IDX_MAX_OFFSET {
+		return int64(offset), true
+	}
+
+	pos = int(offset & (IDX_MAX_OFFSET - 1))
+	data := make([]byte, 8)
+	i.input.ReadAt(data, i.layerOffset(EXT_LAYER)+int64(8*pos))
+	return int64(binary.BigEndian.Uint64(data)), true
+}
+
+func (i *Index) PrefixMatch(name string) []string {
+	if !HEX_PREFIX.MatchString(name) {
+		return []string{}
+	}
+
+	pos, _ := i.oidPosition(name)
+	oids := []string{}
+	for ; pos < i.Size; pos++ {
+		oid := i.readOid(pos)
+		if !strings.HasPrefix(oid, name) {
+			break
+		}
+		oids = append(oids, oid)
+	}
+	return oids
+}
+
+func (i *Index) Oids() []string {
+	oids := make([]string, i.Size)
+	for pos := range oids {
+		oids[pos] = i.readOid(pos)
+	}
+	return oids
+}
+
+func (i *Index) loadFanoutTable() error {
+	header := make([]byte, 8)
+	if _, err := i.input.ReadAt(header, 0); err != nil {
+		return invalidPack("bad index header: %v", err)
+	}
+	if binary.BigEndian.Uint32(header[0:4]) != IDX_SIGNATURE {
+		return invalidPack("bad index signature")
+	}
+	if version := binary.BigEndian.Uint32(header[4:8]); version != IDX_VERSION {
+		return invalidPack("unsupported index version: %d", version)
+	}
+
+	data := make([]byte, FANOUT_SIZE)
+	if _, err := i.input.ReadAt(data, 8); err != nil {
+		return invalidPack("bad index fanout table: %v", err)
+	}
+	i.fanout = make([]uint32, 256)
+	for n := range i.fanout {
+		i.fanout[n] = binary.BigEndian.Uint32(data[4*n : 4*n+4])
+	}
+	i.Size = int(i.fanout[255])
+	return nil
+}
+
+func (i *Index) oidPosition(oid string) (int, bool) {
+	if !HEX_PREFIX.MatchString(oid) {
+		return 0, false
+	}
+	prefix, _ := hex.DecodeString(oid[:2])
+	low := 0
+	if prefix[0] > 0 {
+		low = int(i.fanout[prefix[0]-1])
+	}
+	high := int(i.fanout[prefix[0]])
+
+	pos := low + sort.Search(high-low, func(n int) bool {
+		return i.readOid(low+n) >= oid
+	})
+	return pos, pos < high && i.readOid(pos) == oid
+}
+
+func (i *Index) readOid(pos int) string {
+	data := make([]byte, 20)
+	i.input.ReadAt(data, i.layerOffset(OID_LAYER)+int64(20*pos))
+	return hex.EncodeToString(data)
+}
+
+func (i *Index) readUint32(layer, pos int) uint32 {
+	data := make([]byte, 4)
+	i.input.ReadAt(data, i.layerOffset(layer)+int64(4*pos))
+	return binary.BigEndian.Uint32(data)
+}
+
+func (i *Index) layerOffset(layer int) int64 {
+	offset := int64(8 + FANOUT_SIZE)
+	switch layer {
+	case OID_LAYER:
+		return offset
+	case CRC_LAYER:
+		return offset + int64(20*i.Size)
+	case OFS_LAYER:
+		return offset + int64(24*i.Size)
+	}
+	return offset + int64(28*i.Size)
+}
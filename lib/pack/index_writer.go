@@ -0,0 +1,102 @@
+package pack
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"sort"
+)
+
+type IndexEntry struct {
+	Oid    string
+	Offset int64
+	Crc    uint32
+}
+
+func WriteIndex(output io.Writer, entries []IndexEntry, packChecksum []byte) error {
+	sorted := make([]IndexEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Oid < sorted[j].Oid
+	})
+
+	digest := sha1.New()
+	out := io.MultiWriter(output, digest)
+
+	if err := writeUint32(out, IDX_SIGNATURE, IDX_VERSION); err != nil {
+		return err
+	}
+	if err := writeFanout(out, sorted); err != nil {
+		return err
+	}
+
+	for _, entry := range sorted {
+		oid, err := hex.DecodeString(entry.Oid)
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(oid); err != nil {
+			return err
+		}
+	}
+	for _, entry := range sorted {
+		if err := writeUint32(out, entry.Crc); err != nil {
+			return err
+		}
+	}
+
+	largeOffsets := []int64{}
+	for _, entry := range sorted {
+		offset := uint32(entry.Offset)
+		if entry.Offset >= IDX_MAX_OFFSET {
+			largeOffsets = append(largeOffsets, entry.Offset)
+			offset = IDX_MAX_OFFSET | uint32(len(largeOffsets)-1)
+		}
+		if err := writeUint32(out, offset); err != nil {
+			return err
+		}
+	}
+	for _, offset := range largeOffsets {
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint64(data, uint64(offset))
+		if _, err := out.Write(data); err != nil {
+			return err
+		}
+	}
+
+	if _, err := out.Write(packChecksum); err != nil {
+		return err
+	}
+	_, err := output.Write(digest.Sum(nil))
+	return err
+}
+
+func writeFanout(output io.Writer, entries []IndexEntry) error {
+	counts := make([]uint32, 256)
+	for _, entry := range entries {
+		prefix, err := hex.DecodeString(entry.Oid[:2])
+		if err != nil {
+			return err
+		}
+		counts[prefix[0]]++
+	}
+
+	total := uint32(0)
+	for _, count := range counts {
+		total += count
+		if err := writeUint32(output, total); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeUint32(output io.Writer, values ...uint32) error {
+	data := make([]byte, 4*len(values))
+	for i, value := range values {
+		binary.BigEndian.PutUint32(data[4*i:], value)
+	}
+	_, err := output.Write(data)
+	return err
+}
@@ -0,0 +1,262 @@
+package pack
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type pendingDelta struct {
+	offset int64
+	crc    uint32
+}
+
+type Indexer struct {
+	database Loader
+	reader   *Reader
+	stream   *Stream
+	packPath string
+	index    map[string]IndexEntry
+	pending  map[interface{}][]pendingDelta
+	packFile *os.File
+	digest   hash.Hash
+	pack     *os.File
+}
+
+func NewIndexer(database Loader, reader *Reader, stream *Stream, packPath string) *Indexer {
+	return &Indexer{
+		database: database,
+		reader:   reader,
+		stream:   stream,
+		packPath: packPath,
+		index:    map[string]IndexEntry{},
+		pending:  map[interface{}][]pendingDelta{},
+		digest:   sha1.New(),
+	}
+}
+
+func (i *Indexer) ProcessPack() error {
+	if err := os.MkdirAll(i.packPath, os.ModePerm); err != nil {
+		return err
+	}
+	packFile, err := os.CreateTemp(i.packPath, "tmp_pack_")
+	if err != nil {
+		return err
+	}
+	i.packFile = packFile
+	defer os.Remove(packFile.Name())
+	defer packFile.Close()
+
+	if err := i.writeHeader(); err != nil {
+		return err
+	}
+	if err := i.writeObjects(); err != nil {
+		return err
+	}
+	if err := i.writeChecksum(); err != nil {
+		return err
+	}
+	defer i.pack.Close()
+
+	if err := i.resolveDeltas(); err != nil {
+		return err
+	}
+	return i.writeIndex()
+}
+
+func (i *Indexer) writeHeader() error {
+	header := make([]byte, HEADER_SIZE)
+	copy(header, SIGNATURE)
+	binary.BigEndian.PutUint32(header[4:8], VERSION)
+	binary.BigEndian.PutUint32(header[8:12], uint32(i.reader.Count))
+	return i.write(header)
+}
+
+func (i *Indexer) writeObjects() error {
+	for n := 0; n < i.reader.Count; n++ {
+		if err := i.indexObject(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Indexer) indexObject() error {
+	offset := i.stream.Offset()
+
+	var record interface{}
+	data, err := i.stream.Capture(func() error {
+		var err error
+		record, err = i.reader.ReadRecord()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	crc := crc32.ChecksumIEEE(data)
+	if err := i.write(data); err != nil {
+		return err
+	}
+
+	switch v := record.(type) {
+	case *Record:
+		oid := v.Hash()
+		i.index[oid] = IndexEntry{Oid: oid, Offset: offset, Crc: crc}
+	case *OfsDelta:
+		base := offset - v.BaseOfs
+		i.pending[base] = append(i.pending[base], pendingDelta{offset, crc})
+	case *RefDelta:
+		i.pending[v.BaseOid] = append(i.pending[v.BaseOid], pendingDelta{offset, crc})
+	}
+	return nil
+}
+
+func (i *Indexer) writeChecksum() error {
+	if err := i.stream.VerifyChecksum(); err != nil {
+		return err
+	}
+
+	checksum := i.digest.Sum(nil)
+	if _, err := i.packFile.Write(checksum); err != nil {
+		return err
+	}
+	if err := i.packFile.Close(); err != nil {
+		return err
+	}
+
+	path := filepath.Join(i.packPath, i.packName()+".pack")
+	if err := os.Rename(i.packFile.Name(), path); err != nil {
+		return err
+	}
+
+	pack, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	i.pack = pack
+	return nil
+}
+
+func (i *Indexer) resolveDeltas() error {
+	entries := make([]IndexEntry, 0, len(i.index))
+	for _, entry := range i.index {
+		entries = append(entries, entry)
+	}
+
+	for _, entry := range entries {
+		record, err := i.readRecordAt(entry.Offset)
+		if err != nil {
+			return err
+		}
+		if err := i.resolveDeltaBase(record.(*Record), entry); err != nil {
+			return err
+		}
+	}
+
+	for key, deltas := range i.pending {
+		oid, ok := key.(string)
+		if !ok {
+			return invalidPack("no object at offset %d", key)
+		}
+		base, err := i.database.LoadRaw(oid)
+		if err != nil {
+			return err
+		}
+		delete(i.pending, key)
+		if err := i.resolvePending(base, deltas); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Indexer) resolveDeltaBase(record *Record, entry IndexEntry) error {
+	for _, key := range []interface{}{entry.Oid, entry.Offset} {
+		deltas, ok := i.pending[key]
+		if !ok {
+			continue
+		}
+		delete(i.pending, key)
+		if err := i.resolvePending(record, deltas); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Indexer) resolvePending(record *Record, deltas []pendingDelta) error {
+	for _, pending := range deltas {
+		delta, err := i.readRecordAt(pending.offset)
+		if err != nil {
+			return err
+		}
+
+		var deltaData []byte
+		switch v := delta.(type) {
+		case *OfsDelta:
+			deltaData = v.DeltaData
+		case *RefDelta:
+			deltaData = v.DeltaData
+		}
+
+		data, err := Expand(record.Data, deltaData)
+		if err != nil {
+			return err
+		}
+		object := NewRecord(record.Type, data)
+		oid := object.Hash()
+
+		entry := IndexEntry{Oid: oid, Offset: pending.offset, Crc: pending.crc}
+		i.index[oid] = entry
+		if err := i.resolveDeltaBase(object, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Indexer) readRecordAt(offset int64) (interface{}, error) {
+	if _, err := i.pack.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return NewReader(NewStream(i.pack, nil)).ReadRecord()
+}
+
+func (i *Indexer) writeIndex() error {
+	entries := make([]IndexEntry, 0, len(i.index))
+	for _, entry := range i.index {
+		entries = append(entries, entry)
+	}
+
+	indexFile, err := os.CreateTemp(i.packPath, "tmp_idx_")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(indexFile.Name())
+	defer indexFile.Close()
+
+	if err := WriteIndex(indexFile, entries, i.digest.Sum(nil)); err != nil {
+		return err
+	}
+	if err := indexFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(indexFile.Name(), filepath.Join(i.packPath, i.packName()+".idx"))
+}
+
+func (i *Indexer) packName() string {
+	return "pack-" + hex.EncodeToString(i.digest.Sum(nil))
+}
+
+func (i *Indexer) write(data []byte) error {
+	if _, err := i.packFile.Write(data); err != nil {
+		return err
+	}
+	i.digest.Write(data)
+	return nil
+}
@@ -0,0 +1,93 @@
+package pack
+
+import "io"
+
+func ReadVarIntLE(input io.ByteReader, shift uint) (byte, int, error) {
+	first, err := input.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	value := int(first) & (1<<shift - 1)
+	b := first
+	for b >= 0x80 {
+		b, err = input.ReadByte()
+		if err != nil {
+			return 0, 0, err
+		}
+		value |= int(b&0x7f) << shift
+		shift += 7
+	}
+	return first, value, nil
+}
+
+func WriteVarIntLE(value int, shift uint) []byte {
+	bytes := []byte{}
+	mask := 1<<shift - 1
+
+	for value > mask {
+		bytes = append(bytes, byte(0x80|value&mask))
+		value >>= shift
+
+		mask, shift = 0x7f, 7
+	}
+	return append(bytes, byte(value))
+}
+
+func ReadVarIntBE(input io.ByteReader) (int, error) {
+	b, err := input.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	value := int(b & 0x7f)
+
+	for b >= 0x80 {
+		b, err = input.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		value = ((value + 1) << 7) | int(b&0x7f)
+	}
+	return value, nil
+}
+
+func WriteVarIntBE(value int) []byte {
+	bytes := []byte{byte(value & 0x7f)}
+
+	for value >>= 7; value != 0; value >>= 7 {
+		value--
+		bytes = append([]byte{byte(0x80 | value&0x7f)}, bytes...)
+	}
+	return bytes
+}
+
+func ReadPackedInt56LE(input io.ByteReader, header byte) (int, error) {
+	value := 0
+	for i := 0; i < 7; i++ {
+		if header&(1<<i) == 0 {
+			continue
+		}
+		b, err := input.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		value |= int(b) << (8 * i)
+	}
+	return value, nil
+}
+
+func WritePackedInt56LE(value int) []byte {
+	header := byte(0)
+	bytes := []byte{0}
+
+	for i := 0; i < 7; i++ {
+		b := byte((value >> (8 * i)) & 0xff)
+		if b == 0 {
+			continue
+		}
+		header |= 1 << i
+		bytes = append(bytes, b)
+	}
+	bytes[0] = header
+	return bytes
+}
@@ -0,0 +1,94 @@
+package pack
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	HEADER_SIZE = 12
+	SIGNATURE   = "PACK"
+	VERSION     = 2
+
+	COMMIT    = 1
+	TREE      = 2
+	BLOB      = 3
+	TAG       = 4
+	OFS_DELTA = 6
+	REF_DELTA = 7
+
+	MAX_COPY   = 0xffffff
+	MAX_INSERT = 0x7f
+)
+
+var TYPE_CODES = map[string]int{
+	"commit": COMMIT,
+	"tree":   TREE,
+	"blob":   BLOB,
+	"tag":    TAG,
+}
+
+var TYPE_NAMES = map[int]string{
+	COMMIT: "commit",
+	TREE:   "tree",
+	BLOB:   "blob",
+	TAG:    "tag",
+}
+
+type InvalidPackError struct {
+	msg string
+}
+
+func (e *InvalidPackError) Error() string {
+	return e.msg
+}
+
+func invalidPack(format string, args ...interface{}) error {
+	return &InvalidPackError{fmt.Sprintf(format, args...)}
+}
+
+type Record struct {
+	oid  string
+	Type string
+	Data []byte
+}
+
+func NewRecord(rtype string, data []byte) *Record {
+	return &Record{
+		Type: rtype,
+		Data: data,
+	}
+}
+
+func (r *Record) Oid() string {
+	return r.oid
+}
+
+func (r *Record) SetOid(oid string) {
+	r.oid = oid
+}
+
+func (r *Record) String() string {
+	return string(r.Data)
+}
+
+func (r *Record) Content() []byte {
+	header := fmt.Sprintf("%s %d\x00", r.Type, len(r.Data))
+	return append([]byte(header), r.Data...)
+}
+
+func (r *Record) Hash() string {
+	sum := sha1.Sum(r.Content())
+	return hex.EncodeToString(sum[:])
+}
+
+type OfsDelta struct {
+	BaseOfs   int64
+	DeltaData []byte
+}
+
+type RefDelta struct {
+	BaseOid   string
+	DeltaData []byte
+}
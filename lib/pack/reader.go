@@ -0,0 +1,111 @@
+package pack
+
+import (
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+)
+
+type Reader struct {
+	input *Stream
+	Count int
+}
+
+func NewReader(input *Stream) *Reader {
+	return &Reader{
+		input: input,
+	}
+}
+
+func (r *Reader) ReadHeader() error {
+	data, err := r.input.ReadFull(HEADER_SIZE)
+	if err != nil {
+		return invalidPack("bad pack header: %v", err)
+	}
+
+	signature := string(data[0:4])
+	version := binary.BigEndian.Uint32(data[4:8])
+	r.Count = int(binary.BigEndian.Uint32(data[8:12]))
+
+	if signature != SIGNATURE {
+		return invalidPack("bad pack signature: %s", signature)
+	}
+	if version != VERSION {
+		return invalidPack("unsupported pack version: %d", version)
+	}
+	return nil
+}
+
+func (r *Reader) ReadRecord() (interface{}, error) {
+	rtype, size, err := r.readRecordHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	switch rtype {
+	case COMMIT, TREE, BLOB, TAG:
+		data, err := r.readZlibStream(size)
+		if err != nil {
+			return nil, err
+		}
+		return NewRecord(TYPE_NAMES[rtype], data), nil
+
+	case OFS_DELTA:
+		return r.readOfsDelta(size)
+
+	case REF_DELTA:
+		return r.readRefDelta(size)
+	}
+	return nil, invalidPack("unknown object type in pack: %d", rtype)
+}
+
+func (r *Reader) readRecordHeader() (int, int, error) {
+	first, size, err := ReadVarIntLE(r.input, 4)
+	if err != nil {
+		return 0, 0, err
+	}
+	rtype := int(first>>4) & 0x7
+	return rtype, size, nil
+}
+
+func (r *Reader) readOfsDelta(size int) (*OfsDelta, error) {
+	offset, err := ReadVarIntBE(r.input)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.readZlibStream(size)
+	if err != nil {
+		return nil, err
+	}
+	return &OfsDelta{BaseOfs: int64(offset), DeltaData: data}, nil
+}
+
+func (r *Reader) readRefDelta(size int) (*RefDelta, error) {
+	base, err := r.input.ReadFull(20)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.readZlibStream(size)
+	if err != nil {
+		return nil, err
+	}
+	return &RefDelta{BaseOid: hex.EncodeToString(base), DeltaData: data}, nil
+}
+
+func (r *Reader) readZlibStream(size int) ([]byte, error) {
+	zr, err := zlib.NewReader(r.input)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != size {
+		return nil, invalidPack("object size mismatch: expected %d, got %d", size, len(data))
+	}
+	return data, nil
+}
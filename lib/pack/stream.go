@@ -0,0 +1,81 @@
+package pack
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"hash"
+	"io"
+)
+
+type Stream struct {
+	input   *bufio.Reader
+	digest  hash.Hash
+	offset  int64
+	capture *bytes.Buffer
+}
+
+func NewStream(input io.Reader, prefix []byte) *Stream {
+	if len(prefix) > 0 {
+		input = io.MultiReader(bytes.NewReader(prefix), input)
+	}
+	return &Stream{
+		input:  bufio.NewReader(input),
+		digest: sha1.New(),
+	}
+}
+
+func (s *Stream) Offset() int64 {
+	return s.offset
+}
+
+func (s *Stream) Read(p []byte) (int, error) {
+	n, err := s.input.Read(p)
+	s.update(p[:n])
+	return n, err
+}
+
+func (s *Stream) ReadByte() (byte, error) {
+	b, err := s.input.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	s.update([]byte{b})
+	return b, nil
+}
+
+func (s *Stream) ReadFull(size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(s, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *Stream) Capture(fn func() error) ([]byte, error) {
+	s.capture = new(bytes.Buffer)
+	defer func() { s.capture = nil }()
+
+	err := fn()
+	return s.capture.Bytes(), err
+}
+
+func (s *Stream) VerifyChecksum() error {
+	expected := s.digest.Sum(nil)
+	actual := make([]byte, sha1.Size)
+	if _, err := io.ReadFull(s.input, actual); err != nil {
+		return invalidPack("Checksum missing from pack: %v", err)
+	}
+	if !bytes.Equal(expected, actual) {
+		return invalidPack("Checksum does not match value read from pack")
+	}
+	return nil
+}
+
+func (s *Stream) update(data []byte) {
+	if s.capture != nil {
+		s.capture.Write(data)
+	}
+	s.digest.Write(data)
+	s.offset += int64(len(data))
+}
@@ -0,0 +1,77 @@
+package pack
+
+type Storer interface {
+	Loader
+	StoreRaw(record *Record) error
+}
+
+type Unpacker struct {
+	database Storer
+	reader   *Reader
+	stream   *Stream
+	offsets  map[int64]string
+}
+
+func NewUnpacker(database Storer, reader *Reader, stream *Stream) *Unpacker {
+	return &Unpacker{
+		database: database,
+		reader:   reader,
+		stream:   stream,
+		offsets:  map[int64]string{},
+	}
+}
+
+func (u *Unpacker) ProcessPack() error {
+	for i := 0; i < u.reader.Count; i++ {
+		if err := u.processRecord(); err != nil {
+			return err
+		}
+	}
+	return u.stream.VerifyChecksum()
+}
+
+func (u *Unpacker) processRecord() error {
+	offset := u.stream.Offset()
+	record, err := u.reader.ReadRecord()
+	if err != nil {
+		return err
+	}
+
+	resolved, err := u.resolve(record, offset)
+	if err != nil {
+		return err
+	}
+	if err := u.database.StoreRaw(resolved); err != nil {
+		return err
+	}
+	u.offsets[offset] = resolved.Oid()
+	return nil
+}
+
+func (u *Unpacker) resolve(record interface{}, offset int64) (*Record, error) {
+	switch v := record.(type) {
+	case *Record:
+		return v, nil
+	case *OfsDelta:
+		oid, ok := u.offsets[offset-v.BaseOfs]
+		if !ok {
+			return nil, invalidPack("no object at offset %d", offset-v.BaseOfs)
+		}
+		return u.resolveDelta(v.DeltaData, oid)
+	case *RefDelta:
+		return u.resolveDelta(v.DeltaData, v.BaseOid)
+	}
+	return nil, invalidPack("unknown record in pack")
+}
+
+func (u *Unpacker) resolveDelta(delta []byte, baseOid string) (*Record, error) {
+	base, err := u.database.LoadRaw(baseOid)
+	if err != nil {
+		return nil, err
+	}
+	data, err := Expand(base.Data, delta)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecord(base.Type, data), nil
+}
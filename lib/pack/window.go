@@ -0,0 +1,42 @@
+package pack
+
+type Unpacked struct {
+	entry      *Entry
+	data       []byte
+	deltaIndex *XDelta
+}
+
+type Window struct {
+	objects []*Unpacked
+	offset  int
+}
+
+func NewWindow(size int) *Window {
+	return &Window{
+		objects: make([]*Unpacked, size),
+	}
+}
+
+func (w *Window) Add(entry *Entry, data []byte) *Unpacked {
+	unpacked := &Unpacked{entry: entry, data: data}
+	w.objects[w.offset] = unpacked
+	w.offset = w.wrap(w.offset + 1)
+	return unpacked
+}
+
+func (w *Window) Each(fn func(unpacked *Unpacked)) {
+	cursor := w.wrap(w.offset - 2)
+	limit := w.wrap(w.offset - 1)
+
+	for cursor != limit {
+		if unpacked := w.objects[cursor]; unpacked != nil {
+			fn(unpacked)
+		}
+		cursor = w.wrap(cursor - 1)
+	}
+}
+
+func (w *Window) wrap(offset int) int {
+	size := len(w.objects)
+	return (offset%size + size) % size
+}
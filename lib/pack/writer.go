@@ -0,0 +1,149 @@
+package pack
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+type Loader interface {
+	LoadRaw(oid string) (*Record, error)
+}
+
+type WriterOption struct {
+	AllowOfs bool
+}
+
+type Writer struct {
+	output   io.Writer
+	digest   hash.Hash
+	offset   int64
+	database Loader
+	options  WriterOption
+	packList []*Entry
+	checksum []byte
+}
+
+func NewWriter(output io.Writer, database Loader, options WriterOption) *Writer {
+	return &Writer{
+		output:   output,
+		digest:   sha1.New(),
+		database: database,
+		options:  options,
+		packList: []*Entry{},
+	}
+}
+
+func (w *Writer) Add(oid, path string) error {
+	record, err := w.database.LoadRaw(oid)
+	if err != nil {
+		return err
+	}
+	entry := NewEntry(oid, record.Type, len(record.Data), path, w.options.AllowOfs)
+	w.packList = append(w.packList, entry)
+	return nil
+}
+
+func (w *Writer) Entries() []*Entry {
+	return w.packList
+}
+
+func (w *Writer) Checksum() []byte {
+	return w.checksum
+}
+
+func (w *Writer) WriteObjects() error {
+	if err := w.compressObjects(); err != nil {
+		return err
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	for _, entry := range w.packList {
+		if err := w.writeEntry(entry); err != nil {
+			return err
+		}
+	}
+
+	w.checksum = w.digest.Sum(nil)
+	_, err := w.output.Write(w.checksum)
+	return err
+}
+
+func (w *Writer) WriteIndex(output io.Writer) error {
+	entries := make([]IndexEntry, len(w.packList))
+	for i, entry := range w.packList {
+		entries[i] = IndexEntry{Oid: entry.oid, Offset: entry.Offset, Crc: entry.crc}
+	}
+	return WriteIndex(output, entries, w.checksum)
+}
+
+func (w *Writer) compressObjects() error {
+	compressor := NewCompressor(w.database)
+	for _, entry := range w.packList {
+		compressor.Add(entry)
+	}
+	return compressor.BuildDeltas()
+}
+
+func (w *Writer) writeHeader() error {
+	header := make([]byte, HEADER_SIZE)
+	copy(header, SIGNATURE)
+	binary.BigEndian.PutUint32(header[4:8], VERSION)
+	binary.BigEndian.PutUint32(header[8:12], uint32(len(w.packList)))
+	return w.write(header)
+}
+
+func (w *Writer) writeEntry(entry *Entry) error {
+	if entry.delta != nil {
+		if err := w.writeEntry(entry.delta.base); err != nil {
+			return err
+		}
+	}
+	if entry.Offset != 0 {
+		return nil
+	}
+	entry.Offset = w.offset
+
+	var data []byte
+	if entry.delta != nil {
+		data = entry.delta.data
+	} else {
+		record, err := w.database.LoadRaw(entry.oid)
+		if err != nil {
+			return err
+		}
+		data = record.Data
+	}
+
+	header := WriteVarIntLE(entry.PackedSize(), 4)
+	header[0] |= byte(entry.PackedType() << 4)
+
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.Write(entry.DeltaPrefix())
+
+	compressor := zlib.NewWriter(&buf)
+	if _, err := compressor.Write(data); err != nil {
+		return err
+	}
+	if err := compressor.Close(); err != nil {
+		return err
+	}
+
+	entry.crc = crc32.ChecksumIEEE(buf.Bytes())
+	return w.write(buf.Bytes())
+}
+
+func (w *Writer) write(data []byte) error {
+	if _, err := w.output.Write(data); err != nil {
+		return err
+	}
+	w.digest.Write(data)
+	w.offset += int64(len(data))
+	return nil
+}
@@ -0,0 +1,131 @@
+package pack
+
+const BLOCK_SIZE = 16
+
+type XDelta struct {
+	source []byte
+	index  map[string][]int
+	target []byte
+	offset int
+	insert []byte
+	ops    []DeltaOp
+}
+
+func CreateXDeltaIndex(source []byte) *XDelta {
+	blocks := len(source) / BLOCK_SIZE
+	index := map[string][]int{}
+
+	for i := 0; i < blocks; i++ {
+		offset := i * BLOCK_SIZE
+		slice := string(source[offset : offset+BLOCK_SIZE])
+		index[slice] = append(index[slice], offset)
+	}
+
+	return &XDelta{
+		source: source,
+		index:  index,
+	}
+}
+
+func (x *XDelta) Compress(target []byte) []DeltaOp {
+	x.target = target
+	x.offset = 0
+	x.insert = []byte{}
+	x.ops = []DeltaOp{}
+
+	for x.offset < len(x.target) {
+		x.generateOps()
+	}
+	x.flushInsert(0)
+
+	return x.ops
+}
+
+func (x *XDelta) generateOps() {
+	mOffset, mSize := x.longestMatch()
+	if mSize == 0 {
+		x.pushInsert()
+		return
+	}
+
+	mOffset, mSize = x.expandMatch(mOffset, mSize)
+	x.flushInsert(0)
+	x.ops = append(x.ops, &Copy{Offset: mOffset, Size: mSize})
+}
+
+func (x *XDelta) longestMatch() (int, int) {
+	end := x.offset + BLOCK_SIZE
+	if end > len(x.target) {
+		return 0, 0
+	}
+	positions, ok := x.index[string(x.target[x.offset:end])]
+	if !ok {
+		return 0, 0
+	}
+
+	mOffset, mSize := 0, 0
+	for _, pos := range positions {
+		remaining := x.remainingBytes(pos)
+		if remaining <= mSize {
+			break
+		}
+
+		s := x.matchFrom(pos, remaining)
+		if mSize >= s-pos {
+			continue
+		}
+		mOffset, mSize = pos, s-pos
+	}
+	return mOffset, mSize
+}
+
+func (x *XDelta) remainingBytes(pos int) int {
+	remaining := len(x.source) - pos
+	if targetRemaining := len(x.target) - x.offset; targetRemaining < remaining {
+		remaining = targetRemaining
+	}
+	if remaining > MAX_COPY {
+		remaining = MAX_COPY
+	}
+	return remaining
+}
+
+func (x *XDelta) matchFrom(pos, remaining int) int {
+	s, t := pos, x.offset
+	for remaining > 0 && x.source[s] == x.target[t] {
+		s, t = s+1, t+1
+		remaining--
+	}
+	return s
+}
+
+func (x *XDelta) expandMatch(mOffset, mSize int) (int, int) {
+	for mOffset > 0 && len(x.insert) > 0 && x.source[mOffset-1] == x.insert[len(x.insert)-1] {
+		if mSize == MAX_COPY {
+			break
+		}
+		x.offset--
+		mOffset--
+		mSize++
+		x.insert = x.insert[:len(x.insert)-1]
+	}
+	x.offset += mSize
+	return mOffset, mSize
+}
+
+func (x *XDelta) pushInsert() {
+	x.insert = append(x.insert, x.target[x.offset])
+	x.offset++
+	x.flushInsert(MAX_INSERT)
+}
+
+func (x *XDelta) flushInsert(size int) {
+	if size > 0 && len(x.insert) < size {
+		return
+	}
+	if len(x.insert) == 0 {
+		return
+	}
+	x.ops = append(x.ops, &Insert{Data: x.insert})
+	x.insert = []byte{}
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"bufio"
+	"building-git/lib/config"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const GITIGNORE = ".gitignore"
+
+type IgnorePattern struct {
+	Source   string
+	Line     int
+	Pattern  string
+	base     string
+	negate   bool
+	dirOnly  bool
+	baseOnly bool
+	regexp   *regexp.Regexp
+}
+
+func parseIgnorePattern(text, source, base string, line int) *IgnorePattern {
+	text = trimIgnoreLine(text)
+	if text == "" || strings.HasPrefix(text, "#") {
+		return nil
+	}
+
+	p := &IgnorePattern{Source: source, Line: line, Pattern: text, base: base}
+	if strings.HasPrefix(text, "!") {
+		p.negate = true
+		text = text[1:]
+	}
+	if strings.HasSuffix(text, "/") {
+		p.dirOnly = true
+		text = strings.TrimSuffix(text, "/")
+	}
+	p.baseOnly = !strings.Contains(text, "/")
+	text = strings.TrimPrefix(text, "/")
+	if text == "" {
+		return nil
+	}
+
+	p.regexp = compileIgnorePattern(text)
+	return p
+}
+
+func trimIgnoreLine(text string) string {
+	end := len(text)
+	for end > 0 && text[end-1] == ' ' {
+		if end > 1 && text[end-2] == '\\' {
+			break
+		}
+		end--
+	}
+	return text[:end]
+}
+
+func compileIgnorePattern(text string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	for i := 0; i < len(text); {
+		atSegment := i == 0 || text[i-1] == '/'
+		switch {
+		case atSegment && strings.HasPrefix(text[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 3
+		case atSegment && text[i:] == "**":
+			expr.WriteString(".*")
+			i += 2
+		case text[i] == '*':
+			expr.WriteString("[^/]*")
+			i++
+		case text[i] == '?':
+			expr.WriteString("[^/]")
+			i++
+		case text[i] == '[':
+			class, n := compileCharClass(text[i:])
+			expr.WriteString(class)
+			i += n
+		case text[i] == '\\' && i+1 < len(text):
+			expr.WriteString(regexp.QuoteMeta(text[i+1 : i+2]))
+			i += 2
+		default:
+			expr.WriteString(regexp.QuoteMeta(text[i : i+1]))
+			i++
+		}
+	}
+
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
+
+func compileCharClass(text string) (string, int) {
+	i := 1
+	if i < len(text) && (text[i] == '!' || text[i] == '^') {
+		i++
+	}
+	if i < len(text) && text[i] == ']' {
+		i++
+	}
+	for i < len(text) && text[i] != ']' {
+		i++
+	}
+	if i >= len(text) {
+		return regexp.QuoteMeta("["), 1
+	}
+
+	body := text[1:i]
+	if strings.HasPrefix(body, "!") {
+		body = "^" + body[1:]
+	}
+	body = strings.ReplaceAll(body, `\`, `\\`)
+	if _, err := regexp.Compile("[" + body + "]"); err != nil {
+		return regexp.QuoteMeta("["), 1
+	}
+	return "[" + body + "]", i + 1
+}
+
+func (p *IgnorePattern) IsNegated() bool {
+	return p.negate
+}
+
+func (p *IgnorePattern) matches(path string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if p.base != "" {
+		if !strings.HasPrefix(path, p.base+"/") {
+			return false
+		}
+		path = strings.TrimPrefix(path, p.base+"/")
+	}
+	if p.baseOnly {
+		path = filepath.Base(path)
+	}
+	return p.regexp.MatchString(path)
+}
+
+type Ignore struct {
+	rootPath string
+	gitPath  string
+	config   *config.Stack
+	global   []*IgnorePattern
+	loaded   bool
+	dirs     map[string][]*IgnorePattern
+}
+
+func NewIgnore(rootPath, gitPath string, config *config.Stack) *Ignore {
+	return &Ignore{
+		rootPath: rootPath,
+		gitPath:  gitPath,
+		config:   config,
+		dirs:     map[string][]*IgnorePattern{},
+	}
+}
+
+func (i *Ignore) IsIgnored(path string, isDir bool) bool {
+	pattern := i.Match(path, isDir)
+	return pattern != nil && !pattern.negate
+}
+
+func (i *Ignore) Match(path string, isDir bool) *IgnorePattern {
+	path = filepath.ToSlash(filepath.Clean(path))
+	if path == "." || path == "" {
+		return nil
+	}
+
+	parts := strings.Split(path, "/")
+	for n := 1; n < len(parts); n++ {
+		if pattern := i.lastMatch(strings.Join(parts[:n], "/"), true); pattern != nil && !pattern.negate {
+			return pattern
+		}
+	}
+	return i.lastMatch(path, isDir)
+}
+
+func (i *Ignore) lastMatch(path string, isDir bool) *IgnorePattern {
+	var match *IgnorePattern
+
+	for _, pattern := range i.patternsFor(path) {
+		if pattern.matches(path, isDir) {
+			match = pattern
+		}
+	}
+	return match
+}
+
+func (i *Ignore) patternsFor(path string) []*IgnorePattern {
+	if !i.loaded {
+		i.loadGlobalPatterns()
+		i.loaded = true
+	}
+
+	patterns := append([]*IgnorePattern{}, i.global...)
+	patterns = append(patterns, i.directoryPatterns("")...)
+
+	parts := strings.Split(path, "/")
+	for n := 1; n < len(parts); n++ {
+		patterns = append(patterns, i.directoryPatterns(strings.Join(parts[:n], "/"))...)
+	}
+	return patterns
+}
+
+func (i *Ignore) loadGlobalPatterns() {
+	if i.config != nil {
+		if value, _ := i.config.Get([]string{"core", "excludesFile"}); value != nil {
+			if path, ok := value.(string); ok {
+				i.global = append(i.global, readIgnoreFile(expandHome(path), path, "")...)
+			}
+		}
+	}
+
+	exclude := filepath.Join(i.gitPath, "info", "exclude")
+	source := filepath.Join(".git", "info", "exclude")
+	if relPath, err := filepath.Rel(i.rootPath, exclude); err == nil {
+		source = relPath
+	}
+	i.global = append(i.global, readIgnoreFile(exclude, source, "")...)
+}
+
+func (i *Ignore) directoryPatterns(dirname string) []*IgnorePattern {
+	if patterns, ok := i.dirs[dirname]; ok {
+		return patterns
+	}
+
+	source := filepath.Join(dirname, GITIGNORE)
+	patterns := readIgnoreFile(filepath.Join(i.rootPath, source), source, dirname)
+	i.dirs[dirname] = patterns
+	return patterns
+}
+
+func readIgnoreFile(path, source, base string) []*IgnorePattern {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	patterns := []*IgnorePattern{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if pattern := parseIgnorePattern(scanner.Text(), source, base, line); pattern != nil {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, path[2:])
+}
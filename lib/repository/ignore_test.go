@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnore(t *testing.T) {
+	setup := func(files map[string]string) string {
+		tmpDir, err := os.MkdirTemp("", "ignore")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range files {
+			path := filepath.Join(tmpDir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return tmpDir
+	}
+
+	assertIgnored := func(t *testing.T, ignore *Ignore, path string, isDir, expected bool) {
+		t.Helper()
+		if got := ignore.IsIgnored(path, isDir); got != expected {
+			t.Errorf("%s: want %v, but got %v", path, expected, got)
+		}
+	}
+
+	t.Run("matches basenames at any depth", func(t *testing.T) {
+		tmpDir := setup(map[string]string{".gitignore": "*.o\n# comment\n\nfoo?.txt\n"})
+		defer os.RemoveAll(tmpDir)
+		ignore := NewIgnore(tmpDir, filepath.Join(tmpDir, ".git"), nil)
+
+		assertIgnored(t, ignore, "main.o", false, true)
+		assertIgnored(t, ignore, "lib/deep/main.o", false, true)
+		assertIgnored(t, ignore, "main.c", false, false)
+		assertIgnored(t, ignore, "src/foo1.txt", false, true)
+		assertIgnored(t, ignore, "src/foo12.txt", false, false)
+		assertIgnored(t, ignore, "# comment", false, false)
+	})
+
+	t.Run("anchors patterns containing a slash", func(t *testing.T) {
+		tmpDir := setup(map[string]string{".gitignore": "/top.txt\ndoc/*.html\n"})
+		defer os.RemoveAll(tmpDir)
+		ignore := NewIgnore(tmpDir, filepath.Join(tmpDir, ".git"), nil)
+
+		assertIgnored(t, ignore, "top.txt", false, true)
+		assertIgnored(t, ignore, "sub/top.txt", false, false)
+		assertIgnored(t, ignore, "doc/index.html", false, true)
+		assertIgnored(t, ignore, "doc/api/index.html", false, false)
+		assertIgnored(t, ignore, "src/doc/index.html", false, false)
+	})
+
+	t.Run("matches directory-only patterns against directories", func(t *testing.T) {
+		tmpDir := setup(map[string]string{".gitignore": "build/\n"})
+		defer os.RemoveAll(tmpDir)
+		ignore := NewIgnore(tmpDir, filepath.Join(tmpDir, ".git"), nil)
+
+		assertIgnored(t, ignore, "build", true, true)
+		assertIgnored(t, ignore, "build", false, false)
+		assertIgnored(t, ignore, "build/output.bin", false, true)
+		assertIgnored(t, ignore, "src/build/output.bin", false, true)
+	})
+
+	t.Run("expands double asterisks", func(t *testing.T) {
+		tmpDir := setup(map[string]string{".gitignore": "**/logs\nvendor/**\na/**/z\n"})
+		defer os.RemoveAll(tmpDir)
+		ignore := NewIgnore(tmpDir, filepath.Join(tmpDir, ".git"), nil)
+
+		assertIgnored(t, ignore, "logs", true, true)
+		assertIgnored(t, ignore, "app/logs", true, true)
+		assertIgnored(t, ignore, "vendor/lib/x.go", false, true)
+		assertIgnored(t, ignore, "a/z", false, true)
+		assertIgnored(t, ignore, "a/b/c/z", false, true)
+		assertIgnored(t, ignore, "b/a/z", false, false)
+	})
+
+	t.Run("re-includes paths with negated patterns", func(t *testing.T) {
+		tmpDir := setup(map[string]string{".gitignore": "*.log\n!keep.log\nbuild/\n!build/keep.txt\n"})
+		defer os.RemoveAll(tmpDir)
+		ignore := NewIgnore(tmpDir, filepath.Join(tmpDir, ".git"), nil)
+
+		assertIgnored(t, ignore, "debug.log", false, true)
+		assertIgnored(t, ignore, "keep.log", false, false)
+		assertIgnored(t, ignore, "build/keep.txt", false, true)
+	})
+
+	t.Run("lets deeper .gitignore files override shallower ones", func(t *testing.T) {
+		tmpDir := setup(map[string]string{
+			".gitignore":      "*.txt\n",
+			"docs/.gitignore": "!*.txt\n/local\n",
+		})
+		defer os.RemoveAll(tmpDir)
+		ignore := NewIgnore(tmpDir, filepath.Join(tmpDir, ".git"), nil)
+
+		assertIgnored(t, ignore, "notes.txt", false, true)
+		assertIgnored(t, ignore, "docs/notes.txt", false, false)
+		assertIgnored(t, ignore, "docs/local", false, true)
+		assertIgnored(t, ignore, "local", false, false)
+
+		pattern := ignore.Match("docs/local", false)
+		if pattern.Source != "docs/.gitignore" || pattern.Line != 2 || pattern.Pattern != "/local" {
+			t.Errorf("want %q, but got %s:%d:%s", "docs/.gitignore:2:/local", pattern.Source, pattern.Line, pattern.Pattern)
+		}
+	})
+
+	t.Run("reads .git/info/exclude", func(t *testing.T) {
+		tmpDir := setup(map[string]string{".git/info/exclude": "secret\n"})
+		defer os.RemoveAll(tmpDir)
+		ignore := NewIgnore(tmpDir, filepath.Join(tmpDir, ".git"), nil)
+
+		assertIgnored(t, ignore, "secret", false, true)
+		if source := ignore.Match("secret", false).Source; source != ".git/info/exclude" {
+			t.Errorf("want %q, but got %q", ".git/info/exclude", source)
+		}
+	})
+
+	t.Run("handles escapes and character classes", func(t *testing.T) {
+		tmpDir := setup(map[string]string{".gitignore": "\\#hash\n\\!bang\nfile[0-9].c\nx[!ab].c\ntrailing\\ \n"})
+		defer os.RemoveAll(tmpDir)
+		ignore := NewIgnore(tmpDir, filepath.Join(tmpDir, ".git"), nil)
+
+		assertIgnored(t, ignore, "#hash", false, true)
+		assertIgnored(t, ignore, "!bang", false, true)
+		assertIgnored(t, ignore, "file3.c", false, true)
+		assertIgnored(t, ignore, "fileA.c", false, false)
+		assertIgnored(t, ignore, "xc.c", false, true)
+		assertIgnored(t, ignore, "xa.c", false, false)
+		assertIgnored(t, ignore, "trailing ", false, true)
+	})
+}
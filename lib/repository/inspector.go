@@ -20,9 +20,9 @@ func (i *Inspector) isTrackableFile(path string, stat fs.FileInfo) bool {
 		return false
 	}
 	if stat.Mode().IsRegular() {
-		return !i.repo.Index.IsTrackedFile(path)
+		return !i.repo.Index.IsTrackedFile(path) && !i.repo.Workspace.IsIgnored(path, false)
 	}
-	if !stat.IsDir() {
+	if !stat.IsDir() || i.repo.Workspace.IsIgnored(path, true) {
 		return false
 	}
 
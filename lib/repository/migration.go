@@ -236,5 +236,5 @@ func (m *Migration) collectErrors() error {
 	if len(m.Errors) == 0 {
 		return nil
 	}
-	return fmt.Errorf("conflicts detected")
+	return fmt.Errorf("%s", strings.Join(m.Errors, "\n"))
 }
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"bufio"
+	"building-git/lib/database"
+	"building-git/lib/lockfile"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	PACKED_REFS        = "packed-refs"
+	PACKED_REFS_HEADER = "# pack-refs with: peeled fully-peeled sorted \n"
+)
+
+type packedRef struct {
+	name   string
+	oid    string
+	peeled string
+}
+
+func (r *Refs) PackRefs(db *database.Database, all, prune bool) error {
+	lockfile, err := r.holdRefLock(r.packedRefsPath())
+	if err != nil {
+		return err
+	}
+
+	packed, err := r.loadPackedRefs()
+	if err != nil {
+		lockfile.Rollback()
+		return err
+	}
+
+	loose, err := r.listLooseRefs(r.refsPath)
+	if err != nil {
+		lockfile.Rollback()
+		return err
+	}
+
+	pruned := []string{}
+	for _, ref := range loose {
+		if !all && !strings.HasPrefix(ref.Path, TagsDir()+"/") {
+			continue
+		}
+		value, err := r.readOidOrSymRef(filepath.Join(r.pathname, ref.Path))
+		if err != nil {
+			lockfile.Rollback()
+			return err
+		}
+		oidRef, ok := value.(*Ref)
+		if !ok {
+			continue
+		}
+
+		entry := &packedRef{name: ref.Path, oid: oidRef.oid}
+		if object, err := db.Peel(entry.oid); err == nil && object.Oid() != entry.oid {
+			entry.peeled = object.Oid()
+		}
+		packed[ref.Path] = entry
+		pruned = append(pruned, ref.Path)
+	}
+
+	if err := r.writePackedRefs(lockfile, packed); err != nil {
+		return err
+	}
+	if !prune {
+		return nil
+	}
+
+	for _, name := range pruned {
+		path := filepath.Join(r.pathname, name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := r.deleteParentDirectories(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Refs) packedRefsPath() string {
+	return filepath.Join(r.pathname, PACKED_REFS)
+}
+
+// readPackedRefs parses the packed-refs file and keeps the result while the
+// file's size and modification time stay the same, so that looking up many
+// refs does not read the file for each one but writes made by another Refs or
+// another process are still picked up.
+func (r *Refs) readPackedRefs() (map[string]*packedRef, error) {
+	stat, err := os.Stat(r.packedRefsPath())
+	if err != nil {
+		r.packed, r.packedStat = nil, nil
+		if os.IsNotExist(err) {
+			return map[string]*packedRef{}, nil
+		}
+		return nil, err
+	}
+	if r.packed != nil && stat.Size() == r.packedStat.Size() && stat.ModTime().Equal(r.packedStat.ModTime()) {
+		return r.packed, nil
+	}
+
+	refs, err := r.loadPackedRefs()
+	if err != nil {
+		return nil, err
+	}
+	r.packed, r.packedStat = refs, stat
+	return refs, nil
+}
+
+func (r *Refs) loadPackedRefs() (map[string]*packedRef, error) {
+	refs := map[string]*packedRef{}
+
+	data, err := os.ReadFile(r.packedRefsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return refs, nil
+		}
+		return nil, err
+	}
+
+	var last *packedRef
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "^"):
+			if last != nil {
+				last.peeled = line[1:]
+			}
+		default:
+			fields := strings.SplitN(line, " ", 2)
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("unexpected line in %s: %s", PACKED_REFS, line)
+			}
+			last = &packedRef{name: fields[1], oid: fields[0]}
+			refs[last.name] = last
+		}
+	}
+	return refs, scanner.Err()
+}
+
+func (r *Refs) readPackedRef(name string) *packedRef {
+	refs, err := r.readPackedRefs()
+	if err != nil {
+		return nil
+	}
+	return refs[name]
+}
+
+func (r *Refs) listPackedRefs(rootPath string) ([]*SymRef, error) {
+	prefix, err := filepath.Rel(r.pathname, rootPath)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := r.readPackedRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []*SymRef{}
+	for name := range packed {
+		if strings.HasPrefix(name, prefix+"/") {
+			refs = append(refs, &SymRef{Refs: r, Path: name})
+		}
+	}
+	return refs, nil
+}
+
+func (r *Refs) deletePackedRef(name string) error {
+	if r.readPackedRef(name) == nil {
+		return nil
+	}
+
+	lockfile, err := r.holdRefLock(r.packedRefsPath())
+	if err != nil {
+		return err
+	}
+	packed, err := r.loadPackedRefs()
+	if err != nil {
+		lockfile.Rollback()
+		return err
+	}
+	if _, ok := packed[name]; !ok {
+		return lockfile.Rollback()
+	}
+
+	remaining := map[string]*packedRef{}
+	for refName, ref := range packed {
+		if refName != name {
+			remaining[refName] = ref
+		}
+	}
+	return r.writePackedRefs(lockfile, remaining)
+}
+
+func (r *Refs) writePackedRefs(lockfile *lockfile.Lockfile, packed map[string]*packedRef) error {
+	names := make([]string, 0, len(packed))
+	for name := range packed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteString(PACKED_REFS_HEADER)
+	for _, name := range names {
+		ref := packed[name]
+		fmt.Fprintf(&buf, "%s %s\n", ref.oid, ref.name)
+		if ref.peeled != "" {
+			fmt.Fprintf(&buf, "^%s\n", ref.peeled)
+		}
+	}
+
+	r.packed, r.packedStat = nil, nil
+	if err := lockfile.Write(buf.Bytes()); err != nil {
+		lockfile.Rollback()
+		return err
+	}
+	return lockfile.Commit()
+}
@@ -20,11 +20,13 @@ type MergeType int
 const (
 	Merge MergeType = iota
 	CherryPick
+	Revert
 )
 
 var HEAD_FILES = map[MergeType]string{
 	Merge:      "MERGE_HEAD",
 	CherryPick: "CHERRY_PICK_HEAD",
+	Revert:     "REVERT_HEAD",
 }
 
 type PendingCommit struct {
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"bufio"
+	"building-git/lib/database"
+	"building-git/lib/lockfile"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const LOGS_DIR = "logs"
+
+type ReflogEntry struct {
+	OldOid   string
+	NewOid   string
+	Identity *database.Author
+	Message  string
+}
+
+func (e *ReflogEntry) String() string {
+	return fmt.Sprintf("%s %s %s <%s> %d %s\t%s\n",
+		NilToZero(e.OldOid),
+		NilToZero(e.NewOid),
+		e.Identity.Name,
+		e.Identity.Email,
+		e.Identity.Time().Unix(),
+		e.Identity.Time().Format("-0700"),
+		e.Message)
+}
+
+type Reflog struct {
+	pathname string
+}
+
+func NewReflog(pathname string) *Reflog {
+	return &Reflog{
+		pathname: pathname,
+	}
+}
+
+func (r *Reflog) Exists(name string) bool {
+	stat, err := os.Stat(r.logPath(name))
+	return err == nil && stat.Mode().IsRegular()
+}
+
+func (r *Reflog) Append(name, oldOid, newOid, message string, now time.Time) error {
+	path := r.logPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entry := &ReflogEntry{oldOid, newOid, currentIdentity(now), strings.Split(message, "\n")[0]}
+	_, err = file.WriteString(entry.String())
+	return err
+}
+
+func (r *Reflog) Read(name string) ([]*ReflogEntry, error) {
+	file, err := os.Open(r.logPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*ReflogEntry{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []*ReflogEntry{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry, err := parseReflogEntry(scanner.Text())
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func (r *Reflog) Write(name string, entries []*ReflogEntry) error {
+	lockfile := lockfile.NewLockfile(r.logPath(name))
+	if err := lockfile.HoldForUpdate(); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := lockfile.Write([]byte(entry.String())); err != nil {
+			lockfile.Rollback()
+			return err
+		}
+	}
+	return lockfile.Commit()
+}
+
+func (r *Reflog) Delete(name string) error {
+	path := r.logPath(name)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	logsPath := filepath.Join(r.pathname, LOGS_DIR)
+	for dir := filepath.Dir(path); dir != logsPath && strings.HasPrefix(dir, logsPath); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (r *Reflog) ListLogs() ([]string, error) {
+	logsPath := filepath.Join(r.pathname, LOGS_DIR)
+	names := []string{}
+
+	err := filepath.Walk(logsPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || strings.HasSuffix(path, ".lock") {
+			return err
+		}
+		name, err := filepath.Rel(logsPath, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return names, nil
+	}
+	return names, err
+}
+
+func (r *Reflog) logPath(name string) string {
+	return filepath.Join(r.pathname, LOGS_DIR, name)
+}
+
+func parseReflogEntry(line string) (*ReflogEntry, error) {
+	parts := strings.SplitN(line, "\t", 2)
+	header := strings.SplitN(parts[0], " ", 3)
+	if len(header) != 3 {
+		return nil, fmt.Errorf("invalid reflog entry: %s", line)
+	}
+
+	identity, err := database.ParseAuthor(header[2])
+	if err != nil {
+		return nil, err
+	}
+
+	message := ""
+	if len(parts) == 2 {
+		message = parts[1]
+	}
+	return &ReflogEntry{ZeroToNil(header[0]), ZeroToNil(header[1]), identity, message}, nil
+}
+
+func currentIdentity(now time.Time) *database.Author {
+	name, email := os.Getenv("GIT_AUTHOR_NAME"), os.Getenv("GIT_AUTHOR_EMAIL")
+	if name == "" {
+		name = "unknown"
+	}
+	return database.NewAuthor(name, email, now)
+}
+
+const ZERO_OID = "0000000000000000000000000000000000000000"
+
+// NilToZero writes a missing oid as the all-zero oid used by reflogs and the
+// push protocol, and ZeroToNil reads it back.
+func NilToZero(oid string) string {
+	if oid == "" {
+		return ZERO_OID
+	}
+	return oid
+}
+
+func ZeroToNil(oid string) string {
+	if oid == ZERO_OID {
+		return ""
+	}
+	return oid
+}
@@ -1,12 +1,15 @@
 package repository
 
 import (
+	"building-git/lib/database"
 	"building-git/lib/errors"
 	"building-git/lib/lockfile"
 	"building-git/lib/pathutils"
 	"io/fs"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"fmt"
 	"os"
@@ -16,7 +19,10 @@ import (
 const HEAD = "HEAD"
 const ORIG_HEAD = "ORIG_HEAD"
 
-var symRefRegexp = regexp.MustCompile(`^ref: (.+)$`)
+var (
+	symRefRegexp = regexp.MustCompile(`^ref: (.+)$`)
+	CHECKOUT_LOG = regexp.MustCompile(`^checkout: moving from (\S+) to \S+$`)
+)
 
 const REFS_DIR = "refs"
 
@@ -24,6 +30,10 @@ func HeadsDir() string {
 	return filepath.Join(REFS_DIR, "heads")
 }
 
+func TagsDir() string {
+	return filepath.Join(REFS_DIR, "tags")
+}
+
 func RemotesDir() string {
 	return filepath.Join(REFS_DIR, "remotes")
 }
@@ -36,6 +46,14 @@ func (e *InvalidBranchError) Error() string {
 	return fmt.Sprintf("%s", e.msg)
 }
 
+type StaleValueError struct {
+	msg string
+}
+
+func (e *StaleValueError) Error() string {
+	return e.msg
+}
+
 type SymRef struct {
 	Refs *Refs
 	Path string
@@ -65,7 +83,11 @@ type Refs struct {
 	pathname    string
 	refsPath    string
 	headsPath   string
+	tagsPath    string
 	remotesPath string
+	reflog      *Reflog
+	packed      map[string]*packedRef
+	packedStat  os.FileInfo
 }
 
 func NewRefs(pathname string) *Refs {
@@ -73,33 +95,75 @@ func NewRefs(pathname string) *Refs {
 		pathname:    pathname,
 		refsPath:    filepath.Join(pathname, REFS_DIR),
 		headsPath:   filepath.Join(pathname, HeadsDir()),
+		tagsPath:    filepath.Join(pathname, TagsDir()),
 		remotesPath: filepath.Join(pathname, RemotesDir()),
+		reflog:      NewReflog(pathname),
 	}
 }
 
+func (r *Refs) PreviousBranch(n int) (string, error) {
+	entries, err := r.reflog.Read(HEAD)
+	if err != nil {
+		return "", err
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		match := CHECKOUT_LOG.FindStringSubmatch(entries[i].Message)
+		if match == nil {
+			continue
+		}
+		if n--; n == 0 {
+			return match[1], nil
+		}
+	}
+	return "", nil
+}
+
 func (r *Refs) ReadHead() (string, error) {
 	return r.readSymRef(filepath.Join(r.pathname, HEAD))
 }
 
-func (r *Refs) UpdateHead(oid string) (string, error) {
-	return r.updateSymRef(filepath.Join(r.pathname, HEAD), oid)
+func (r *Refs) UpdateHead(oid, message string) (string, error) {
+	return r.updateSymRef(HEAD, oid, message)
 }
 
-func (r *Refs) SetHead(revision, oid string) error {
+func (r *Refs) UpdateSymbolicRef(name, target string) error {
+	return r.updateRefFile(filepath.Join(r.pathname, name), fmt.Sprintf("ref: %s", target))
+}
+
+func (r *Refs) SetHead(revision, oid, message string) error {
 	head := filepath.Join(r.pathname, HEAD)
 	path := filepath.Join(r.headsPath, revision)
+	oldOid, _ := r.ReadHead()
 
-	if fileInfo, err := os.Stat(path); err == nil && fileInfo.Mode().IsRegular() {
-		relative, err := relativePathFrom(r.pathname, path)
-		if err != nil {
-			return err
+	var err error
+	if r.isBranch(revision, path) {
+		relative, relErr := relativePathFrom(r.pathname, path)
+		if relErr != nil {
+			return relErr
 		}
-		return r.updateRefFile(head, fmt.Sprintf("ref: %s", relative))
+		err = r.updateRefFile(head, fmt.Sprintf("ref: %s", relative))
+	} else {
+		err = r.updateRefFile(head, oid)
+	}
+	if err != nil {
+		return err
+	}
+	return r.logRefUpdate(HEAD, oldOid, oid, message)
+}
+
+func (r *Refs) isBranch(revision, path string) bool {
+	if fileInfo, err := os.Stat(path); err == nil {
+		return fileInfo.Mode().IsRegular()
 	}
-	return r.updateRefFile(head, oid)
+	return r.readPackedRef(filepath.Join(HeadsDir(), revision)) != nil
+}
+
+func (r *Refs) Reflog() *Reflog {
+	return r.reflog
 }
 
-func (r *Refs) listAllRefs() []*SymRef {
+func (r *Refs) ListAllRefs() []*SymRef {
 	list, _ := r.listRefs(r.refsPath)
 	list = append([]*SymRef{{Refs: r, Path: HEAD}}, list...)
 	return list
@@ -109,14 +173,21 @@ func (r *Refs) ListBranches() ([]*SymRef, error) {
 	return r.listRefs(r.headsPath)
 }
 
-func (r *Refs) ReverseRefs() map[string][]*SymRef {
+func (r *Refs) ListTags() ([]*SymRef, error) {
+	return r.listRefs(r.tagsPath)
+}
+
+func (r *Refs) ReverseRefs(db *database.Database) map[string][]*SymRef {
 	table := make(map[string][]*SymRef)
 
-	for _, ref := range r.listAllRefs() {
+	for _, ref := range r.ListAllRefs() {
 		oid, _ := ref.ReadOid()
 		if oid == "" {
 			continue
 		}
+		if object, err := db.Peel(oid); err == nil {
+			oid = object.Oid()
+		}
 		table[oid] = append(table[oid], ref)
 	}
 	return table
@@ -125,7 +196,7 @@ func (r *Refs) ReverseRefs() map[string][]*SymRef {
 func (r *Refs) ShortName(path string) (string, error) {
 	joinedPath := filepath.Join(r.pathname, path)
 
-	prefixes := []string{r.remotesPath, r.headsPath, r.pathname}
+	prefixes := []string{r.remotesPath, r.headsPath, r.tagsPath, r.pathname}
 	for _, prefix := range prefixes {
 		if strings.HasPrefix(joinedPath, prefix) {
 			if prefix != "" {
@@ -166,11 +237,66 @@ func (r *Refs) ReadRef(name string) (string, error) {
 	return r.readSymRef(path)
 }
 
+func (r *Refs) UpdateRef(name, oid, message string) error {
+	path := filepath.Join(r.pathname, name)
+	if oid != "" {
+		oldOid, _ := r.readSymRef(path)
+		if err := r.updateRefFile(path, oid); err != nil {
+			return err
+		}
+		return r.logRefUpdate(name, oldOid, oid, message)
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := r.deletePackedRef(name); err != nil {
+		return err
+	}
+	if err := r.reflog.Delete(name); err != nil {
+		return err
+	}
+	return r.deleteParentDirectories(path)
+}
+
+func (r *Refs) CompareAndSwap(name, oldOid, newOid, message string) error {
+	path := filepath.Join(r.pathname, name)
+	lockfile, err := r.holdRefLock(path)
+	if err != nil {
+		return err
+	}
+
+	currentOid, _ := r.readSymRef(path)
+	if currentOid != oldOid {
+		lockfile.Rollback()
+		return &StaleValueError{fmt.Sprintf("value of %s changed since last read", name)}
+	}
+
+	if newOid != "" {
+		if err := r.writeLockFile(lockfile, newOid); err != nil {
+			return err
+		}
+		return r.logRefUpdate(name, oldOid, newOid, message)
+	}
+	err = os.Remove(path)
+	lockfile.Rollback()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := r.deletePackedRef(name); err != nil {
+		return err
+	}
+	if err := r.reflog.Delete(name); err != nil {
+		return err
+	}
+	return r.deleteParentDirectories(path)
+}
+
 func (r *Refs) UpateRef(name, oid string) error {
 	return r.updateRefFile(filepath.Join(r.headsPath, name), oid)
 }
 
-func (r *Refs) CreateBranch(branchName, startOid string) error {
+func (r *Refs) CreateBranch(branchName, startOid, message string) error {
 	path := filepath.Join(r.headsPath, branchName)
 	if !IsValidRef(branchName) {
 		return &InvalidBranchError{
@@ -178,18 +304,48 @@ func (r *Refs) CreateBranch(branchName, startOid string) error {
 		}
 	}
 
-	if _, err := os.Stat(path); err == nil {
+	if r.refExists(path) {
 		return &InvalidBranchError{
 			msg: fmt.Sprintf("A branch named '%s' already exists.", branchName),
 		}
 	}
 
-	return r.updateRefFile(path, startOid)
+	if err := r.updateRefFile(path, startOid); err != nil {
+		return err
+	}
+	return r.logRefUpdate(filepath.Join(HeadsDir(), branchName), "", startOid, message)
 }
 
 func (r *Refs) DeleteBranch(branchName string) (string, error) {
-	path := filepath.Join(r.headsPath, branchName)
+	oid, err := r.deleteRef(filepath.Join(r.headsPath, branchName))
+	if err != nil {
+		return "", err
+	}
+	return oid, r.reflog.Delete(filepath.Join(HeadsDir(), branchName))
+}
 
+func (r *Refs) CreateTag(tagName, oid string, force bool) error {
+	path := filepath.Join(r.tagsPath, tagName)
+	if !IsValidRef(tagName) {
+		return &InvalidBranchError{
+			msg: fmt.Sprintf("'%s' is not a valid tag name.", tagName),
+		}
+	}
+
+	if r.refExists(path) && !force {
+		return &InvalidBranchError{
+			msg: fmt.Sprintf("tag '%s' already exists", tagName),
+		}
+	}
+
+	return r.updateRefFile(path, oid)
+}
+
+func (r *Refs) DeleteTag(tagName string) (string, error) {
+	return r.deleteRef(filepath.Join(r.tagsPath, tagName))
+}
+
+func (r *Refs) deleteRef(path string) (string, error) {
 	lockfile := lockfile.NewLockfile(path)
 	lockfile.HoldForUpdate()
 	defer lockfile.Rollback()
@@ -198,7 +354,14 @@ func (r *Refs) DeleteBranch(branchName string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if err := os.Remove(path); err != nil {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	name, err := filepath.Rel(r.pathname, path)
+	if err != nil {
+		return "", err
+	}
+	if err := r.deletePackedRef(name); err != nil {
 		return "", err
 	}
 	if err := r.deleteParentDirectories(path); err != nil {
@@ -225,7 +388,32 @@ func (r *Refs) CurrentRef(source string) (*SymRef, error) {
 }
 
 func (r *Refs) listRefs(rootPath string) ([]*SymRef, error) {
-	var refs []*SymRef
+	refs, err := r.listLooseRefs(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := r.listPackedRefs(rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		seen[ref.Path] = true
+	}
+	for _, ref := range packed {
+		if !seen[ref.Path] {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Path < refs[j].Path
+	})
+	return refs, nil
+}
+
+func (r *Refs) listLooseRefs(rootPath string) ([]*SymRef, error) {
+	refs := []*SymRef{}
 
 	err := filepath.Walk(rootPath, func(path string, info fs.FileInfo, err error) error {
 		if err != nil {
@@ -261,8 +449,16 @@ func (r *Refs) listRefs(rootPath string) ([]*SymRef, error) {
 	return refs, nil
 }
 
+func (r *Refs) ExpandName(name string) (string, error) {
+	path, err := r.pathForName(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(r.pathname, path)
+}
+
 func (r *Refs) pathForName(name string) (string, error) {
-	prefixes := []string{r.pathname, r.refsPath, r.headsPath, r.remotesPath}
+	prefixes := []string{r.pathname, r.refsPath, r.tagsPath, r.headsPath, r.remotesPath}
 
 	var err error
 	for _, prefix := range prefixes {
@@ -271,13 +467,19 @@ func (r *Refs) pathForName(name string) (string, error) {
 			return path, nil
 		}
 	}
+	for _, prefix := range prefixes {
+		path := filepath.Join(prefix, name)
+		if relPath, relErr := filepath.Rel(r.pathname, path); relErr == nil && r.readPackedRef(relPath) != nil {
+			return path, nil
+		}
+	}
 	return "", err
 }
 
 func (r *Refs) deleteParentDirectories(path string) error {
 	dirs := pathutils.Ascend(path)
 	for _, dir := range dirs {
-		if dir == r.headsPath {
+		if dir == r.headsPath || dir == r.tagsPath || dir == r.refsPath {
 			break
 		}
 		err := os.Remove(dir)
@@ -297,7 +499,7 @@ func (r *Refs) readOidOrSymRef(path string) (interface{}, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, nil
+			return r.readPackedOid(path), nil
 		}
 		return nil, err
 	}
@@ -310,6 +512,24 @@ func (r *Refs) readOidOrSymRef(path string) (interface{}, error) {
 	return &Ref{oid: trimedData}, nil
 }
 
+func (r *Refs) readPackedOid(path string) interface{} {
+	name, err := filepath.Rel(r.pathname, path)
+	if err != nil || !strings.HasPrefix(name, REFS_DIR+"/") {
+		return nil
+	}
+	if ref := r.readPackedRef(name); ref != nil {
+		return &Ref{oid: ref.oid}
+	}
+	return nil
+}
+
+func (r *Refs) refExists(path string) bool {
+	if _, err := os.Stat(path); err == nil {
+		return true
+	}
+	return r.readPackedOid(path) != nil
+}
+
 func (r *Refs) readSymRef(path string) (string, error) {
 	ref, err := r.readOidOrSymRef(path)
 	if err != nil {
@@ -326,6 +546,14 @@ func (r *Refs) readSymRef(path string) (string, error) {
 }
 
 func (r *Refs) updateRefFile(path, oid string) error {
+	lockfile, err := r.holdRefLock(path)
+	if err != nil {
+		return err
+	}
+	return r.writeLockFile(lockfile, oid)
+}
+
+func (r *Refs) holdRefLock(path string) (*lockfile.Lockfile, error) {
 	lockfile := lockfile.NewLockfile(path)
 
 	for {
@@ -334,20 +562,20 @@ func (r *Refs) updateRefFile(path, oid string) error {
 			if _, ok := err.(*errors.MissingParentError); ok {
 				err := os.MkdirAll(filepath.Dir(path), 0755)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				continue
 			} else {
-				return err
+				return nil, err
 			}
 		}
 		break
 	}
-
-	return r.writeLockFile(lockfile, oid)
+	return lockfile, nil
 }
 
-func (r *Refs) updateSymRef(path, oid string) (string, error) {
+func (r *Refs) updateSymRef(name, oid, message string) (string, error) {
+	path := filepath.Join(r.pathname, name)
 	lockfile := lockfile.NewLockfile(path)
 	err := lockfile.HoldForUpdate()
 	if err != nil {
@@ -362,15 +590,40 @@ func (r *Refs) updateSymRef(path, oid string) (string, error) {
 	switch v := ref.(type) {
 	case *SymRef:
 		defer lockfile.Rollback()
-		return r.updateSymRef(filepath.Join(r.pathname, v.Path), oid)
+		oldOid, err := r.updateSymRef(v.Path, oid, message)
+		if err != nil {
+			return "", err
+		}
+		return oldOid, r.logRefUpdate(name, oldOid, oid, message)
 	default:
-		err := r.writeLockFile(lockfile, oid)
-		r, ok := v.(*Ref)
-		if err != nil || !ok {
+		if err := r.writeLockFile(lockfile, oid); err != nil {
 			return "", err
 		}
-		return r.oid, nil
+		oldOid := ""
+		if ref, ok := v.(*Ref); ok {
+			oldOid = ref.oid
+		}
+		return oldOid, r.logRefUpdate(name, oldOid, oid, message)
+	}
+}
+
+func (r *Refs) logRefUpdate(name, oldOid, newOid, message string) error {
+	if !r.shouldLogRef(name) {
+		return nil
+	}
+	return r.reflog.Append(name, oldOid, newOid, message, time.Now())
+}
+
+func (r *Refs) shouldLogRef(name string) bool {
+	if name == HEAD || name == STASH_REF || r.reflog.Exists(name) {
+		return true
+	}
+	for _, dir := range []string{HeadsDir(), RemotesDir()} {
+		if strings.HasPrefix(name, dir+"/") {
+			return true
+		}
 	}
+	return false
 }
 
 func (r *Refs) writeLockFile(lockfile *lockfile.Lockfile, oid string) error {
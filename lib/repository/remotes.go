@@ -66,6 +66,19 @@ func (r *Remotes) ListRemotes() []string {
 	return r.config.Subsection("remote")
 }
 
+func (r *Remotes) SetUpstream(branch, upstream string) (string, string, error) {
+	for _, name := range r.ListRemotes() {
+		refName, err := r.Get(name).SetUpstream(branch, upstream)
+		if err != nil {
+			return "", "", err
+		}
+		if refName != "" {
+			return name, refName, nil
+		}
+	}
+	return "", "", &InvalidBranchError{fmt.Sprintf("Cannot setup tracking information; starting point '%s' is not a branch", upstream)}
+}
+
 func (r *Remotes) Get(name string) *remotes.Remote {
 	r.config.Open()
 	if !r.config.HasSection([]string{"remote", name}) {
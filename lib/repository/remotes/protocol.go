@@ -0,0 +1,144 @@
+package remotes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var PKT_LINE_HEAD = regexp.MustCompile(`^[0-9a-f]{4}$`)
+
+type Protocol struct {
+	command    string
+	input      *bufio.Reader
+	output     io.Writer
+	capsLocal  []string
+	capsRemote []string
+	capsSent   bool
+}
+
+func NewProtocol(command string, input io.Reader, output io.Writer, capabilities []string) *Protocol {
+	return &Protocol{
+		command:   command,
+		input:     bufio.NewReader(input),
+		output:    output,
+		capsLocal: capabilities,
+	}
+}
+
+func (p *Protocol) Input() io.Reader {
+	return p.input
+}
+
+func (p *Protocol) Output() io.Writer {
+	return p.output
+}
+
+func (p *Protocol) IsCapable(ability string) bool {
+	for _, cap := range p.capsRemote {
+		if cap == ability {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Protocol) SendPacket(line string) error {
+	line = p.appendCaps(line)
+	_, err := fmt.Fprintf(p.output, "%04x%s\n", len(line)+5, line)
+	return err
+}
+
+func (p *Protocol) SendFlush() error {
+	_, err := io.WriteString(p.output, "0000")
+	return err
+}
+
+func (p *Protocol) RecvPacket() (string, bool, error) {
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(p.input, head); err != nil {
+		return "", false, err
+	}
+	if !PKT_LINE_HEAD.Match(head) {
+		return string(head), false, nil
+	}
+
+	size, _ := strconv.ParseInt(string(head), 16, 32)
+	if size == 0 {
+		return "", true, nil
+	}
+
+	data := make([]byte, size-4)
+	if _, err := io.ReadFull(p.input, data); err != nil {
+		return "", false, err
+	}
+	line := strings.TrimSuffix(string(data), "\n")
+	return p.detectCaps(line), false, nil
+}
+
+func (p *Protocol) RecvUntil(terminator string, fn func(line string) error) error {
+	for {
+		line, flush, err := p.RecvPacket()
+		if err != nil {
+			return err
+		}
+		if (terminator == "" && flush) || (terminator != "" && line == terminator) {
+			return nil
+		}
+		if flush {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Protocol) appendCaps(line string) string {
+	if p.capsSent {
+		return line
+	}
+	p.capsSent = true
+
+	sep := "\x00"
+	if p.command == "fetch" {
+		sep = " "
+	}
+
+	caps := p.capsLocal
+	if p.capsRemote != nil {
+		caps = []string{}
+		for _, cap := range p.capsLocal {
+			if p.IsCapable(cap) {
+				caps = append(caps, cap)
+			}
+		}
+	}
+	return line + sep + strings.Join(caps, " ")
+}
+
+func (p *Protocol) detectCaps(line string) string {
+	if p.capsRemote != nil {
+		return line
+	}
+
+	sep, n := "\x00", 2
+	if p.command == "upload-pack" {
+		sep, n = " ", 3
+	}
+
+	parts := strings.SplitN(line, sep, n)
+	caps := ""
+	if len(parts) == n {
+		caps = parts[n-1]
+		parts = parts[:n-1]
+	}
+	p.capsRemote = strings.Fields(caps)
+	if p.capsRemote == nil {
+		p.capsRemote = []string{}
+	}
+	return strings.Join(parts, " ")
+}
@@ -1,11 +1,15 @@
 package remotes
 
 import (
+	"path/filepath"
 	"regexp"
 	"strings"
 )
 
-var REFSPEC_FORMAT = regexp.MustCompile(`^(\+?)([^:]+):([^:]+)$`)
+var (
+	REFSPEC_FORMAT = regexp.MustCompile(`^(\+?)([^:]*)(:([^:]*))?$`)
+	INVALID_NAME   = regexp.MustCompile(`^\.|\/\.|\.\.|^\/|\/$|\.lock$|@\{|[\x00-\x20*:?\[\\^~\x7f]`)
+)
 
 type RefSpec struct {
 	source string
@@ -22,19 +26,59 @@ func NewRefSpec(source, target string, forced bool) *RefSpec {
 }
 
 func ParseRefspec(spec string) *RefSpec {
+	return parseRefspec(spec, nil)
+}
+
+// parseRefspec expands the short names in spec. The source is looked up in
+// refs first, and a short target goes into the same namespace as a source
+// that turned out to be a tag.
+func parseRefspec(spec string, refs []string) *RefSpec {
 	matches := REFSPEC_FORMAT.FindStringSubmatch(spec)
-	forced := matches[1] == "+"
-	return NewRefSpec(
-		matches[2],
-		matches[3],
-		forced,
-	)
+	if matches == nil {
+		return NewRefSpec("", "", false)
+	}
+
+	source := canonical(matches[2], refs, "refs/heads")
+	namespace := "refs/heads"
+	if strings.HasPrefix(source, "refs/tags/") {
+		namespace = "refs/tags"
+	}
+	target := canonical(matches[4], nil, namespace)
+	if matches[3] == "" {
+		target = source
+	}
+	return NewRefSpec(source, target, matches[1] == "+")
+}
+
+// canonical returns the full name of a ref, trying the same places as git
+// does to find a short name among refs before putting it in namespace.
+func canonical(name string, refs []string, namespace string) string {
+	if name == "" || INVALID_NAME.MatchString(name) {
+		return name
+	}
+
+	for _, dir := range []string{"refs", "refs/tags", "refs/heads", "refs/remotes"} {
+		candidate := filepath.Join(dir, name)
+		for _, ref := range refs {
+			if ref == candidate {
+				return candidate
+			}
+		}
+	}
+
+	first := strings.Split(name, "/")[0]
+	for _, dir := range []string{"refs", "refs/heads", "refs/remotes"} {
+		if filepath.Base(dir) == first {
+			return filepath.Join(filepath.Dir(dir), name)
+		}
+	}
+	return filepath.Join(namespace, name)
 }
 
 func ExpandRefspecs(specs []string, refs []string) map[string][]interface{} {
 	refspecs := make([]*RefSpec, len(specs))
 	for i, spec := range specs {
-		refspecs[i] = ParseRefspec(spec)
+		refspecs[i] = parseRefspec(spec, refs)
 	}
 
 	mappings := make(map[string][]interface{})
@@ -46,8 +90,27 @@ func ExpandRefspecs(specs []string, refs []string) map[string][]interface{} {
 	return mappings
 }
 
+func InvertRefspecs(specs []string, ref string) string {
+	for _, spec := range specs {
+		refspec := ParseRefspec(spec)
+		inverted := NewRefSpec(refspec.target, refspec.source, refspec.forced)
+		if !strings.Contains(inverted.source, "*") && inverted.source != ref {
+			continue
+		}
+		for target := range inverted.MatchRefs([]string{ref}) {
+			return target
+		}
+	}
+	return ""
+}
+
 func (r *RefSpec) MatchRefs(refs []string) map[string][]interface{} {
 	mappings := make(map[string][]interface{})
+	if !strings.Contains(r.source, "*") {
+		mappings[r.target] = []interface{}{r.source, r.forced}
+		return mappings
+	}
+
 	patternStr := "^" + strings.ReplaceAll(regexp.QuoteMeta(r.source), `\*`, "(.*)") + "$"
 	pattern := regexp.MustCompile(patternStr)
 
@@ -17,34 +17,67 @@ func NewRemote(config *config.Config, name string) *Remote {
 }
 
 func (r *Remote) FetchUrl() (string, error) {
-	v, err := r.config.Get([]string{"remote", r.name, "url"})
-	if err != nil {
-		return "", err
+	return r.getString("url")
+}
+
+func (r *Remote) FetchSpecs() ([]string, error) {
+	return r.getStrings("fetch")
+}
+
+func (r *Remote) PushUrl() (string, error) {
+	url, err := r.getString("pushurl")
+	if err != nil || url != "" {
+		return url, err
 	}
-	return v.(string), nil
+	return r.FetchUrl()
+}
+
+func (r *Remote) PushSpecs() ([]string, error) {
+	return r.getStrings("push")
+}
+
+func (r *Remote) Uploader() (string, error) {
+	return r.getString("uploadpack")
 }
 
-func (r *Remote) FetchSpecs() (string, error) {
-	v, err := r.config.Get([]string{"remote", r.name, "fetch"})
+func (r *Remote) Receiver() (string, error) {
+	return r.getString("receivepack")
+}
+
+func (r *Remote) SetUpstream(branch, upstream string) (string, error) {
+	specs, err := r.FetchSpecs()
 	if err != nil {
 		return "", err
 	}
-	return v.(string), nil
+	refName := InvertRefspecs(specs, upstream)
+	if refName == "" {
+		return "", nil
+	}
+
+	if err := r.config.OpenForUpdate(); err != nil {
+		return "", err
+	}
+	r.config.Set([]string{"branch", branch, "remote"}, r.name)
+	r.config.Set([]string{"branch", branch, "merge"}, refName)
+	return refName, r.config.Save()
 }
 
-func (r *Remote) PushUrl() (string, error) {
-	v, err := r.config.Get([]string{"remote", r.name, "url"})
-	if err == nil {
-		return v.(string), nil
+func (r *Remote) getString(name string) (string, error) {
+	v, err := r.config.Get([]string{"remote", r.name, name})
+	if err != nil || v == nil {
+		return "", err
 	}
-	v, _ = r.FetchUrl()
 	return v.(string), nil
 }
 
-func (r *Remote) Uploader() (string, error) {
-	v, err := r.config.Get([]string{"remote", r.name, "uploadpack"})
+func (r *Remote) getStrings(name string) ([]string, error) {
+	values, err := r.config.GetAll([]string{"remote", r.name, name})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return v.(string), nil
+	specs := []string{}
+	for _, v := range values {
+		specs = append(specs, v.(string))
+	}
+	return specs, nil
 }
@@ -3,6 +3,7 @@ package repository
 import (
 	"building-git/lib/config"
 	"building-git/lib/database"
+	"building-git/lib/diff"
 	"building-git/lib/index"
 
 	"path/filepath"
@@ -19,22 +20,49 @@ type Repository struct {
 }
 
 func NewRepository(rootPath string) *Repository {
-	gitPath := filepath.Join(rootPath, ".git")
+	return newRepository(filepath.Join(rootPath, ".git"), rootPath)
+}
+
+func NewBareRepository(gitPath string) *Repository {
+	return newRepository(gitPath, gitPath)
+}
+
+func newRepository(gitPath, rootPath string) *Repository {
+	stack := config.NewStack(gitPath)
+	workspace := NewWorkspace(rootPath)
+	workspace.ignore = NewIgnore(rootPath, gitPath, stack)
+
 	return &Repository{
 		GitPath:       gitPath,
-		Config:        config.NewStack(gitPath),
+		Config:        stack,
 		Database:      database.NewDatabase(filepath.Join(gitPath, "objects")),
 		Index:         index.NewIndex(filepath.Join(gitPath, "index")),
 		Refs:          NewRefs(gitPath),
-		Workspace:     NewWorkspace(rootPath),
+		Workspace:     workspace,
 		PendingCommit: NewPendingCommit(gitPath),
 	}
 }
 
+func (r *Repository) IsBare() bool {
+	return filepath.Base(r.GitPath) != ".git"
+}
+
 func (r *Repository) HardReset(oid string) {
 	NewHardReset(r, oid).Execute()
 }
 
+// DiffAlgorithm returns the algorithm named by the diff.algorithm config key,
+// falling back to Myers when it is unset or not recognised.
+func (r *Repository) DiffAlgorithm() diff.Algorithm {
+	value, _ := r.Config.Get([]string{"diff", "algorithm"})
+	if name, ok := value.(string); ok {
+		if algorithm, err := diff.ParseAlgorithm(name); err == nil {
+			return algorithm
+		}
+	}
+	return diff.MYERS
+}
+
 func (r *Repository) Remotes() *Remotes {
 	return NewRemotes(config.StackFile("local", r.Config))
 }
@@ -3,6 +3,7 @@ package repository
 import (
 	"building-git/lib/database"
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"sort"
 )
@@ -27,6 +28,8 @@ type RevList struct {
 	flags   map[string]map[RevListFlag]bool
 	queue   []*database.Commit
 	pending []*database.Entry
+	tags    []*database.Tag
+	paths   map[string]string
 	limited bool
 	prune   []string
 	diffs   map[[2]string]map[string][2]database.TreeObject
@@ -36,19 +39,27 @@ type RevList struct {
 	all     bool
 	objects bool
 	missing bool
+	topo    bool
+	matcher *commitFilter
+	follow  bool
+	filters map[string]*database.PathFilter
 }
 
 type RevListOption struct {
-	Walk    *bool
-	All     bool
-	Objects bool
-	Missing bool
+	Walk      *bool
+	All       bool
+	Objects   bool
+	Missing   bool
+	TopoOrder bool
+	Follow    bool
+	Filter    RevListFilter
 }
 
 func NewRevList(repo *Repository, revs []string, options RevListOption) (*RevList, error) {
 	revList := &RevList{
 		repo:    repo,
 		commits: map[string]*database.Commit{},
+		paths:   map[string]string{},
 		flags:   map[string]map[RevListFlag]bool{},
 		queue:   make([]*database.Commit, 0),
 		prune:   make([]string, 0),
@@ -57,6 +68,9 @@ func NewRevList(repo *Repository, revs []string, options RevListOption) (*RevLis
 		objects: options.Objects,
 		all:     options.All,
 		missing: options.Missing,
+		topo:    options.TopoOrder,
+		follow:  options.Follow,
+		filters: map[string]*database.PathFilter{},
 	}
 	if options.Walk == nil {
 		revList.walk = true
@@ -65,7 +79,7 @@ func NewRevList(repo *Repository, revs []string, options RevListOption) (*RevLis
 	}
 
 	if revList.all {
-		err := revList.includeRefs(repo.Refs.listAllRefs())
+		err := revList.includeRefs(repo.Refs.ListAllRefs())
 		if err != nil {
 			return nil, err
 		}
@@ -83,14 +97,22 @@ func NewRevList(repo *Repository, revs []string, options RevListOption) (*RevLis
 			return nil, err
 		}
 	}
+	if revList.follow && len(revList.prune) != 1 {
+		return nil, fmt.Errorf("--follow requires exactly one pathspec")
+	}
 	revList.filter = database.PathFilterBuild(revList.prune)
 
+	matcher, err := newCommitFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+	revList.matcher = matcher
+
 	return revList, nil
 }
 
 type RevListObject interface {
 	Oid() string
-	SetOid(string)
 	Type() string
 }
 
@@ -102,16 +124,34 @@ func (r *RevList) Each() []RevListObject {
 		r.markEdgesUninteresting()
 	}
 
-	var objects []RevListObject
+	var commits []*database.Commit
 	r.traverseCommits(func(c *database.Commit) {
-		objects = append(objects, c)
+		commits = append(commits, c)
 	})
+	if r.topo {
+		commits = r.sortTopologically(commits)
+	}
+	if max := r.matcher.options.MaxCount; max != nil && len(commits) > *max {
+		commits = commits[:*max]
+	}
+
+	var objects []RevListObject
+	for _, commit := range commits {
+		objects = append(objects, commit)
+	}
+	for _, tag := range r.tags {
+		objects = append(objects, tag)
+	}
 	r.traversePending(func(obj database.TreeObject) {
 		objects = append(objects, obj.(RevListObject))
 	})
 	return objects
 }
 
+func (r *RevList) Path(oid string) string {
+	return r.paths[oid]
+}
+
 func (r *RevList) ReverseEach() []RevListObject {
 	objects := r.Each()
 	for i := 0; i < len(objects)/2; i++ {
@@ -127,7 +167,11 @@ func (r *RevList) TreeDiff(oldOid, newOid string, differ *database.PathFilter) m
 		return diff
 	}
 
-	r.diffs[key] = r.repo.Database.TreeDiff(oldOid, newOid, r.filter)
+	filter, ok := r.filters[newOid]
+	if !ok {
+		filter = r.filter
+	}
+	r.diffs[key] = r.repo.Database.TreeDiff(oldOid, newOid, filter)
 	return r.diffs[key]
 }
 
@@ -171,12 +215,18 @@ func (r *RevList) setStartPoint(rev string, interesting bool) error {
 	}
 
 	oid, err := NewRevision(r.repo, rev).Resolve(COMMIT)
-	if err != nil && !r.missing {
+	if err != nil {
+		if r.missing {
+			return nil
+		}
 		return err
 	}
 	commit := r.loadCommit(oid)
 
 	r.enqueueCommit(commit)
+	if interesting && r.objects {
+		r.addTags(rev)
+	}
 
 	if !interesting {
 		r.limited = true
@@ -186,6 +236,24 @@ func (r *RevList) setStartPoint(rev string, interesting bool) error {
 	return nil
 }
 
+func (r *RevList) addTags(rev string) {
+	oid, _ := NewRevision(r.repo, rev).Resolve("")
+	for oid != "" {
+		object, err := r.repo.Database.Load(oid)
+		if err != nil {
+			return
+		}
+		tag, ok := object.(*database.Tag)
+		if !ok {
+			return
+		}
+		if r.mark(oid, seen) {
+			r.tags = append(r.tags, tag)
+		}
+		oid = tag.Object()
+	}
+}
+
 func (r *RevList) enqueueCommit(commit *database.Commit) {
 	if !r.mark(commit.Oid(), seen) {
 		return
@@ -224,8 +292,6 @@ func (r *RevList) isStillInteresting() bool {
 	}
 	newestIn := r.queue[0]
 
-	fmt.Println(oldestOut)
-
 	if oldestOut != nil &&
 		(oldestOut.Date().Before(newestIn.Date()) ||
 			oldestOut.Date().Equal(newestIn.Date())) {
@@ -286,13 +352,13 @@ func (r *RevList) markParentsUninteresting(commit *database.Commit) {
 
 func (r *RevList) markEdgesUninteresting() {
 	for _, c := range r.queue {
-		if r.mark(c.Oid(), uninteresting) {
+		if r.isMarked(c.Oid(), uninteresting) {
 			r.markTreeUninteresting(c.Tree())
 		}
 
 		for _, oid := range c.Parents {
 			if !r.isMarked(oid, uninteresting) {
-				return
+				continue
 			}
 			parent := r.loadCommit(oid)
 			r.markTreeUninteresting(parent.Tree())
@@ -302,17 +368,27 @@ func (r *RevList) markEdgesUninteresting() {
 
 func (r *RevList) markTreeUninteresting(treeOid string) {
 	entry := r.repo.Database.TreeEntry(treeOid)
-	r.traverseTree(entry, func(object *database.Entry) bool {
+	r.traverseTree(entry, "", func(object *database.Entry) bool {
 		return r.mark(object.Oid(), uninteresting)
 	})
 }
 
+func (r *RevList) parents(commit *database.Commit) []string {
+	if r.matcher.options.FirstParent && len(commit.Parents) > 1 {
+		return commit.Parents[:1]
+	}
+	return commit.Parents
+}
+
 func (r *RevList) simplifyCommit(commit *database.Commit) []string {
 	if len(r.prune) == 0 {
-		return commit.Parents
+		return r.parents(commit)
+	}
+	if r.follow {
+		r.filters[commit.Oid()] = r.filter
 	}
 
-	parents := commit.Parents
+	parents := r.parents(commit)
 	if len(parents) == 0 {
 		parents = append(parents, "")
 	}
@@ -323,14 +399,50 @@ func (r *RevList) simplifyCommit(commit *database.Commit) []string {
 			continue
 		}
 		r.mark(commit.Oid(), treesame)
+		if oid == "" {
+			return []string{}
+		}
 		return []string{oid}
 	}
 
-	return commit.Parents
+	if r.follow {
+		r.followRename(commit)
+	}
+	return r.parents(commit)
+}
+
+// followRename checks whether the path being followed came into existence in
+// this commit by being renamed, in which case the walk carries on down the
+// commit's history looking for the old path. The commit itself is diffed on
+// both paths so that the rename shows up in its patch.
+func (r *RevList) followRename(commit *database.Commit) {
+	path := r.prune[0]
+	changes, ok := r.TreeDiff(commit.Parent(), commit.Oid(), nil)[path]
+	if !ok || (changes[0] != nil && !changes[0].IsNil()) {
+		return
+	}
+
+	diff := r.repo.Database.TreeDiff(commit.Parent(), commit.Oid(), nil)
+	_, renames := r.repo.Database.DetectRenames(diff, database.RenameOption{})
+	for _, rename := range renames {
+		if rename.NewPath != path || rename.Copy {
+			continue
+		}
+		r.prune = []string{rename.OldPath}
+		r.filter = database.PathFilterBuild(r.prune)
+		r.filters[commit.Oid()] = database.PathFilterBuild([]string{rename.OldPath, rename.NewPath})
+		r.diffs[[2]string{commit.Parent(), commit.Oid()}] = r.repo.Database.TreeDiff(commit.Parent(), commit.Oid(), r.filters[commit.Oid()])
+		return
+	}
 }
 
 func (r *RevList) traverseCommits(fn func(*database.Commit)) {
+	count := 0
 	for len(r.queue) > 0 {
+		if max := r.matcher.options.MaxCount; max != nil && !r.topo && count >= *max {
+			return
+		}
+
 		commit := r.queue[0]
 		r.queue = r.queue[1:]
 
@@ -343,19 +455,67 @@ func (r *RevList) traverseCommits(fn func(*database.Commit)) {
 		if r.isMarked(commit.Oid(), treesame) {
 			continue
 		}
+		if !r.matcher.matches(commit) || !r.matchesDiff(commit) {
+			continue
+		}
+		count++
 
 		r.pending = append(r.pending, r.repo.Database.TreeEntry(commit.Tree()))
 		fn(commit)
 	}
 }
 
+// sortTopologically reorders commits so that none appears before any of its
+// children, keeping each line of history together: when a commit is shown,
+// the walk continues with whichever of its parents has just become ready.
+func (r *RevList) sortTopologically(commits []*database.Commit) []*database.Commit {
+	indegree := map[string]int{}
+	for _, commit := range commits {
+		indegree[commit.Oid()] = 1
+	}
+	for _, commit := range commits {
+		for _, parent := range commit.Parents {
+			if indegree[parent] > 0 {
+				indegree[parent]++
+			}
+		}
+	}
+
+	stack := []*database.Commit{}
+	for i := len(commits) - 1; i >= 0; i-- {
+		if indegree[commits[i].Oid()] == 1 {
+			stack = append(stack, commits[i])
+		}
+	}
+
+	sorted := []*database.Commit{}
+	for len(stack) > 0 {
+		commit := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, parent := range commit.Parents {
+			if indegree[parent] == 0 {
+				continue
+			}
+			indegree[parent]--
+			if indegree[parent] == 1 {
+				stack = append(stack, r.loadCommit(parent))
+			}
+		}
+
+		indegree[commit.Oid()] = 0
+		sorted = append(sorted, commit)
+	}
+	return sorted
+}
+
 func (r *RevList) traversePending(fn func(entry database.TreeObject)) {
 	if !r.objects {
 		return
 	}
 
 	for _, entry := range r.pending {
-		r.traverseTree(entry, func(object *database.Entry) bool {
+		r.traverseTree(entry, "", func(object *database.Entry) bool {
 			if r.isMarked(object.Oid(), uninteresting) {
 				return false
 			}
@@ -368,7 +528,11 @@ func (r *RevList) traversePending(fn func(entry database.TreeObject)) {
 	}
 }
 
-func (r *RevList) traverseTree(entry *database.Entry, fn func(entry *database.Entry) bool) {
+func (r *RevList) traverseTree(entry *database.Entry, path string, fn func(entry *database.Entry) bool) {
+	if _, exists := r.paths[entry.Oid()]; !exists {
+		r.paths[entry.Oid()] = path
+	}
+
 	if !fn(entry) {
 		return
 	}
@@ -377,10 +541,15 @@ func (r *RevList) traverseTree(entry *database.Entry, fn func(entry *database.En
 	}
 
 	tree, _ := r.repo.Database.Load(entry.Oid())
-	for _, item := range tree.(*database.Tree).Entries {
-		r.traverseTree(item.(*database.Entry), func(object *database.Entry) bool {
-			return fn(object)
-		})
+	entries := tree.(*database.Tree).Entries
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		r.traverseTree(entries[name].(*database.Entry), filepath.Join(path, name), fn)
 	}
 }
 
@@ -393,6 +562,7 @@ func (r *RevList) loadCommit(oid string) *database.Commit {
 	}
 	commitObj, _ := r.repo.Database.Load(oid)
 	commit := commitObj.(*database.Commit)
+	r.commits[oid] = commit
 
 	return commit
 }
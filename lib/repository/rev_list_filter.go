@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"building-git/lib/database"
+	"building-git/lib/diff"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RevListFilter limits which of the walked commits are listed. Zero values
+// leave the corresponding filter switched off.
+type RevListFilter struct {
+	MaxCount    *int
+	Since       time.Time
+	Until       time.Time
+	Authors     []string
+	Committers  []string
+	Grep        []string
+	AllMatch    bool
+	IgnoreCase  bool
+	FirstParent bool
+	Merges      bool
+	NoMerges    bool
+
+	// Pickaxe lists commits that change how many times the string occurs,
+	// read as a regular expression when PickaxeRegex is set. DiffGrep lists
+	// commits whose added or removed lines match the regular expression.
+	Pickaxe      string
+	PickaxeRegex bool
+	DiffGrep     string
+}
+
+type commitFilter struct {
+	options    RevListFilter
+	authors    []*regexp.Regexp
+	committers []*regexp.Regexp
+	grep       []*regexp.Regexp
+	pickaxe    *regexp.Regexp
+	diffGrep   *regexp.Regexp
+}
+
+func newCommitFilter(options RevListFilter) (*commitFilter, error) {
+	filter := &commitFilter{options: options}
+
+	var err error
+	if filter.authors, err = compilePatterns(options.Authors, options.IgnoreCase); err != nil {
+		return nil, err
+	}
+	if filter.committers, err = compilePatterns(options.Committers, options.IgnoreCase); err != nil {
+		return nil, err
+	}
+	if filter.grep, err = compilePatterns(options.Grep, options.IgnoreCase); err != nil {
+		return nil, err
+	}
+
+	if options.Pickaxe != "" && options.DiffGrep != "" {
+		return nil, fmt.Errorf("options '-G' and '-S' cannot be used together")
+	}
+	if options.Pickaxe != "" {
+		pattern := options.Pickaxe
+		if !options.PickaxeRegex {
+			pattern = regexp.QuoteMeta(pattern)
+		}
+		patterns, err := compilePatterns([]string{pattern}, options.IgnoreCase)
+		if err != nil {
+			return nil, err
+		}
+		filter.pickaxe = patterns[0]
+	}
+	if options.DiffGrep != "" {
+		patterns, err := compilePatterns([]string{options.DiffGrep}, options.IgnoreCase)
+		if err != nil {
+			return nil, err
+		}
+		filter.diffGrep = patterns[0]
+	}
+	return filter, nil
+}
+
+func compilePatterns(patterns []string, ignoreCase bool) ([]*regexp.Regexp, error) {
+	compiled := []*regexp.Regexp{}
+	for _, pattern := range patterns {
+		if ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression '%s'", strings.TrimPrefix(pattern, "(?i)"))
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func (f *commitFilter) matches(commit *database.Commit) bool {
+	if f.options.Merges && len(commit.Parents) < 2 {
+		return false
+	}
+	if f.options.NoMerges && len(commit.Parents) > 1 {
+		return false
+	}
+
+	date := commit.Date()
+	if !f.options.Since.IsZero() && date.Before(f.options.Since) {
+		return false
+	}
+	if !f.options.Until.IsZero() && date.After(f.options.Until) {
+		return false
+	}
+
+	if !matchesAny(f.authors, identity(commit.Author())) {
+		return false
+	}
+	if !matchesAny(f.committers, identity(commit.Committer())) {
+		return false
+	}
+
+	return f.matchesMessage(commit.Message())
+}
+
+func (f *commitFilter) matchesMessage(message string) bool {
+	if !f.options.AllMatch {
+		return matchesAny(f.grep, message)
+	}
+	for _, re := range f.grep {
+		if !re.MatchString(message) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesDiff applies -S and -G, which look at the changes each commit
+// makes to the paths being listed. Merges have no single diff to search, so
+// they never match, the same as when git log is not asked to diff them.
+func (r *RevList) matchesDiff(commit *database.Commit) bool {
+	pickaxe, diffGrep := r.matcher.pickaxe, r.matcher.diffGrep
+	if pickaxe == nil && diffGrep == nil {
+		return true
+	}
+	if commit.IsMerge() {
+		return false
+	}
+
+	for _, change := range r.TreeDiff(commit.Parent(), commit.Oid(), nil) {
+		a, b := r.blobData(change[0]), r.blobData(change[1])
+
+		if pickaxe != nil && len(pickaxe.FindAllStringIndex(a, -1)) != len(pickaxe.FindAllStringIndex(b, -1)) {
+			return true
+		}
+		if diffGrep != nil && r.changedLineMatches(diffGrep, a, b) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RevList) changedLineMatches(re *regexp.Regexp, a, b string) bool {
+	if isBinary(a) || isBinary(b) {
+		return false
+	}
+
+	for _, edit := range diff.DiffWith(r.repo.DiffAlgorithm(), a, b) {
+		line := edit.ALine()
+		switch edit.Type() {
+		case diff.EQL:
+			continue
+		case diff.INS:
+			line = edit.BLine()
+		}
+		if re.MatchString(strings.TrimSuffix(line.Text, "\n")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RevList) blobData(entry database.TreeObject) string {
+	if entry == nil || entry.IsNil() {
+		return ""
+	}
+	blob, err := r.repo.Database.Load(entry.Oid())
+	if err != nil {
+		return ""
+	}
+	return blob.String()
+}
+
+func isBinary(data string) bool {
+	return strings.IndexByte(data, 0) >= 0
+}
+
+func matchesAny(patterns []*regexp.Regexp, text string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, re := range patterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+func identity(author *database.Author) string {
+	return fmt.Sprintf("%s <%s>", author.Name, author.Email)
+}
+
+var (
+	RELATIVE_DATE = regexp.MustCompile(`^(\d+)[ .]*(second|minute|hour|day|week|month|year)s?[ .]*ago$`)
+	DATE_FORMATS  = []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05 -0700",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+		"Mon Jan 2 15:04:05 2006 -0700",
+	}
+)
+
+// ParseDate understands the forms of date most often given to --since and
+// --until: absolute dates and times, unix timestamps, and relative phrases
+// such as "2 weeks ago" or "yesterday".
+func ParseDate(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	switch strings.ToLower(value) {
+	case "now":
+		return now, nil
+	case "today":
+		return startOfDay(now), nil
+	case "yesterday":
+		return startOfDay(now).AddDate(0, 0, -1), nil
+	}
+
+	if match := RELATIVE_DATE.FindStringSubmatch(strings.ToLower(value)); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		switch match[2] {
+		case "second":
+			return now.Add(-time.Duration(n) * time.Second), nil
+		case "minute":
+			return now.Add(-time.Duration(n) * time.Minute), nil
+		case "hour":
+			return now.Add(-time.Duration(n) * time.Hour), nil
+		case "day":
+			return now.AddDate(0, 0, -n), nil
+		case "week":
+			return now.AddDate(0, 0, -7*n), nil
+		case "month":
+			return now.AddDate(0, -n, 0), nil
+		case "year":
+			return now.AddDate(-n, 0, 0), nil
+		}
+	}
+
+	if seconds, err := strconv.ParseInt(strings.TrimPrefix(value, "@"), 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	for _, format := range DATE_FORMATS {
+		if t, err := time.ParseInLocation(format, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date '%s'", value)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
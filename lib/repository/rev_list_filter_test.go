@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func assertParseDate(t *testing.T, value string, expected time.Time) {
+	now := time.Date(2023, 4, 12, 15, 30, 0, 0, time.UTC)
+	result, err := ParseDate(value, now)
+	if err != nil {
+		t.Fatalf("want %q to parse, but got %v", value, err)
+	}
+	if !result.Equal(expected) {
+		t.Errorf("want %v, but got %v", expected, result)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	t.Run("parses now", func(t *testing.T) {
+		assertParseDate(t, "now", time.Date(2023, 4, 12, 15, 30, 0, 0, time.UTC))
+	})
+
+	t.Run("parses yesterday", func(t *testing.T) {
+		assertParseDate(t, "yesterday", time.Date(2023, 4, 11, 0, 0, 0, 0, time.UTC))
+	})
+
+	t.Run("parses a relative date", func(t *testing.T) {
+		assertParseDate(t, "2 weeks ago", time.Date(2023, 3, 29, 15, 30, 0, 0, time.UTC))
+	})
+
+	t.Run("parses a relative date with dots", func(t *testing.T) {
+		assertParseDate(t, "3.hours.ago", time.Date(2023, 4, 12, 12, 30, 0, 0, time.UTC))
+	})
+
+	t.Run("parses a unix timestamp", func(t *testing.T) {
+		assertParseDate(t, "@1681313400", time.Date(2023, 4, 12, 15, 30, 0, 0, time.UTC))
+	})
+
+	t.Run("parses a date", func(t *testing.T) {
+		assertParseDate(t, "2023-01-05", time.Date(2023, 1, 5, 0, 0, 0, 0, time.UTC))
+	})
+
+	t.Run("parses a date and time with a zone", func(t *testing.T) {
+		assertParseDate(t, "2023-01-05 10:00:00 +0900", time.Date(2023, 1, 5, 1, 0, 0, 0, time.UTC))
+	})
+
+	t.Run("rejects an unknown date", func(t *testing.T) {
+		if _, err := ParseDate("next tuesday", time.Now()); err == nil {
+			t.Errorf("want an error, but got none")
+		}
+	})
+}
@@ -6,12 +6,16 @@ import (
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
 )
 
 var (
 	INVALID_NAME = regexp.MustCompile(`^\.|\/\.|\.\.|^\/|\/$|\.lock$|@\{|[\x00-\x20*:?\[\\^~\x7f]`)
 	PARENT       = regexp.MustCompile(`^(.+)\^(\d*)$`)
 	ANCESTOR     = regexp.MustCompile(`^(.+)~(\d+)$`)
+	REFLOG       = regexp.MustCompile(`^(.*)@\{(\d+)\}$`)
+	PREVIOUS     = regexp.MustCompile(`^@\{-(\d+)\}$`)
+	TREE_PATH    = regexp.MustCompile(`^([^:]+):(.*)$`)
 	REF_ALIASES  = map[string]string{
 		"@": HEAD,
 	}
@@ -68,8 +72,10 @@ func (r *Revision) Resolve(otype string) (string, error) {
 
 	oid, _ := r.query.resolve(r)
 	if otype != "" {
-		if _, err := r.loadTypedObject(oid, otype); err != nil {
+		if object, err := r.loadTypedObject(oid, otype); err != nil {
 			oid = ""
+		} else {
+			oid = object.Oid()
 		}
 	}
 
@@ -98,6 +104,43 @@ func (r *Revision) readRef(name string) (string, error) {
 	return "", nil
 }
 
+func (r *Revision) reflogEntry(name string, n int) (string, error) {
+	refName, err := r.reflogName(name)
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := r.repo.Refs.Reflog().Read(refName)
+	if err != nil {
+		return "", err
+	}
+	if n >= len(entries) {
+		message := fmt.Sprintf("log for '%s' only has %d entries", name, len(entries))
+		r.Errors = append(r.Errors, HintedError{message, []string{}})
+		return "", nil
+	}
+	return entries[len(entries)-1-n].NewOid, nil
+}
+
+func (r *Revision) reflogName(name string) (string, error) {
+	if name == "" || name == "@" {
+		current, err := r.repo.Refs.CurrentRef("")
+		if err != nil {
+			return "", err
+		}
+		return current.Path, nil
+	}
+	return r.repo.Refs.ExpandName(name)
+}
+
+func (r *Revision) previousCheckout(n int) (string, error) {
+	name, err := r.repo.Refs.PreviousBranch(n)
+	if err != nil || name == "" {
+		return "", err
+	}
+	return r.readRef(name)
+}
+
 func (r *Revision) commitParent(oid string, n int) (string, error) {
 	if n == 0 {
 		n = 1
@@ -118,17 +161,61 @@ func (r *Revision) commitParent(oid string, n int) (string, error) {
 	return "", nil
 }
 
+// treeEntry finds the object at path in the tree of the commit or tree
+// named by oid. An empty path names the tree itself.
+func (r *Revision) treeEntry(oid, rev, path string) (string, error) {
+	if oid == "" {
+		return "", nil
+	}
+	object, err := r.repo.Database.Peel(oid)
+	if err != nil {
+		return "", err
+	}
+	switch object := object.(type) {
+	case *database.Commit:
+		oid = object.Tree()
+	case *database.Tree:
+		oid = object.Oid()
+	default:
+		message := fmt.Sprintf("object %s is a %s, not a tree", oid, object.Type())
+		r.Errors = append(r.Errors, HintedError{message, []string{}})
+		return "", nil
+	}
+
+	for _, name := range strings.Split(path, "/") {
+		if name == "" {
+			continue
+		}
+		object, err := r.repo.Database.Load(oid)
+		if err != nil {
+			return "", err
+		}
+		tree, ok := object.(*database.Tree)
+		var entry database.TreeObject
+		if ok {
+			entry, ok = tree.Entries[name]
+		}
+		if !ok {
+			message := fmt.Sprintf("path '%s' does not exist in '%s'", path, rev)
+			r.Errors = append(r.Errors, HintedError{message, []string{}})
+			return "", nil
+		}
+		oid = entry.Oid()
+	}
+	return oid, nil
+}
+
 func (r *Revision) loadTypedObject(oid, otype string) (database.GitObject, error) {
 	if oid == "" {
 		return nil, fmt.Errorf("oid is empty")
 	}
 
-	obj, err := r.repo.Database.Load(oid)
+	obj, err := r.repo.Database.Peel(oid)
 
 	if err != nil {
 		return nil, err
 	}
-	if obj.Type() == COMMIT {
+	if obj.Type() == otype {
 		return obj, nil
 	}
 
@@ -166,7 +253,11 @@ func (r *Revision) logAmbiguousSha1(name string, candidates []string) error {
 }
 
 func parse(revision string) ParsedRevision {
-	if match := PARENT.FindStringSubmatch(revision); match != nil {
+	if match := TREE_PATH.FindStringSubmatch(revision); match != nil {
+		if rev := parse(match[1]); rev != nil {
+			return &TreePath{rev, match[1], match[2]}
+		}
+	} else if match := PARENT.FindStringSubmatch(revision); match != nil {
 		rev := parse(match[1])
 		n := 1
 		if match[2] != "" {
@@ -181,6 +272,14 @@ func parse(revision string) ParsedRevision {
 			n, _ := strconv.Atoi(match[2])
 			return &Ancestor{rev, n}
 		}
+	} else if match := PREVIOUS.FindStringSubmatch(revision); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		return &PreviousCheckout{n}
+	} else if match := REFLOG.FindStringSubmatch(revision); match != nil {
+		if match[1] == "" || IsValidRef(match[1]) {
+			n, _ := strconv.Atoi(match[2])
+			return &ReflogRef{match[1], n}
+		}
 	} else if IsValidRef(revision) {
 		name := REF_ALIASES[revision]
 		if name == "" {
@@ -199,6 +298,23 @@ func (r *ref) resolve(context *Revision) (string, error) {
 	return context.readRef(r.name)
 }
 
+type ReflogRef struct {
+	name string
+	n    int
+}
+
+func (r *ReflogRef) resolve(context *Revision) (string, error) {
+	return context.reflogEntry(r.name, r.n)
+}
+
+type PreviousCheckout struct {
+	n int
+}
+
+func (p *PreviousCheckout) resolve(context *Revision) (string, error) {
+	return context.previousCheckout(p.n)
+}
+
 type Parent struct {
 	rev ParsedRevision
 	n   int
@@ -225,3 +341,14 @@ func (a *Ancestor) resolve(context *Revision) (string, error) {
 	}
 	return oid, nil
 }
+
+type TreePath struct {
+	rev  ParsedRevision
+	name string
+	path string
+}
+
+func (t *TreePath) resolve(context *Revision) (string, error) {
+	oid, _ := t.rev.resolve(context)
+	return context.treeEntry(oid, t.name, t.path)
+}
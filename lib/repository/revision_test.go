@@ -64,4 +64,13 @@ func TestParse(t *testing.T) {
 			},
 		)
 	})
+
+	t.Run("parses a path in a revision", func(t *testing.T) {
+		assertParse(t, "HEAD~2:lib/file.txt",
+			&TreePath{&Ancestor{&ref{"HEAD"}, 2}, "HEAD~2", "lib/file.txt"})
+	})
+
+	t.Run("parses the root tree of a revision", func(t *testing.T) {
+		assertParse(t, "master^:", &TreePath{&Parent{&ref{"master"}, 1}, "master^", ""})
+	})
 }
@@ -7,12 +7,53 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 )
 
 var UNSAFE_MESSAGE = "You seem to have moved HEAD. Not rewinding, check your HEAD!"
 
+const (
+	PICK   = "pick"
+	REVERT = "revert"
+	REWORD = "reword"
+	EDIT   = "edit"
+	SQUASH = "squash"
+	FIXUP  = "fixup"
+	EXEC   = "exec"
+	BREAK  = "break"
+	DROP   = "drop"
+)
+
+var SEQUENCER_ACTIONS = map[string]string{
+	PICK:   PICK,
+	REVERT: REVERT,
+	REWORD: REWORD,
+	EDIT:   EDIT,
+	SQUASH: SQUASH,
+	FIXUP:  FIXUP,
+	EXEC:   EXEC,
+	BREAK:  BREAK,
+	DROP:   DROP,
+	"p":    PICK,
+	"r":    REWORD,
+	"e":    EDIT,
+	"s":    SQUASH,
+	"f":    FIXUP,
+	"x":    EXEC,
+	"b":    BREAK,
+	"d":    DROP,
+}
+
+type SequencerCommand struct {
+	Action string
+	Commit *database.Commit
+	Exec   string
+}
+
+func (c *SequencerCommand) IsPick() bool {
+	return c.Commit != nil && c.Action != DROP
+}
+
 type Sequencer struct {
 	repo      *Repository
 	pathname  string
@@ -20,7 +61,7 @@ type Sequencer struct {
 	headPath  string
 	todoPath  string
 	todoFile  *lockfile.Lockfile
-	commands  []*database.Commit
+	commands  []*SequencerCommand
 }
 
 func NewSequencer(repo *Repository) *Sequencer {
@@ -31,7 +72,7 @@ func NewSequencer(repo *Repository) *Sequencer {
 		abortPath: filepath.Join(pathname, "abort-safety"),
 		headPath:  filepath.Join(pathname, "head"),
 		todoPath:  filepath.Join(pathname, "todo"),
-		commands:  []*database.Commit{},
+		commands:  []*SequencerCommand{},
 	}
 }
 
@@ -46,10 +87,18 @@ func (s *Sequencer) Start() {
 }
 
 func (s *Sequencer) Pick(commit *database.Commit) {
-	s.commands = append(s.commands, commit)
+	s.commands = append(s.commands, &SequencerCommand{Action: PICK, Commit: commit})
+}
+
+func (s *Sequencer) Revert(commit *database.Commit) {
+	s.commands = append(s.commands, &SequencerCommand{Action: REVERT, Commit: commit})
+}
+
+func (s *Sequencer) Push(command *SequencerCommand) {
+	s.commands = append(s.commands, command)
 }
 
-func (s *Sequencer) NextCommand() *database.Commit {
+func (s *Sequencer) NextCommand() *SequencerCommand {
 	if len(s.commands) == 0 {
 		return nil
 	}
@@ -81,16 +130,9 @@ func (s *Sequencer) Load() {
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
-	regex := regexp.MustCompile(`^pick (\S+) (.*)$`)
-
 	for scanner.Scan() {
-		line := scanner.Text()
-		matches := regex.FindStringSubmatch(line)
-		if matches != nil {
-			oid := matches[1]
-			oids, _ := s.repo.Database.PrefixMatch(oid)
-			obj, _ := s.repo.Database.Load(oids[0])
-			s.commands = append(s.commands, obj.(*database.Commit))
+		if command, err := s.ParseCommand(scanner.Text()); err == nil && command != nil {
+			s.commands = append(s.commands, command)
 		}
 	}
 
@@ -104,14 +146,71 @@ func (s *Sequencer) Dump() {
 		return
 	}
 
-	for _, commit := range s.commands {
-		short := s.repo.Database.ShortOid(commit.Oid())
-		s.todoFile.Write([]byte(fmt.Sprintf("pick %s %s\n", short, commit.TitleLine())))
+	for _, command := range s.commands {
+		s.todoFile.Write([]byte(s.FormatCommand(command) + "\n"))
 	}
 	s.todoFile.Commit()
 	fmt.Println()
 }
 
+func (s *Sequencer) Pause() {
+	headOid, _ := s.repo.Refs.ReadHead()
+	s.writeFile(s.abortPath, headOid)
+	s.Dump()
+}
+
+func (s *Sequencer) FormatCommand(command *SequencerCommand) string {
+	switch {
+	case command.Action == EXEC:
+		return fmt.Sprintf("%s %s", command.Action, command.Exec)
+	case command.Commit == nil:
+		return command.Action
+	}
+	short := s.repo.Database.ShortOid(command.Commit.Oid())
+	return fmt.Sprintf("%s %s %s", command.Action, short, command.Commit.TitleLine())
+}
+
+func (s *Sequencer) ParseCommand(line string) (*SequencerCommand, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	fields := strings.SplitN(line, " ", 3)
+	action, ok := SEQUENCER_ACTIONS[fields[0]]
+	if !ok {
+		return nil, fmt.Errorf("invalid command '%s'", fields[0])
+	}
+
+	switch action {
+	case BREAK:
+		return &SequencerCommand{Action: action}, nil
+	case EXEC:
+		command := strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+		if command == "" {
+			return nil, fmt.Errorf("missing command after 'exec'")
+		}
+		return &SequencerCommand{Action: action, Exec: command}, nil
+	}
+
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("missing commit after '%s'", action)
+	}
+	oids, err := s.repo.Database.PrefixMatch(fields[1])
+	if err != nil || len(oids) != 1 {
+		return nil, fmt.Errorf("could not parse '%s'", fields[1])
+	}
+	object, err := s.repo.Database.Load(oids[0])
+	if err != nil {
+		return nil, err
+	}
+	commit, ok := object.(*database.Commit)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a commit", fields[1])
+	}
+	return &SequencerCommand{Action: action, Commit: commit}, nil
+}
+
 func (s *Sequencer) Abort() error {
 	head, _ := os.ReadFile(s.headPath)
 	headOid := strings.TrimSpace(string(head))
@@ -124,7 +223,7 @@ func (s *Sequencer) Abort() error {
 		return fmt.Errorf(UNSAFE_MESSAGE)
 	}
 	s.repo.HardReset(headOid)
-	origHead, _ := s.repo.Refs.UpdateHead(headOid)
+	origHead, _ := s.repo.Refs.UpdateHead(headOid, fmt.Sprintf("reset: moving to %s", headOid))
 	s.repo.Refs.UpateRef(ORIG_HEAD, origHead)
 	return nil
 }
@@ -133,6 +232,27 @@ func (s *Sequencer) Quit() {
 	os.RemoveAll(s.pathname)
 }
 
+func (s *Sequencer) IsInProgress() bool {
+	fileInfo, err := os.Stat(s.pathname)
+	return err == nil && fileInfo.IsDir()
+}
+
+func (s *Sequencer) EditPath() string {
+	return filepath.Join(s.pathname, "git-rebase-todo")
+}
+
+func (s *Sequencer) WriteState(name, value string) {
+	s.writeFile(filepath.Join(s.pathname, name), value)
+}
+
+func (s *Sequencer) ReadState(name string) string {
+	data, err := os.ReadFile(filepath.Join(s.pathname, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
 func (s *Sequencer) writeFile(path, content string) {
 	lockfile := lockfile.NewLockfile(path)
 	lockfile.HoldForUpdate()
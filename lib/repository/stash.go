@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"building-git/lib/database"
+	"building-git/lib/index"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+const STASH_REF = "refs/stash"
+
+type StashOption struct {
+	Paths     []string
+	Untracked bool
+	Message   string
+}
+
+type Stash struct {
+	repo *Repository
+}
+
+func NewStash(repo *Repository) *Stash {
+	return &Stash{
+		repo: repo,
+	}
+}
+
+func StashName(n int) string {
+	return fmt.Sprintf("stash@{%d}", n)
+}
+
+func (s *Stash) List() ([]*ReflogEntry, error) {
+	entries, err := s.repo.Refs.Reflog().Read(STASH_REF)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*ReflogEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		list = append(list, entries[i])
+	}
+	return list, nil
+}
+
+func (s *Stash) Push(options StashOption, author *database.Author) (*database.Commit, error) {
+	headOid, _ := s.repo.Refs.ReadHead()
+	if headOid == "" {
+		return nil, fmt.Errorf("You do not have the initial commit yet")
+	}
+	if s.repo.Index.IsConflict() {
+		return nil, fmt.Errorf("cannot stash with unmerged paths")
+	}
+
+	status, err := s.repo.Status("")
+	if err != nil {
+		return nil, err
+	}
+	matcher := newStashPathspec(options.Paths)
+
+	scratch := index.NewIndex(filepath.Join(s.repo.GitPath, "index.stash"))
+	for path, entry := range status.HeadTree {
+		scratch.AddFromDb(path, entry)
+	}
+
+	changed := []string{}
+	status.IndexChanges.Iterate(func(path string, ctype ChangeType) {
+		if !matcher.matches(path) {
+			return
+		}
+		changed = append(changed, path)
+		if entry := s.repo.Index.EntryForPath(path, "0"); ctype != Deleted && entry != nil {
+			scratch.AddFromDb(path, database.NewEntry(entry.Oid(), entry.Mode()))
+		} else {
+			scratch.Remove(path)
+		}
+	})
+	indexTree := s.writeTree(scratch)
+
+	status.WorkspaceChanges.Iterate(func(path string, ctype ChangeType) {
+		if !matcher.matches(path) {
+			return
+		}
+		changed = append(changed, path)
+		if ctype == Deleted {
+			scratch.Remove(path)
+			return
+		}
+		if oid, stat, err := s.storeWorkspaceFile(path); err == nil {
+			scratch.Add(path, oid, stat)
+		}
+	})
+	workTree := s.writeTree(scratch)
+
+	untracked := []string{}
+	if options.Untracked {
+		untracked, err = s.untrackedFiles(status, matcher)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(changed) == 0 && len(untracked) == 0 {
+		return nil, nil
+	}
+
+	commit, err := s.writeCommits(headOid, indexTree, workTree, untracked, options.Message, author)
+	if err != nil {
+		return nil, err
+	}
+
+	reset := &HardReset{oid: headOid, repo: s.repo, status: status}
+	for _, path := range changed {
+		reset.resetPath(path)
+	}
+	for _, path := range untracked {
+		s.repo.Workspace.Remove(path)
+	}
+	return commit, nil
+}
+
+func (s *Stash) Drop(n int) (*ReflogEntry, error) {
+	reflog := s.repo.Refs.Reflog()
+	entries, err := reflog.Read(STASH_REF)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n >= len(entries) {
+		return nil, fmt.Errorf("%s is not a valid reference", StashName(n))
+	}
+
+	index := len(entries) - 1 - n
+	dropped := entries[index]
+	remaining := append(append([]*ReflogEntry{}, entries[:index]...), entries[index+1:]...)
+
+	if len(remaining) == 0 {
+		return dropped, s.repo.Refs.UpdateRef(STASH_REF, "", "")
+	}
+
+	for i, entry := range remaining {
+		entry.OldOid = ""
+		if i > 0 {
+			entry.OldOid = remaining[i-1].NewOid
+		}
+	}
+	top := remaining[len(remaining)-1]
+	if err := s.repo.Refs.UpdateRef(STASH_REF, top.NewOid, top.Message); err != nil {
+		return nil, err
+	}
+	return dropped, reflog.Write(STASH_REF, remaining)
+}
+
+func (s *Stash) writeCommits(headOid string, indexTree, workTree *database.Tree, untracked []string, message string, author *database.Author) (*database.Commit, error) {
+	head, err := s.repo.Database.Load(headOid)
+	if err != nil {
+		return nil, err
+	}
+	branch := "(no branch)"
+	if ref, err := s.repo.Refs.CurrentRef(""); err == nil && !ref.IsHead() {
+		branch, _ = ref.ShortName()
+	}
+	base := fmt.Sprintf("%s: %s %s", branch, s.repo.Database.ShortOid(headOid), head.(*database.Commit).TitleLine())
+
+	indexCommit := database.NewCommit([]string{headOid}, indexTree.Oid(), author, author, "index on "+base+"\n")
+	if err := s.repo.Database.Store(indexCommit); err != nil {
+		return nil, err
+	}
+	parents := []string{headOid, indexCommit.Oid()}
+
+	if len(untracked) > 0 {
+		scratch := index.NewIndex(filepath.Join(s.repo.GitPath, "index.stash"))
+		for _, path := range untracked {
+			oid, stat, err := s.storeWorkspaceFile(path)
+			if err != nil {
+				return nil, err
+			}
+			scratch.Add(path, oid, stat)
+		}
+		tree := s.writeTree(scratch)
+		untrackedCommit := database.NewCommit([]string{}, tree.Oid(), author, author, "untracked files on "+base+"\n")
+		if err := s.repo.Database.Store(untrackedCommit); err != nil {
+			return nil, err
+		}
+		parents = append(parents, untrackedCommit.Oid())
+	}
+
+	title := "WIP on " + base
+	if message != "" {
+		title = fmt.Sprintf("On %s: %s", branch, message)
+	}
+	commit := database.NewCommit(parents, workTree.Oid(), author, author, title+"\n")
+	if err := s.repo.Database.Store(commit); err != nil {
+		return nil, err
+	}
+	return commit, s.repo.Refs.UpdateRef(STASH_REF, commit.Oid(), title)
+}
+
+func (s *Stash) untrackedFiles(status *Status, matcher *stashPathspec) ([]string, error) {
+	files := []string{}
+
+	var err error
+	status.Untracked.Iterate(func(path string, _ struct{}) {
+		if err != nil {
+			return
+		}
+		var listed []string
+		listed, err = s.repo.Workspace.ListFiles(filepath.Join(s.repo.Workspace.pathname, path), s.repo.Index)
+		for _, file := range listed {
+			if matcher.matches(file) {
+				files = append(files, file)
+			}
+		}
+	})
+	return files, err
+}
+
+func (s *Stash) storeWorkspaceFile(path string) (string, fs.FileInfo, error) {
+	data, err := s.repo.Workspace.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	stat, err := s.repo.Workspace.StatFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	blob := database.NewBlob(data)
+	if err := s.repo.Database.Store(blob); err != nil {
+		return "", nil, err
+	}
+	return blob.Oid(), stat, nil
+}
+
+func (s *Stash) writeTree(scratch *index.Index) *database.Tree {
+	root := database.BuildTree(scratch.EachEntry())
+	root.Traverse(func(t database.TreeObject) {
+		if object, ok := t.(database.GitObject); ok {
+			s.repo.Database.Store(object)
+		}
+	})
+	return root
+}
+
+type stashPathspec struct {
+	paths []string
+}
+
+func newStashPathspec(paths []string) *stashPathspec {
+	cleaned := []string{}
+	for _, path := range paths {
+		cleaned = append(cleaned, filepath.Clean(path))
+	}
+	return &stashPathspec{paths: cleaned}
+}
+
+func (p *stashPathspec) matches(path string) bool {
+	if len(p.paths) == 0 {
+		return true
+	}
+	path = strings.TrimSuffix(path, "/")
+	for _, prefix := range p.paths {
+		if prefix == "." || path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
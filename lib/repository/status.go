@@ -15,6 +15,7 @@ const (
 	Modified
 	Unmodified
 	Untracked
+	Renamed
 )
 
 type Status struct {
@@ -26,6 +27,7 @@ type Status struct {
 	Conflicts        *sortedmap.SortedMap[[]string]
 	WorkspaceChanges *sortedmap.SortedMap[ChangeType]
 	Untracked        *sortedmap.SortedMap[struct{}]
+	Ignored          *sortedmap.SortedMap[struct{}]
 	HeadTree         map[string]*database.Entry
 }
 
@@ -39,6 +41,7 @@ func NewStatus(repo *Repository, commitOid string) (*Status, error) {
 		Conflicts:        sortedmap.NewSortedMap[[]string](),
 		WorkspaceChanges: sortedmap.NewSortedMap[ChangeType](),
 		Untracked:        sortedmap.NewSortedMap[struct{}](),
+		Ignored:          sortedmap.NewSortedMap[struct{}](),
 		HeadTree:         make(map[string]*database.Entry),
 	}
 
@@ -77,16 +80,50 @@ func (s *Status) scanWorkspace(prefix string) error {
 				s.scanWorkspace(path)
 			}
 			continue
+		} else if s.repo.Workspace.IsIgnored(path, stat.IsDir()) {
+			s.recordIgnored(path, stat.IsDir())
 		} else if s.inspector.isTrackableFile(path, stat) {
 			if stat.IsDir() {
+				for _, ignored := range s.scanIgnored(path) {
+					s.Ignored.Set(ignored, struct{}{})
+				}
 				path += string(filepath.Separator)
 			}
 			s.Untracked.Set(path, struct{}{})
+		} else if stat.IsDir() && len(s.scanIgnored(path)) > 0 {
+			s.recordIgnored(path, true)
 		}
 	}
 	return nil
 }
 
+func (s *Status) recordIgnored(path string, isDir bool) {
+	if isDir {
+		path += string(filepath.Separator)
+	}
+	s.Ignored.Set(path, struct{}{})
+}
+
+func (s *Status) scanIgnored(prefix string) []string {
+	files, err := s.repo.Workspace.ListDir(prefix)
+	if err != nil {
+		return nil
+	}
+
+	ignored := []string{}
+	for path, stat := range files {
+		if s.repo.Workspace.IsIgnored(path, stat.IsDir()) {
+			if stat.IsDir() {
+				path += string(filepath.Separator)
+			}
+			ignored = append(ignored, path)
+		} else if stat.IsDir() {
+			ignored = append(ignored, s.scanIgnored(path)...)
+		}
+	}
+	return ignored
+}
+
 func (s *Status) checkIndexEntries() {
 	for _, entry := range s.repo.Index.EachEntry() {
 		if entry.Stage() == "0" {
@@ -129,3 +166,19 @@ func (s *Status) collectDeletedHeadFiles() {
 		s.recordChange(path, s.IndexChanges, Deleted)
 	}
 }
+
+func (s *Status) IndexDiff() map[string][2]database.TreeObject {
+	changes := map[string][2]database.TreeObject{}
+
+	s.IndexChanges.Iterate(func(path string, _ ChangeType) {
+		images := [2]database.TreeObject{}
+		if entry, ok := s.HeadTree[path]; ok {
+			images[0] = entry
+		}
+		if entry := s.repo.Index.EntryForPath(path, "0"); entry != nil {
+			images[1] = entry
+		}
+		changes[path] = images
+	})
+	return changes
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"building-git/lib/index"
 	"building-git/lib/pathutils"
 	"fmt"
 	"io/fs"
@@ -20,14 +21,24 @@ var IGNORE = map[string]struct{}{
 
 type Workspace struct {
 	pathname string
+	ignore   *Ignore
 }
 
 func NewWorkspace(pathname string) *Workspace {
 	return &Workspace{
 		pathname: pathname,
+		ignore:   NewIgnore(pathname, filepath.Join(pathname, ".git"), nil),
 	}
 }
 
+func (w *Workspace) Ignore() *Ignore {
+	return w.ignore
+}
+
+func (w *Workspace) IsIgnored(path string, isDir bool) bool {
+	return w.ignore.IsIgnored(path, isDir)
+}
+
 func (w *Workspace) ListDir(dirname string) (map[string]os.FileInfo, error) {
 	path := filepath.Join(w.pathname, dirname)
 	files, err := ioutil.ReadDir(path)
@@ -49,8 +60,11 @@ func (w *Workspace) ListDir(dirname string) (map[string]os.FileInfo, error) {
 	return stats, nil
 }
 
-func (w *Workspace) ListFiles(path string) ([]string, error) {
+// ListFiles lists the files under path, leaving out those matched by the
+// ignore rules unless the index already tracks them.
+func (w *Workspace) ListFiles(path string, index *index.Index) ([]string, error) {
 	var files []string
+	root := path
 
 	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -67,6 +81,12 @@ func (w *Workspace) ListFiles(path string) ([]string, error) {
 				return nil
 			}
 		}
+		if path != root && !index.IsTracked(relativePath) && w.IsIgnored(relativePath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		if info.Mode().IsRegular() {
 			relative, err := filepath.Rel(w.pathname, path)
 			if err != nil {
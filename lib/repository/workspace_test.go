@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"building-git/lib/index"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -43,7 +44,7 @@ func TestListFiles(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	files, err := workspace.ListFiles(tmpDir)
+	files, err := workspace.ListFiles(tmpDir, index.NewIndex(filepath.Join(gitDir, "index")))
 	if err != nil {
 		t.Fatal(err)
 	}